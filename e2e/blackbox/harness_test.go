@@ -159,6 +159,10 @@ func normalizeRunbook(s string) string {
 			out = append(out, "Total duration: <normalized> ms")
 			continue
 		}
+		if strings.HasPrefix(line, "- Recorded under policy ") {
+			out = append(out, "- Recorded under policy `<normalized>`.")
+			continue
+		}
 		if stepTitleRE.MatchString(line) {
 			parts := strings.SplitN(line, "] ", 2)
 			if len(parts) == 2 {
@@ -0,0 +1,243 @@
+// Package audit keeps an append-only log of the policy decisions made while
+// recording. Events name the rule and the action taken, never the command
+// text, so the log holds no secrets.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+// FileName is the audit log inside a store directory.
+const FileName = "audit.jsonl"
+
+// Actions taken by the policy.
+const (
+	// ActionRedacted means a rule replaced part of the command.
+	ActionRedacted = "redacted"
+	// ActionDenied means the command was recorded as a placeholder.
+	ActionDenied = "denied"
+	// ActionBlocked means the command was not run.
+	ActionBlocked = "blocked"
+	// ActionConfirmed means a guarded command ran after typed confirmation.
+	ActionConfirmed = "confirmed"
+)
+
+// Sources of an event.
+const (
+	SourceRun  = "run"
+	SourceHook = "hook"
+)
+
+// Event is one policy decision.
+type Event struct {
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id"`
+	Env       string    `json:"env,omitempty"`
+	Source    string    `json:"source"`
+	Kind      string    `json:"kind"`
+	Rule      string    `json:"rule"`
+	Action    string    `json:"action"`
+}
+
+// StepEvents lists the decisions in e for a step of session. Denials become
+// blocks when blocked is set.
+func StepEvents(session *store.Session, source string, at time.Time, e policy.Explanation, blocked bool) []Event {
+	event := func(kind, rule, action string) Event {
+		return Event{Time: at.UTC(), SessionID: session.ID, Env: session.Env, Source: source, Kind: kind, Rule: rule, Action: action}
+	}
+	if e.DeniedBy != nil {
+		action := ActionDenied
+		if blocked {
+			action = ActionBlocked
+		}
+		return []Event{event(e.DeniedBy.Kind, e.DeniedBy.Rule, action)}
+	}
+	events := make([]Event, 0, len(e.Redactions))
+	for _, r := range e.Redactions {
+		events = append(events, event(r.Kind, r.Name, ActionRedacted))
+	}
+	return events
+}
+
+// Log appends events to, and reads them from, a JSON Lines file.
+type Log struct {
+	path string
+}
+
+// Open returns the audit log of the store at rootDir. The file is created on
+// the first Append.
+func Open(rootDir string) *Log {
+	return &Log{path: filepath.Join(rootDir, FileName)}
+}
+
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes events at the end of the log. Existing entries are never
+// rewritten.
+func (l *Log) Append(events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var buf strings.Builder
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode audit event: %w", err)
+		}
+		buf.Write(payload)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return fmt.Errorf("create audit directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	if _, err := file.WriteString(buf.String()); err != nil {
+		file.Close()
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return file.Close()
+}
+
+// Read returns every event in the log, oldest first. A missing log has no
+// events.
+func (l *Log) Read() ([]Event, error) {
+	file, err := os.Open(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(text), &event); err != nil {
+			return nil, fmt.Errorf("decode audit log line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan audit log: %w", err)
+	}
+	return events, nil
+}
+
+// Filter selects events. Zero fields match everything; Until is exclusive.
+type Filter struct {
+	Since     time.Time
+	Until     time.Time
+	SessionID string
+}
+
+func (f Filter) match(event Event) bool {
+	if !f.Since.IsZero() && event.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !event.Time.Before(f.Until) {
+		return false
+	}
+	return f.SessionID == "" || event.SessionID == f.SessionID
+}
+
+// RuleSummary counts the actions one rule took.
+type RuleSummary struct {
+	Kind      string    `json:"kind"`
+	Rule      string    `json:"rule"`
+	Redacted  int       `json:"redacted"`
+	Denied    int       `json:"denied"`
+	Blocked   int       `json:"blocked"`
+	Confirmed int       `json:"confirmed"`
+	Total     int       `json:"total"`
+	Last      time.Time `json:"last"`
+}
+
+// Summary is the audit log condensed by rule.
+type Summary struct {
+	Events   int           `json:"events"`
+	Sessions int           `json:"sessions"`
+	First    *time.Time    `json:"first,omitempty"`
+	Last     *time.Time    `json:"last,omitempty"`
+	Rules    []RuleSummary `json:"rules"`
+}
+
+// Summarize counts the events matching f by rule, busiest rule first.
+func Summarize(events []Event, f Filter) Summary {
+	summary := Summary{Rules: []RuleSummary{}}
+	byRule := make(map[[2]string]*RuleSummary)
+	sessions := make(map[string]bool)
+	for _, event := range events {
+		if !f.match(event) {
+			continue
+		}
+		summary.Events++
+		sessions[event.SessionID] = true
+		at := event.Time
+		if summary.First == nil || at.Before(*summary.First) {
+			summary.First = &at
+		}
+		if summary.Last == nil || at.After(*summary.Last) {
+			summary.Last = &at
+		}
+
+		key := [2]string{event.Kind, event.Rule}
+		rule, ok := byRule[key]
+		if !ok {
+			rule = &RuleSummary{Kind: event.Kind, Rule: event.Rule}
+			byRule[key] = rule
+		}
+		switch event.Action {
+		case ActionRedacted:
+			rule.Redacted++
+		case ActionDenied:
+			rule.Denied++
+		case ActionBlocked:
+			rule.Blocked++
+		case ActionConfirmed:
+			rule.Confirmed++
+		}
+		rule.Total++
+		if at.After(rule.Last) {
+			rule.Last = at
+		}
+	}
+	summary.Sessions = len(sessions)
+
+	for _, rule := range byRule {
+		summary.Rules = append(summary.Rules, *rule)
+	}
+	sort.Slice(summary.Rules, func(i, j int) bool {
+		a, b := summary.Rules[i], summary.Rules[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Rule < b.Rule
+	})
+	return summary
+}
@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestStepEventsNameRulesWithoutCommandText(t *testing.T) {
+	t.Parallel()
+
+	p := policy.NewDefault()
+	session := &store.Session{ID: "s1", Env: "prod"}
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	events := StepEvents(session, SourceRun, at, p.Explain("curl --token=hunter2 https://x", nil), false)
+	if len(events) == 0 || events[0].Action != ActionRedacted || events[0].SessionID != "s1" || events[0].Env != "prod" {
+		t.Fatalf("unexpected redaction events: %+v", events)
+	}
+	for _, event := range events {
+		if strings.Contains(event.Rule, "hunter2") {
+			t.Fatalf("event leaked the secret: %+v", event)
+		}
+	}
+
+	events = StepEvents(session, SourceRun, at, p.Explain("printenv", nil), true)
+	if len(events) != 1 || events[0].Action != ActionBlocked || events[0].Kind != policy.RuleBuiltin {
+		t.Fatalf("unexpected block events: %+v", events)
+	}
+	if events := StepEvents(session, SourceHook, at, p.Explain("ls -la", nil), false); len(events) != 0 {
+		t.Fatalf("allowed command produced events: %+v", events)
+	}
+}
+
+func TestLogAppendsAndSummarizes(t *testing.T) {
+	t.Parallel()
+
+	log := Open(t.TempDir())
+	if events, err := log.Read(); err != nil || len(events) != 0 {
+		t.Fatalf("missing log should be empty: %v %v", events, err)
+	}
+
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	if err := log.Append(
+		Event{Time: day.Add(time.Hour), SessionID: "a", Kind: "denylist", Rule: "vault read*", Action: ActionBlocked},
+		Event{Time: day.Add(2 * time.Hour), SessionID: "a", Kind: "denylist", Rule: "vault read*", Action: ActionDenied},
+	); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := log.Append(Event{Time: day.AddDate(0, 0, 2), SessionID: "b", Kind: "detector", Rule: "aws_access_key", Action: ActionRedacted}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if info, err := os.Stat(log.Path()); err != nil || info.Mode().Perm()&0o077 != 0 && os.PathSeparator == '/' {
+		t.Fatalf("audit log should be private: %v %v", info, err)
+	}
+
+	events, err := log.Read()
+	if err != nil || len(events) != 3 {
+		t.Fatalf("Read = %d events, %v", len(events), err)
+	}
+
+	all := Summarize(events, Filter{})
+	if all.Events != 3 || all.Sessions != 2 || len(all.Rules) != 2 {
+		t.Fatalf("unexpected summary: %+v", all)
+	}
+	if top := all.Rules[0]; top.Rule != "vault read*" || top.Blocked != 1 || top.Denied != 1 || top.Total != 2 {
+		t.Fatalf("busiest rule should come first: %+v", top)
+	}
+
+	firstDay := Summarize(events, Filter{Since: day, Until: day.AddDate(0, 0, 1)})
+	if firstDay.Events != 2 || len(firstDay.Rules) != 1 {
+		t.Fatalf("time range not applied: %+v", firstDay)
+	}
+	if bySession := Summarize(events, Filter{SessionID: "b"}); bySession.Events != 1 || bySession.Rules[0].Redacted != 1 {
+		t.Fatalf("session filter not applied: %+v", bySession)
+	}
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// secretFinding locates text the current policy would redact. It never holds
+// the text itself.
+type secretFinding struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Session string `json:"session,omitempty"`
+	Step    int    `json:"step,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Kind    string `json:"kind"`
+	Rule    string `json:"rule"`
+}
+
+type secretAuditReport struct {
+	Sessions int             `json:"sessions"`
+	Runbooks int             `json:"runbooks"`
+	Findings []secretFinding `json:"findings"`
+}
+
+func newAuditCmd(rt *storeRuntime) *cobra.Command {
+	var (
+		runbooksDir string
+		jsonMode    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Scan stored sessions and exported runbooks for secrets",
+		Long: "Check every stored session, the active one included, and the Markdown\n" +
+			"runbooks in the export directory against the current redaction rules and\n" +
+			"secret detectors. Findings name the location and the rule, never the\n" +
+			"secret. Exits with code 1 when anything is found, for use in pre-commit\n" +
+			"hooks.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			report := secretAuditReport{Findings: []secretFinding{}}
+
+			policies := make(map[string]*policy.Policy)
+			err := rt.store.EachSession(cmd.Context(), func(src store.SessionSource, session *store.Session) error {
+				p, ok := policies[session.Env]
+				if !ok {
+					p = rt.profile(session.Env).Policy
+					policies[session.Env] = p
+				}
+				report.Sessions++
+				report.Findings = append(report.Findings, auditSession(rt.displayPath(src.Path), src.Line, session, p)...)
+				return nil
+			})
+			if err != nil && !errors.Is(err, store.ErrNotInitialized) {
+				return fmt.Errorf("scan sessions: %w", err)
+			}
+
+			if runbooksDir == "" {
+				runbooksDir = rt.runbooksDir(rt.workingDir)
+			}
+			paths, err := filepath.Glob(filepath.Join(runbooksDir, "*.md"))
+			if err != nil {
+				return fmt.Errorf("list runbooks: %w", err)
+			}
+			sort.Strings(paths)
+			for _, path := range paths {
+				findings, err := auditRunbook(path, rt.displayPath(path), rt.policy)
+				if err != nil {
+					return err
+				}
+				report.Runbooks++
+				report.Findings = append(report.Findings, findings...)
+			}
+
+			out := cmd.OutOrStdout()
+			if jsonMode {
+				if err := writeJSON(out, report); err != nil {
+					return err
+				}
+			} else {
+				printSecretAudit(out, report)
+			}
+			if len(report.Findings) > 0 {
+				return &ExitError{Code: 1, Err: fmt.Errorf("audit found %d possible secret(s)", len(report.Findings))}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&runbooksDir, "runbooks", "", "Directory of Markdown runbooks to scan (default: export.output_dir)")
+	cmd.Flags().BoolVar(&jsonMode, "json", false, "Print the findings as JSON")
+	return cmd
+}
+
+func auditSession(file string, line int, session *store.Session, p *policy.Policy) []secretFinding {
+	var findings []secretFinding
+	add := func(step int, field string, matches []policy.SecretMatch) {
+		for _, m := range matches {
+			findings = append(findings, secretFinding{
+				File:    file,
+				Line:    line,
+				Session: session.ID,
+				Step:    step,
+				Field:   field,
+				Kind:    m.Kind,
+				Rule:    m.Rule,
+			})
+		}
+	}
+	add(0, "title", p.FindSecrets(session.Title))
+	for i, step := range session.Steps {
+		if step.Command != policy.DeniedPlaceholder {
+			add(i+1, "command", p.FindSecrets(step.Command))
+		}
+		if step.CWD != "" {
+			add(i+1, "cwd", p.FindCWDSecrets(step.CWD))
+		}
+	}
+	return findings
+}
+
+// runbookStepTitle matches the "1. [OK] " prefix of a step title in an
+// exported runbook; the rest of the line is the command.
+var runbookStepTitle = regexp.MustCompile(`^\d+\. \[[A-Z]+\] `)
+
+func auditRunbook(path, display string, p *policy.Policy) ([]secretFinding, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open runbook: %w", err)
+	}
+	defer file.Close()
+
+	var findings []secretFinding
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := runbookStepTitle.ReplaceAllString(scanner.Text(), "")
+		for _, m := range p.FindSecrets(text) {
+			findings = append(findings, secretFinding{File: display, Line: line, Kind: m.Kind, Rule: m.Rule})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read runbook %s: %w", display, err)
+	}
+	return findings, nil
+}
+
+func printSecretAudit(out io.Writer, report secretAuditReport) {
+	for _, f := range report.Findings {
+		location := f.File
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		var where []string
+		if f.Session != "" {
+			where = append(where, "session "+f.Session)
+		}
+		if f.Step > 0 {
+			where = append(where, fmt.Sprintf("step %d", f.Step))
+		}
+		if f.Field != "" {
+			where = append(where, f.Field)
+		}
+		if len(where) > 0 {
+			location += " (" + strings.Join(where, ", ") + ")"
+		}
+		fmt.Fprintf(out, "%s: %s %s\n", location, redactionKindLabel(f.Kind), f.Rule)
+	}
+
+	summary := fmt.Sprintf("Scanned %d session(s) and %d runbook(s)", report.Sessions, report.Runbooks)
+	if len(report.Findings) == 0 {
+		printOK(out, "%s: no secrets found", summary)
+		return
+	}
+	printWarn(out, "%s: %d possible secret(s). Run `cmdry sessions redact --all` for stored sessions and re-export affected runbooks.", summary, len(report.Findings))
+}
+
+// displayPath shortens paths under the working directory for readable output.
+func (rt *storeRuntime) displayPath(path string) string {
+	if rel, err := filepath.Rel(rt.workingDir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return rel
+	}
+	return path
+}
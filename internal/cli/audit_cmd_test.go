@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestAuditReportsSecretLocationsWithoutValues(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	const secret = "acme_live_0123456789abcdefABCDEF0123456789"
+	started := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	ended := started.Add(time.Hour)
+	seeded := store.Session{
+		ID:        store.NewSessionID(started),
+		Title:     "Old deploy",
+		StartedAt: started,
+		EndedAt:   &ended,
+		Steps: []store.Step{
+			{Timestamp: started, Command: "make build", Status: "OK"},
+			{Timestamp: started, Command: "deploy --key " + secret, Status: "OK"},
+		},
+	}
+	if err := store.NewJSONStore(dir).ReplaceSessions(context.Background(), nil, []store.Session{seeded}); err != nil {
+		t.Fatalf("seed session: %v", err)
+	}
+	content := "policy:\n  redaction_rules:\n    - name: acme\n      pattern: 'acme_live_[A-Za-z0-9]{32}'\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	runbooks := t.TempDir()
+	runbook := filepath.Join(runbooks, "deploy.md")
+	if err := os.WriteFile(runbook, []byte("# Deploy\n\n```bash\ndeploy --key "+secret+"\n```\n"), 0o600); err != nil {
+		t.Fatalf("write runbook: %v", err)
+	}
+
+	audit := func(args ...string) (string, error) {
+		t.Helper()
+		root, err := NewRootCommand()
+		if err != nil {
+			t.Fatalf("NewRootCommand failed: %v", err)
+		}
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetErr(&out)
+		root.SetArgs(append([]string{"audit", "--runbooks", runbooks}, args...))
+		err = root.Execute()
+		return out.String(), err
+	}
+
+	out, err := audit()
+	var exitErr *ExitError
+	if !asExitErrorCLI(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("expected exit code 1, got %v\n%s", err, out)
+	}
+	if strings.Contains(out, secret) || strings.Contains(out, "acme_live_") {
+		t.Fatalf("audit must not print the secret: %s", out)
+	}
+	for _, want := range []string{
+		filepath.Join(dir, "sessions.jsonl") + ":1 (session " + seeded.ID + ", step 2, command): redaction rule acme",
+		runbook + ":4: redaction rule acme",
+		"Scanned 1 session(s) and 1 runbook(s): 2 possible secret(s)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("audit output missing %q:\n%s", want, out)
+		}
+	}
+
+	mustExecute(t, "sessions", "redact", "--all")
+	out, _ = audit("--json")
+	var report secretAuditReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].File != runbook || report.Findings[0].Line != 4 {
+		t.Fatalf("only the runbook should still leak: %+v", report.Findings)
+	}
+
+	if err := os.Remove(runbook); err != nil {
+		t.Fatalf("remove runbook: %v", err)
+	}
+	if out, err := audit(); err != nil || !strings.Contains(out, "no secrets found") {
+		t.Fatalf("expected a clean audit, got %v\n%s", err, out)
+	}
+}
+
+func TestAuditKeepsKubectlSetImageAssignments(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+	runbooks := filepath.Join(t.TempDir(), "runbooks")
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("export:\n  output_dir: "+runbooks+"\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	mustExecute(t, "hooks", "enable")
+	mustExecute(t, "start", "Roll out nginx")
+	mustExecute(t, "hook", "record", "--command", "kubectl set image deployment/app app=nginx:1.2")
+	mustExecute(t, "stop")
+	mustExecute(t, "export", "--last", "-f", "md", "--no-annotate")
+
+	root, err := NewRootCommand()
+	if err != nil {
+		t.Fatalf("NewRootCommand failed: %v", err)
+	}
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"audit", "--runbooks", runbooks})
+	if err := root.Execute(); err != nil || !strings.Contains(out.String(), "no secrets found") {
+		t.Fatalf("set image should not be reported, got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "1 runbook(s)") {
+		t.Fatalf("expected the exported runbook to be scanned:\n%s", out.String())
+	}
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/config"
+	"github.com/fixi2/Commandry/internal/export"
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func newConfigCmd(rt *storeRuntime) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect config.yaml",
+	}
+	cmd.AddCommand(newConfigValidateCmd(rt))
+	return cmd
+}
+
+type configReport struct {
+	Path     string           `json:"path"`
+	Exists   bool             `json:"exists"`
+	Valid    bool             `json:"valid"`
+	Error    string           `json:"error,omitempty"`
+	Warnings []config.Warning `json:"warnings"`
+	Config   *config.Config   `json:"config,omitempty"`
+}
+
+func newConfigValidateCmd(rt *storeRuntime) *cobra.Command {
+	var (
+		file     string
+		strict   bool
+		show     bool
+		jsonMode bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check config.yaml for errors and unknown keys",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path := file
+			if strings.TrimSpace(path) == "" {
+				path = config.Path(rt.location.Dir)
+			}
+			report := validateConfigFile(path)
+
+			out := cmd.OutOrStdout()
+			if jsonMode {
+				if err := writeJSON(out, report); err != nil {
+					return err
+				}
+			} else {
+				printConfigReport(out, report, show)
+			}
+
+			if !report.Valid {
+				if jsonMode {
+					return &ExitError{Code: 1, Err: errors.New("config is invalid")}
+				}
+				return fmt.Errorf("config is invalid: %s", report.Error)
+			}
+			if strict && len(report.Warnings) > 0 {
+				return &ExitError{Code: 1, Err: fmt.Errorf("config has %d warning(s) and --strict is set", len(report.Warnings))}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Validate this file instead of the store's config.yaml")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Exit non-zero when there are warnings")
+	cmd.Flags().BoolVar(&show, "show", false, "Print the effective configuration after defaults are applied")
+	cmd.Flags().BoolVar(&jsonMode, "json", false, "Print the result as JSON")
+	return cmd
+}
+
+func validateConfigFile(path string) configReport {
+	report := configReport{Path: path, Warnings: []config.Warning{}}
+	if _, err := os.Stat(path); err == nil {
+		report.Exists = true
+	}
+
+	cfg, warnings, err := config.Load(path)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	// Patterns are only compiled by the policy package, so build it to surface
+	// bad denylist entries and redaction rules here rather than at record time.
+	if _, err := policy.NewFromConfig(cfg.Policy); err != nil {
+		report.Error = err.Error()
+		var ruleErr *policy.RuleError
+		if errors.As(err, &ruleErr) {
+			path := fmt.Sprintf("policy.redaction_rules[%d].%s", ruleErr.Index, ruleErr.Field)
+			line := cfg.Line(path)
+			if line == 0 {
+				line = cfg.Line("policy.redaction_rules")
+			}
+			if line > 0 {
+				report.Error = fmt.Sprintf("line %d: %s", line, err)
+			}
+		}
+		return report
+	}
+	// Detector names live in the policy package, so they are checked here.
+	for name := range cfg.Policy.Detectors {
+		if !policy.IsDetector(name) {
+			warnings = append(warnings, config.Warning{
+				Line:    cfg.Line("policy.detectors." + name),
+				Message: fmt.Sprintf("unknown detector %q in policy.detectors", name),
+			})
+		}
+	}
+	for name := range cfg.Policy.Guard.Rules {
+		if !policy.IsGuardRule(name) {
+			warnings = append(warnings, config.Warning{
+				Line:    cfg.Line("policy.guard.rules." + name),
+				Message: fmt.Sprintf("unknown guard rule %q in policy.guard.rules; use one of %s", name, strings.Join(policy.GuardRuleNames(), ", ")),
+			})
+		}
+	}
+	// A template that fails to parse would only surface on export.
+	if path := strings.TrimSpace(cfg.Export.Template); path != "" {
+		if _, err := export.LoadTemplate(path); err != nil {
+			warnings = append(warnings, config.Warning{
+				Line:    cfg.Line("export.template"),
+				Message: fmt.Sprintf("export.template: %v", err),
+			})
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Line < warnings[j].Line })
+	report.Valid = true
+	report.Config = &cfg
+	if len(warnings) > 0 {
+		report.Warnings = warnings
+	}
+	return report
+}
+
+func printConfigReport(out io.Writer, report configReport, show bool) {
+	if report.Exists {
+		fmt.Fprintf(out, "Config: %s\n", report.Path)
+	} else {
+		fmt.Fprintf(out, "Config: %s (not found, using defaults)\n", report.Path)
+	}
+	if !report.Valid {
+		return
+	}
+	for _, w := range report.Warnings {
+		printWarn(out, "%s", w)
+	}
+	if len(report.Warnings) == 0 {
+		printOK(out, "Config is valid")
+	} else {
+		printOK(out, "Config is valid with %d warning(s)", len(report.Warnings))
+	}
+	if show {
+		effective := *report.Config
+		resolved := policy.FromConfig(effective.Policy)
+		effective.Policy.Denylist = resolved.Denylist
+		effective.Policy.RedactionKeywords = resolved.RedactionKeywords
+		fmt.Fprintln(out)
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(2)
+		_ = enc.Encode(effective)
+		_ = enc.Close()
+	}
+}
@@ -12,11 +12,12 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func newDoctorCmd(s store.SessionStore) *cobra.Command {
+func newDoctorCmd(rt *storeRuntime) *cobra.Command {
 	return &cobra.Command{
 		Use:   "doctor",
 		Short: "Run local diagnostics for Commandry setup",
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			s := rt.store
 			root := s.RootDir()
 			configPath := filepath.Join(root, "config.yaml")
 			sessionsPath := filepath.Join(root, "sessions.jsonl")
@@ -26,6 +27,7 @@ func newDoctorCmd(s store.SessionStore) *cobra.Command {
 			fmt.Fprintln(cmd.OutOrStdout())
 			fmt.Fprintf(cmd.OutOrStdout(), "OS: %s/%s\n", runtime.GOOS, runtime.GOARCH)
 			fmt.Fprintf(cmd.OutOrStdout(), "Root dir: %s\n", root)
+			fmt.Fprintf(cmd.OutOrStdout(), "Store source: %s\n", storeSourceLabel(rt.location.Source))
 			fmt.Fprintf(cmd.OutOrStdout(), "Config file: %s\n", configPath)
 			fmt.Fprintf(cmd.OutOrStdout(), "Sessions store: %s\n", sessionsPath)
 			fmt.Fprintf(cmd.OutOrStdout(), "Active session file: %s\n", activeSessionPath)
@@ -63,6 +65,32 @@ func newDoctorCmd(s store.SessionStore) *cobra.Command {
 				}
 			}
 
+			if initialized {
+				report := validateConfigFile(configPath)
+				switch {
+				case !report.Valid:
+					if supportsUnicode(cmd.OutOrStdout()) {
+						printError(cmd.OutOrStdout(), "Config check failed (%s)", report.Error)
+					} else {
+						fmt.Fprintf(cmd.OutOrStdout(), "Config check: FAILED (%s)\n", report.Error)
+					}
+					printHint(cmd.OutOrStdout(), "Run `cmdry config validate` for details.")
+				case len(report.Warnings) > 0:
+					if supportsUnicode(cmd.OutOrStdout()) {
+						printWarn(cmd.OutOrStdout(), "Config check: %d warning(s)", len(report.Warnings))
+					} else {
+						fmt.Fprintf(cmd.OutOrStdout(), "Config check: %d WARNING(S)\n", len(report.Warnings))
+					}
+					printHint(cmd.OutOrStdout(), "Run `cmdry config validate` for details.")
+				default:
+					if supportsUnicode(cmd.OutOrStdout()) {
+						printOK(cmd.OutOrStdout(), "Config check")
+					} else {
+						fmt.Fprintln(cmd.OutOrStdout(), "Config check: OK")
+					}
+				}
+			}
+
 			if path, err := exec.LookPath("cmdry"); err != nil {
 				if supportsUnicode(cmd.OutOrStdout()) {
 					printWarn(cmd.OutOrStdout(), "Command executable `cmdry` in PATH: no")
@@ -107,6 +135,19 @@ func newDoctorCmd(s store.SessionStore) *cobra.Command {
 	}
 }
 
+func storeSourceLabel(source string) string {
+	switch source {
+	case store.SourceFlag:
+		return "--store flag"
+	case store.SourceEnv:
+		return store.HomeEnvVar + " environment variable"
+	case store.SourceProject:
+		return "project (" + store.ProjectDirName + " found from current directory)"
+	default:
+		return "user config directory"
+	}
+}
+
 func ensureWritable(root string) error {
 	if err := os.MkdirAll(root, 0o700); err != nil {
 		return fmt.Errorf("create root dir: %w", err)
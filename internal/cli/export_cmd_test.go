@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/fixi2/Commandry/internal/export"
+)
+
+func TestExportJSONFormat(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	books := filepath.Join(t.TempDir(), "books")
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("export:\n  output_dir: "+books+"\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	mustExecute(t, "hooks", "enable")
+	mustExecute(t, "start", "Deploy api", "-e", "staging")
+	mustExecute(t, "hook", "record", "--command", "kubectl rollout restart deployment/api")
+	mustExecute(t, "hook", "record", "--command", "kubectl rollout status deployment/api", "--exit-code", "1")
+	mustExecute(t, "stop")
+
+	mustExecute(t, "export", "--last", "--format", "JSON", "--no-annotate")
+	paths, err := filepath.Glob(filepath.Join(books, "*.json"))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("expected one .json runbook, got %v (%v)", paths, err)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	var doc export.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode export: %v\n%s", err, data)
+	}
+	if doc.SchemaVersion != export.JSONSchemaVersion || doc.Session.Env != "staging" || doc.Summary.OK != 1 || doc.Summary.Failed != 1 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	if len(doc.Steps) != 2 || doc.Steps[1].Reason != "nonzero_exit" || len(doc.Guidance.Rollback) != 2 {
+		t.Fatalf("unexpected steps or guidance: %+v", doc)
+	}
+
+	schema := mustExecute(t, "export", "--schema")
+	if !strings.Contains(schema, export.JSONSchemaID) {
+		t.Fatalf("expected the JSON Schema: %s", schema)
+	}
+}
+
+func TestExportShellScript(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	books := filepath.Join(t.TempDir(), "books")
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("export:\n  output_dir: "+books+"\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	mustExecute(t, "hooks", "enable")
+	mustExecute(t, "start", "Build")
+	mustExecute(t, "hook", "record", "--command", "make build", "--cwd", "/srv/my app")
+	mustExecute(t, "hook", "record", "--command", "make test", "--exit-code", "2")
+	mustExecute(t, "stop")
+
+	mustExecute(t, "export", "--last", "-f", "sh", "--no-annotate")
+	paths, err := filepath.Glob(filepath.Join(books, "*.sh"))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("expected one .sh script, got %v (%v)", paths, err)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("read script: %v", err)
+	}
+	script := string(data)
+	for _, want := range []string{"set -euo pipefail\n", "cd '/srv/my app'\nmake build\n", "# Step 2: FAILED (nonzero_exit, exit 2), commented out\n# $ make test\n"} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("script missing %q:\n%s", want, script)
+		}
+	}
+	info, err := os.Stat(paths[0])
+	if err != nil {
+		t.Fatalf("stat script: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o100 == 0 {
+		t.Fatalf("script should be executable: %v", info.Mode())
+	}
+}
+
+func TestExportPowerShellScript(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	books := filepath.Join(t.TempDir(), "books")
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("export:\n  output_dir: "+books+"\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	mustExecute(t, "hooks", "enable")
+	mustExecute(t, "start", "Build")
+	mustExecute(t, "hook", "record", "--shell", "pwsh", "--command", "dotnet build", "--cwd", "/srv/it's")
+	mustExecute(t, "stop")
+
+	mustExecute(t, "export", "--last", "-f", "ps1", "--no-annotate")
+	paths, err := filepath.Glob(filepath.Join(books, "*.ps1"))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("expected one .ps1 script, got %v (%v)", paths, err)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("read script: %v", err)
+	}
+	want := "Set-Location -LiteralPath '/srv/it''s'\ndotnet build\nif ($LASTEXITCODE -ne 0) { throw \"Step 1 failed with exit code $LASTEXITCODE\" }\n"
+	if !strings.Contains(string(data), "$ErrorActionPreference = 'Stop'") || !strings.Contains(string(data), want) {
+		t.Fatalf("unexpected script:\n%s", data)
+	}
+}
+
+func TestExportHTMLIsDeterministic(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	books := filepath.Join(t.TempDir(), "books")
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("export:\n  output_dir: "+books+"\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	mustExecute(t, "hooks", "enable")
+	mustExecute(t, "start", "Build")
+	mustExecute(t, "hook", "record", "--command", "make build")
+	mustExecute(t, "stop")
+
+	var pages []string
+	for i := 0; i < 2; i++ {
+		mustExecute(t, "export", "--last", "-f", "html", "--no-annotate")
+		paths, err := filepath.Glob(filepath.Join(books, "*.html"))
+		if err != nil || len(paths) != 1 {
+			t.Fatalf("expected one .html runbook, got %v (%v)", paths, err)
+		}
+		data, err := os.ReadFile(paths[0])
+		if err != nil {
+			t.Fatalf("read runbook: %v", err)
+		}
+		pages = append(pages, string(data))
+	}
+	if pages[0] != pages[1] {
+		t.Fatal("exporting the same session twice must give the same bytes")
+	}
+	if !strings.Contains(pages[0], `<pre id="cmd-1"><code>make build</code></pre>`) || !strings.Contains(pages[0], "@media print") {
+		t.Fatalf("unexpected page:\n%s", pages[0])
+	}
+}
+
+func TestExportWithCustomTemplate(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	books := filepath.Join(t.TempDir(), "books")
+	tmplPath := filepath.Join(t.TempDir(), "company.md.tmpl")
+	if err := os.WriteFile(tmplPath, []byte("# {{.Session.Title}}\n{{range .Steps}}- {{.Command}} ({{duration .DurationMS}})\n{{end}}"), 0o600); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	content := "export:\n  output_dir: " + books + "\n  template: " + tmplPath + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	mustExecute(t, "hooks", "enable")
+	mustExecute(t, "start", "Build")
+	mustExecute(t, "hook", "record", "--command", "make build", "--duration-ms", "1500")
+	mustExecute(t, "stop")
+
+	mustExecute(t, "export", "--last", "-f", "md", "--no-annotate")
+	paths, err := filepath.Glob(filepath.Join(books, "*.md"))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("expected one runbook, got %v (%v)", paths, err)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("read runbook: %v", err)
+	}
+	if string(data) != "# Build\n- make build (1.5s)\n" {
+		t.Fatalf("unexpected runbook:\n%s", data)
+	}
+
+	if out := mustExecute(t, "export", "--print-template"); !strings.Contains(out, "This runbook was generated from an explicit Commandry session.") {
+		t.Fatalf("expected the built-in template: %s", out)
+	}
+
+	root, err := NewRootCommand()
+	if err != nil {
+		t.Fatalf("NewRootCommand failed: %v", err)
+	}
+	root.SetOut(&strings.Builder{})
+	root.SetErr(&strings.Builder{})
+	root.SetArgs([]string{"export", "--last", "-f", "json", "--template", tmplPath})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "--template") {
+		t.Fatalf("expected --template to be rejected for json, got %v", err)
+	}
+
+	if err := os.WriteFile(tmplPath, []byte("{{.Session.Title"), 0o600); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	report := validateConfigFile(filepath.Join(dir, "config.yaml"))
+	if !report.Valid || len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0].String(), "line 3: export.template: parse template") {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
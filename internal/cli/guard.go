@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+// canConfirm reports whether a dangerous command can be confirmed on a
+// terminal; tests replace it.
+var canConfirm = isInteractiveSession
+
+// confirmDangerous asks the user to type env before a command that matched a
+// guard rule runs. Without a terminal the command is refused outright.
+func confirmDangerous(in io.Reader, out io.Writer, command, env string, match policy.GuardMatch) *store.GuardCheck {
+	check := &store.GuardCheck{Rule: match.Rule}
+	printWarn(out, "%s matches guard rule %q and environment %q is protected.", command, match.Rule, env)
+	if !canConfirm() {
+		check.Decision = store.GuardRefused
+		return check
+	}
+
+	fmt.Fprintf(out, "Type %q to run it: ", env)
+	answer, ok := readLine(bufio.NewReader(in))
+	if ok && answer == strings.TrimSpace(env) {
+		check.Decision = store.GuardConfirmed
+	} else {
+		check.Decision = store.GuardDeclined
+	}
+	return check
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunAsksBeforeDangerousCommandInProtectedEnv(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	content := "policy:\n  guard:\n    patterns: [\"go env*\"]\n  profiles:\n    prod:\n      protected: true\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	prev := canConfirm
+	t.Cleanup(func() { canConfirm = prev })
+
+	runWith := func(input string, args ...string) error {
+		t.Helper()
+		root, err := NewRootCommand()
+		if err != nil {
+			t.Fatalf("NewRootCommand failed: %v", err)
+		}
+		root.SetIn(strings.NewReader(input))
+		root.SetOut(io.Discard)
+		root.SetErr(io.Discard)
+		root.SetArgs(append([]string{"run", "--"}, args...))
+		return root.Execute()
+	}
+
+	mustExecute(t, "start", "Dev", "-e", "dev")
+	canConfirm = func() bool { return false }
+	if err := runWith("", "go", "env", "GOOS"); err != nil {
+		t.Fatalf("unprotected env must not be guarded: %v", err)
+	}
+	mustExecute(t, "stop")
+
+	mustExecute(t, "start", "Prod", "-e", "prod")
+	var exitErr *ExitError
+	if err := runWith("", "terraform", "destroy"); !asExitErrorCLI(err, &exitErr) || exitErr.Code != 2 {
+		t.Fatalf("expected refusal without a terminal, got %v", err)
+	}
+	canConfirm = func() bool { return true }
+	if err := runWith("yes\n", "go", "env", "GOOS"); !asExitErrorCLI(err, &exitErr) || exitErr.Code != 2 {
+		t.Fatalf("expected wrong confirmation to decline, got %v", err)
+	}
+	if err := runWith("prod\n", "go", "env", "GOOS"); err != nil {
+		t.Fatalf("confirmed command failed: %v", err)
+	}
+	mustExecute(t, "stop")
+
+	show := mustExecute(t, "sessions", "show", "--last", "--format", "json")
+	for _, want := range []string{
+		`"rule": "terraform_destroy",
+        "decision": "refused"`,
+		`"decision": "declined"`,
+		`"decision": "confirmed"`,
+		`"reason": "guard_refused"`,
+	} {
+		if !strings.Contains(show, want) {
+			t.Fatalf("expected %q in stored steps: %s", want, show)
+		}
+	}
+	detail := mustExecute(t, "sessions", "show", "--last", "--step", "3")
+	if !strings.Contains(detail, "Guard: confirmed (go env*)") {
+		t.Fatalf("expected guard decision in step detail: %s", detail)
+	}
+}
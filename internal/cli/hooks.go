@@ -5,35 +5,36 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fixi2/Commandry/internal/audit"
 	"github.com/fixi2/Commandry/internal/hooks"
-	"github.com/fixi2/Commandry/internal/policy"
 	"github.com/fixi2/Commandry/internal/store"
 	"github.com/spf13/cobra"
 )
 
-func newHooksCmd(s store.SessionStore, stateStore hooks.StateStore) *cobra.Command {
+func newHooksCmd(rt *storeRuntime) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "hooks",
 		Short: "Manage hooks recording mode state",
 	}
 
 	cmd.AddCommand(
-		newHooksStatusCmd(s, stateStore),
-		newHooksEnableCmd(stateStore),
-		newHooksDisableCmd(stateStore),
-		newHooksConfigureCmd(stateStore),
+		newHooksStatusCmd(rt),
+		newHooksEnableCmd(rt.hooksState),
+		newHooksDisableCmd(rt.hooksState),
+		newHooksConfigureCmd(rt.hooksState),
 		newHooksInstallCmd(),
 		newHooksUninstallCmd(),
 	)
 	return cmd
 }
 
-func newHooksStatusCmd(s store.SessionStore, stateStore hooks.StateStore) *cobra.Command {
+func newHooksStatusCmd(rt *storeRuntime) *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
 		Short: "Show hooks mode state",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			state, err := stateStore.Load(cmd.Context())
+			s := rt.store
+			state, err := rt.hooksState.Load(cmd.Context())
 			if err != nil {
 				return fmt.Errorf("load hooks state: %w", err)
 			}
@@ -48,6 +49,7 @@ func newHooksStatusCmd(s store.SessionStore, stateStore hooks.StateStore) *cobra
 			}
 			fmt.Fprintf(cmd.OutOrStdout(), "Recorded commands: %d\n", state.CommandCount)
 			fmt.Fprintf(cmd.OutOrStdout(), "Session recording: %s\n", boolLabel(recording))
+			printStoreLocation(cmd.OutOrStdout(), rt.location)
 			psInstalled, psDetails := powerShellInstallStatus()
 			fmt.Fprintf(cmd.OutOrStdout(), "PowerShell hook installed: %s\n", boolLabel(psInstalled))
 			if psDetails != "" {
@@ -139,19 +141,20 @@ func newHooksConfigureCmd(stateStore hooks.StateStore) *cobra.Command {
 	return cmd
 }
 
-func newHookCmd(s store.SessionStore, p *policy.Policy, stateStore hooks.StateStore) *cobra.Command {
+func newHookCmd(rt *storeRuntime) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:    "hook",
 		Short:  "Internal hooks endpoint",
 		Hidden: true,
 	}
-	cmd.AddCommand(newHookRecordCmd(s, p, stateStore))
+	cmd.AddCommand(newHookRecordCmd(rt))
 	return cmd
 }
 
-func newHookRecordCmd(s store.SessionStore, p *policy.Policy, stateStore hooks.StateStore) *cobra.Command {
+func newHookRecordCmd(rt *storeRuntime) *cobra.Command {
 	var (
 		rawCommand string
+		shell      string
 		cwd        string
 		exitCode   int
 		durationMS int64
@@ -172,9 +175,27 @@ func newHookRecordCmd(s store.SessionStore, p *policy.Policy, stateStore hooks.S
 				ts = parsed
 			}
 
-			rec := hooks.NewRecorder(s, p, stateStore)
+			// Shell hooks may run with a process directory that differs from the
+			// shell location, so discover the project store and policy from --cwd.
+			if cwd != "" {
+				if rt.location.Source == store.SourceUser || rt.location.Source == store.SourceProject {
+					if loc, err := store.ResolveLocation("", cwd); err == nil {
+						rt.use(loc)
+					}
+				}
+				rt.chdir(cwd)
+			}
+
+			rec := hooks.NewRecorder(rt.store, rt.policy, rt.hooksState)
+			rec.IgnoreCommands(rt.config.Hooks.Ignore)
+			rec.UseProfiles(rt.profile)
+			rec.UseAudit(audit.Open(rt.store.RootDir()))
+			if scrubber := rt.recordScrubber(); scrubber != nil {
+				rec.UsePrivacy(scrubber)
+			}
 			result, err := rec.Record(cmd.Context(), hooks.RecordInput{
 				Command:    rawCommand,
+				Shell:      shell,
 				CWD:        cwd,
 				ExitCode:   exitCode,
 				DurationMS: durationMS,
@@ -183,6 +204,9 @@ func newHookRecordCmd(s store.SessionStore, p *policy.Policy, stateStore hooks.S
 			if err != nil {
 				return err
 			}
+			if result.AuditErr != nil {
+				printWarn(cmd.ErrOrStderr(), "Policy decision not written to the audit log: %v", result.AuditErr)
+			}
 
 			if result.Reminder {
 				fmt.Fprintln(cmd.ErrOrStderr(), "[REC] Commandry recording is active.")
@@ -192,6 +216,7 @@ func newHookRecordCmd(s store.SessionStore, p *policy.Policy, stateStore hooks.S
 	}
 
 	cmd.Flags().StringVar(&rawCommand, "command", "", "Raw command line to record")
+	cmd.Flags().StringVar(&shell, "shell", "", "Shell the command was typed into (bash, zsh, pwsh); selects how the line is tokenized")
 	cmd.Flags().StringVar(&cwd, "cwd", "", "Working directory of the command")
 	cmd.Flags().IntVar(&exitCode, "exit-code", 0, "Command exit code")
 	cmd.Flags().Int64Var(&durationMS, "duration-ms", 0, "Command duration in milliseconds")
@@ -228,9 +228,28 @@ func bashHookBlock(executablePath string) string {
 		bashHookBeginMarker,
 		"__commandry_hook_active=0",
 		"__commandry_hook_ready=0",
+		"__commandry_store_root() {",
+		"  if [ -n \"${CMDRY_HOME:-}\" ]; then",
+		"    printf '%s' \"$CMDRY_HOME\"",
+		"    return",
+		"  fi",
+		"  local __it_dir=\"$PWD\"",
+		"  while [ -n \"$__it_dir\" ]; do",
+		"    if [ -d \"$__it_dir/.commandry\" ]; then",
+		"      printf '%s' \"$__it_dir/.commandry\"",
+		"      return",
+		"    fi",
+		"    [ \"$__it_dir\" = \"/\" ] && break",
+		"    __it_dir=\"${__it_dir%/*}\"",
+		"    [ -z \"$__it_dir\" ] && __it_dir=\"/\"",
+		"  done",
+		"}",
 		"__commandry_should_prefix() {",
 		"  local __it_root",
-		"  if [ -n \"${APPDATA:-}\" ]; then",
+		"  __it_root=\"$(__commandry_store_root)\"",
+		"  if [ -n \"$__it_root\" ]; then",
+		"    :",
+		"  elif [ -n \"${APPDATA:-}\" ]; then",
 		"    __it_root=\"$APPDATA/commandry\"",
 		"  elif [ -n \"${XDG_CONFIG_HOME:-}\" ]; then",
 		"    __it_root=\"$XDG_CONFIG_HOME/commandry\"",
@@ -301,9 +320,27 @@ func zshHookBlock(executablePath string) string {
 		"autoload -Uz add-zsh-hook",
 		"typeset -g __commandry_hook_active=0",
 		"typeset -g __commandry_hook_ready=0",
+		"__commandry_store_root() {",
+		"  if [[ -n \"${CMDRY_HOME:-}\" ]]; then",
+		"    print -rn -- \"$CMDRY_HOME\"",
+		"    return",
+		"  fi",
+		"  local __it_dir=\"$PWD\"",
+		"  while [[ -n \"$__it_dir\" ]]; do",
+		"    if [[ -d \"$__it_dir/.commandry\" ]]; then",
+		"      print -rn -- \"$__it_dir/.commandry\"",
+		"      return",
+		"    fi",
+		"    [[ \"$__it_dir\" == \"/\" ]] && break",
+		"    __it_dir=\"${__it_dir:h}\"",
+		"  done",
+		"}",
 		"__commandry_should_prefix() {",
 		"  local __it_root",
-		"  if [[ -n \"${APPDATA:-}\" ]]; then",
+		"  __it_root=\"$(__commandry_store_root)\"",
+		"  if [[ -n \"$__it_root\" ]]; then",
+		"    :",
+		"  elif [[ -n \"${APPDATA:-}\" ]]; then",
 		"    __it_root=\"$APPDATA/commandry\"",
 		"  elif [[ -n \"${XDG_CONFIG_HOME:-}\" ]]; then",
 		"    __it_root=\"$XDG_CONFIG_HOME/commandry\"",
@@ -285,12 +285,26 @@ func powerShellHookBlock(executablePath string) string {
 		"  if ($commandryHist -and $commandryHist.Id -ne $global:CommandryLastHistoryId) {",
 		"    $global:CommandryLastHistoryId = $commandryHist.Id",
 		"    if ($commandryHist.CommandLine -notmatch '^\\s*(cmdry(\\.exe)?|cmdr|it)\\b') {",
-		fmt.Sprintf("      & '%s' hook record --command $commandryHist.CommandLine --exit-code $commandryExit --duration-ms 0 --cwd $commandryCwd 2>$null", escapedPath),
+		fmt.Sprintf("      & '%s' hook record --shell powershell --command $commandryHist.CommandLine --exit-code $commandryExit --duration-ms 0 --cwd $commandryCwd 2>$null", escapedPath),
 		"    }",
 		"  }",
 		"  $commandryPrefix = \"\"",
 		"  try {",
-		"    $commandryRoot = Join-Path $env:APPDATA \"commandry\"",
+		"    $commandryRoot = $env:CMDRY_HOME",
+		"    if (-not $commandryRoot) {",
+		"      $commandryDir = $commandryCwd",
+		"      while ($commandryDir) {",
+		"        $commandryCandidate = Join-Path $commandryDir \".commandry\"",
+		"        if (Test-Path $commandryCandidate -PathType Container) {",
+		"          $commandryRoot = $commandryCandidate",
+		"          break",
+		"        }",
+		"        $commandryDir = Split-Path $commandryDir -Parent",
+		"      }",
+		"    }",
+		"    if (-not $commandryRoot) {",
+		"      $commandryRoot = Join-Path $env:APPDATA \"commandry\"",
+		"    }",
 		"    $commandryStatePath = Join-Path $commandryRoot \"hooks_state.json\"",
 		"    $commandryActivePath = Join-Path $commandryRoot \"active_session.json\"",
 		"    if ((Test-Path $commandryStatePath -PathType Leaf) -and (Test-Path $commandryActivePath -PathType Leaf)) {",
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+func newPolicyAuditCmd(rt *storeRuntime) *cobra.Command {
+	var (
+		since     string
+		until     string
+		sessionID string
+		jsonMode  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Summarize the policy decisions recorded in the audit log",
+		Long: "Count the redactions, denials, blocks and guard confirmations in the\n" +
+			"store's audit log by rule. The log lists rule names and actions only,\n" +
+			"never the commands themselves.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			now := time.Now().UTC()
+			filter := audit.Filter{SessionID: sessionID}
+			var err error
+			if filter.Since, err = parseTimeBound(since, now, false); err != nil {
+				return fmt.Errorf("--since: %w", err)
+			}
+			if filter.Until, err = parseTimeBound(until, now, true); err != nil {
+				return fmt.Errorf("--until: %w", err)
+			}
+
+			log := audit.Open(rt.store.RootDir())
+			events, err := log.Read()
+			if err != nil {
+				return err
+			}
+			summary := audit.Summarize(events, filter)
+			if jsonMode {
+				return writeJSON(cmd.OutOrStdout(), summary)
+			}
+			printAuditSummary(cmd.OutOrStdout(), log.Path(), summary)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Only count events at or after this time (RFC 3339, YYYY-MM-DD, or an age such as 24h or 7d)")
+	cmd.Flags().StringVar(&until, "until", "", "Only count events before this time; a date includes the whole day")
+	cmd.Flags().StringVar(&sessionID, "session", "", "Only count events of one session")
+	cmd.Flags().BoolVar(&jsonMode, "json", false, "Print the summary as JSON")
+	return cmd
+}
+
+// parseTimeBound reads an RFC 3339 time, a date or an age before now. A date
+// used as an upper bound covers the whole day.
+func parseTimeBound(value string, now time.Time, upper bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		if upper {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time, date or age", value)
+}
+
+func printAuditSummary(out io.Writer, path string, s audit.Summary) {
+	fmt.Fprintf(out, "Audit log: %s\n", path)
+	if s.Events == 0 {
+		fmt.Fprintln(out, "No policy decisions recorded in this range.")
+		return
+	}
+	fmt.Fprintf(out, "Events: %d in %d session(s), %s to %s\n", s.Events, s.Sessions, s.First.Format(time.RFC3339), s.Last.Format(time.RFC3339))
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "KIND\tRULE\tREDACTED\tDENIED\tBLOCKED\tCONFIRMED\tLAST")
+	for _, r := range s.Rules {
+		fmt.Fprintf(out, "%s\t%s\t%d\t%d\t%d\t%d\t%s\n", r.Kind, r.Rule, r.Redacted, r.Denied, r.Blocked, r.Confirmed, r.Last.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/config"
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/shellwords"
+	"github.com/fixi2/Commandry/internal/store"
+	"github.com/fixi2/Commandry/internal/util"
+	"github.com/spf13/cobra"
+)
+
+func newPolicyCmd(rt *storeRuntime) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Inspect how the recording policy treats commands",
+	}
+	cmd.AddCommand(newPolicyShowCmd(rt), newPolicyTestCmd(rt), newPolicyLintCmd(rt), newPolicyAuditCmd(rt))
+	return cmd
+}
+
+func newPolicyShowCmd(rt *storeRuntime) *cobra.Command {
+	var jsonMode bool
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective policy and the layer each entry comes from",
+		Long: "Print the policy merged from the built-in defaults, the system policy\n" +
+			"file, the user's config.yaml and the project's .commandry/config.yaml\n" +
+			"(in a project store) and .commandry/policy.yaml, in that order of\n" +
+			"precedence.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if jsonMode {
+				return writeJSON(cmd.OutOrStdout(), rt.layers)
+			}
+			printMergedPolicy(cmd.OutOrStdout(), rt.layers)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonMode, "json", false, "Print the result as JSON")
+	return cmd
+}
+
+func printMergedPolicy(out io.Writer, m policy.Merged) {
+	fmt.Fprintln(out, "Layers (lowest precedence first):")
+	for _, layer := range m.Layers {
+		fmt.Fprintf(out, "  %s\t%s\n", layer.Name, valueOrDash(layer.Path))
+	}
+
+	fmt.Fprintf(out, "enforce_denylist: %v\t%s\n", m.EnforceDenylist, originLabel(m.EnforceOrigin))
+	fmt.Fprintln(out, "denylist:")
+	for _, entry := range m.Denylist {
+		fmt.Fprintf(out, "  %s\t%s\n", entry.Value, originLabel(entry.Origin))
+	}
+	fmt.Fprintln(out, "redaction_keywords:")
+	for _, entry := range m.RedactionKeywords {
+		fmt.Fprintf(out, "  %s\t%s\n", entry.Value, originLabel(entry.Origin))
+	}
+	if len(m.RedactionRules) > 0 {
+		fmt.Fprintln(out, "redaction_rules:")
+		for _, rule := range m.RedactionRules {
+			fmt.Fprintf(out, "  %s: %s\t%s\n", rule.Name, rule.Pattern, originLabel(rule.Origin))
+		}
+	}
+	if len(m.Detectors) > 0 {
+		fmt.Fprintln(out, "detectors:")
+		for _, d := range m.Detectors {
+			fmt.Fprintf(out, "  %s: %s\t%s\n", d.Name, boolLabel(d.Enabled), originLabel(d.Origin))
+		}
+	}
+	if len(m.GuardPatterns) > 0 {
+		fmt.Fprintln(out, "guard.patterns:")
+		for _, entry := range m.GuardPatterns {
+			fmt.Fprintf(out, "  %s\t%s\n", entry.Value, originLabel(entry.Origin))
+		}
+	}
+	if len(m.GuardRules) > 0 {
+		fmt.Fprintln(out, "guard.rules:")
+		for _, r := range m.GuardRules {
+			fmt.Fprintf(out, "  %s: %s\t%s\n", r.Name, boolLabel(r.Enabled), originLabel(r.Origin))
+		}
+	}
+	if len(m.Profiles) > 0 {
+		fmt.Fprintln(out, "profiles:")
+		for _, p := range m.Profiles {
+			fmt.Fprintf(out, "  %s\t%s\n", p.Name, originLabel(p.Origin))
+		}
+	}
+}
+
+func originLabel(o policy.Origin) string {
+	if o.Locked {
+		return "(" + o.Layer + ", locked)"
+	}
+	return "(" + o.Layer + ")"
+}
+
+func newPolicyTestCmd(rt *storeRuntime) *cobra.Command {
+	var (
+		shell    string
+		env      string
+		jsonMode bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "test -- <command> [args...]",
+		Aliases: []string{"explain"},
+		Short:   "Show how a command would be recorded and which rules matched",
+		Long: "Run a command line through the loaded policy without executing it.\n" +
+			"Pass the command after --, or a whole shell line (pipes, && chains,\n" +
+			"$(...)) as a single quoted argument.",
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("usage: cmdry policy test -- <command> [args...]")
+			}
+			p := rt.profile(env).Policy
+			var e policy.Explanation
+			if len(args) == 1 && strings.ContainsAny(args[0], " \t|;&") {
+				e = p.ExplainLine(args[0], shellwords.ParseDialect(shell))
+			} else {
+				e = p.Explain(util.JoinCommand(args), args)
+			}
+			if jsonMode {
+				return writeJSON(cmd.OutOrStdout(), e)
+			}
+			printExplanation(cmd.OutOrStdout(), e)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&shell, "shell", "", "Tokenize a quoted command line as this shell (bash, zsh, pwsh)")
+	cmd.Flags().StringVarP(&env, "env", "e", "", "Use the policy profile for this environment label")
+	cmd.Flags().BoolVar(&jsonMode, "json", false, "Print the result as JSON")
+	return cmd
+}
+
+func printExplanation(out io.Writer, e policy.Explanation) {
+	fmt.Fprintf(out, "Input:    %s\n", e.Input)
+	switch e.Decision {
+	case policy.DecisionDenied:
+		if e.Enforced {
+			fmt.Fprintln(out, "Decision: denied (blocked by `cmdry run`, enforce_denylist is on)")
+		} else {
+			fmt.Fprintln(out, "Decision: denied (runs, but is recorded as a placeholder)")
+		}
+		if e.DeniedBy.Kind == policy.RuleBuiltin {
+			fmt.Fprintf(out, "Matched:  built-in rule %s\n", e.DeniedBy.Rule)
+		} else {
+			fmt.Fprintf(out, "Matched:  denylist entry %q\n", e.DeniedBy.Rule)
+		}
+	case policy.DecisionRedacted:
+		fmt.Fprintf(out, "Decision: redacted by %d redactor(s)\n", len(e.Redactions))
+		for _, r := range e.Redactions {
+			fmt.Fprintf(out, "  - %s %s\n", redactionKindLabel(r.Kind), r.Name)
+			fmt.Fprintf(out, "      before: %s\n", r.Before)
+			fmt.Fprintf(out, "      after:  %s\n", r.After)
+		}
+	default:
+		fmt.Fprintln(out, "Decision: allowed (recorded unchanged)")
+	}
+	for _, kept := range e.Preserved {
+		fmt.Fprintf(out, "Kept:     %s (kubectl set image assignment)\n", kept)
+	}
+	fmt.Fprintf(out, "Output:   %s\n", e.Output)
+}
+
+func redactionKindLabel(kind string) string {
+	switch kind {
+	case policy.RuleCustom:
+		return "redaction rule"
+	case policy.RuleDetector:
+		return "detector"
+	default:
+		return "built-in redactor"
+	}
+}
+
+func newPolicyLintCmd(rt *storeRuntime) *cobra.Command {
+	var (
+		strict    bool
+		noHistory bool
+		jsonMode  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Find denylist entries and redaction keywords that are too broad or redundant",
+		Long: "Check the denylist and redaction keywords against a built-in corpus of\n" +
+			"everyday DevOps commands and the commands recorded in this store.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var history []string
+			if !noHistory {
+				var err error
+				history, err = recordedCommands(cmd.Context(), rt)
+				if err != nil {
+					return err
+				}
+			}
+
+			resolved := policy.FromConfig(rt.config.Policy)
+			report := policy.Lint(resolved, history)
+
+			out := cmd.OutOrStdout()
+			if jsonMode {
+				if err := writeJSON(out, report); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(out, "Config: %s\n", config.Path(rt.location.Dir))
+				fmt.Fprintf(out, "Checked %d denylist pattern(s) and %d keyword(s) against %d built-in and %d recorded command(s).\n",
+					len(resolved.Denylist), len(resolved.RedactionKeywords), report.CorpusSize, report.HistorySize)
+				printLintReport(out, report)
+			}
+
+			if strict && report.Warnings() > 0 {
+				return &ExitError{Code: 1, Err: fmt.Errorf("policy lint found %d warning(s) and --strict is set", report.Warnings())}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&strict, "strict", false, "Exit non-zero when there are warnings")
+	cmd.Flags().BoolVar(&noHistory, "no-history", false, "Only use the built-in corpus, not recorded sessions")
+	cmd.Flags().BoolVar(&jsonMode, "json", false, "Print the result as JSON")
+	return cmd
+}
+
+// recordedCommands returns the commands of every completed and active session
+// in the store. An uninitialized or empty store has no history.
+func recordedCommands(ctx context.Context, rt *storeRuntime) ([]string, error) {
+	sessions, err := rt.store.ListSessions(ctx, 0)
+	if err != nil && !errors.Is(err, store.ErrNoSessions) && !errors.Is(err, store.ErrNotInitialized) {
+		return nil, fmt.Errorf("read sessions: %w", err)
+	}
+	if active, err := rt.store.GetActiveSession(ctx); err == nil {
+		sessions = append(sessions, *active)
+	}
+
+	var commands []string
+	for _, session := range sessions {
+		for _, step := range session.Steps {
+			if step.Command != "" && step.Command != policy.DeniedPlaceholder {
+				commands = append(commands, step.Command)
+			}
+		}
+	}
+	return commands, nil
+}
+
+func printLintReport(out io.Writer, report policy.LintReport) {
+	if len(report.Findings) == 0 {
+		printOK(out, "No problems found")
+		return
+	}
+	for _, f := range report.Findings {
+		line := fmt.Sprintf("%s: %s (%s)", f.Subject, f.Message, f.Check)
+		if f.Severity == policy.SeverityWarning {
+			printWarn(out, "%s", line)
+		} else {
+			fmt.Fprintf(out, "Note: %s\n", line)
+		}
+		for _, example := range f.Examples {
+			fmt.Fprintf(out, "    %s\n", example)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fixi2/Commandry/internal/audit"
+	"github.com/fixi2/Commandry/internal/config"
+	"github.com/fixi2/Commandry/internal/policy"
+)
+
+func TestPolicyTestCommand(t *testing.T) {
+	isolateConfigDirs(t)
+	t.Setenv("CMDRY_HOME", filepath.Join(t.TempDir(), "store"))
+	mustExecute(t, "init")
+
+	out := mustExecute(t, "policy", "test", "--", "kubectl", "get", "secret", "db", "-o", "yaml")
+	if !strings.Contains(out, "Matched:  built-in rule isKubectlSecretOutputDenied") || !strings.Contains(out, "Output:   [REDACTED BY POLICY]") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	out = mustExecute(t, "policy", "explain", "--json", "curl --token abc https://example.com")
+	var e policy.Explanation
+	if err := json.Unmarshal([]byte(out), &e); err != nil {
+		t.Fatalf("decode explanation: %v\n%s", err, out)
+	}
+	if e.Decision != policy.DecisionRedacted || e.Output != "curl --token [REDACTED] https://example.com" {
+		t.Fatalf("unexpected explanation: %+v", e)
+	}
+	if len(e.Redactions) != 1 || e.Redactions[0].Name != "keyword_flag_value" || e.Redactions[0].Before != "curl --token abc https://example.com" {
+		t.Fatalf("unexpected redactions: %+v", e.Redactions)
+	}
+}
+
+func TestPolicyLintCommand(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+	content := "policy:\n  denylist: [deploy-prod, \"*.pem\"]\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	mustExecute(t, "start", "lint history")
+	mustExecute(t, "run", "--", "go", "version")
+	mustExecute(t, "stop")
+
+	out := mustExecute(t, "policy", "lint", "--json")
+	var report policy.LintReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out)
+	}
+	if report.HistorySize != 1 || len(report.Findings) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	content = "policy:\n  denylist: [env, env]\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	out = mustExecute(t, "policy", "lint", "--no-history")
+	if !strings.Contains(out, `policy.denylist "env": listed more than once (duplicate)`) || !strings.Contains(out, "python3 -m venv .venv") {
+		t.Fatalf("unexpected lint output: %s", out)
+	}
+	root, err := NewRootCommand()
+	if err != nil {
+		t.Fatalf("NewRootCommand failed: %v", err)
+	}
+	root.SetOut(&strings.Builder{})
+	root.SetErr(&strings.Builder{})
+	root.SetArgs([]string{"policy", "lint", "--strict"})
+	if err := root.Execute(); err == nil {
+		t.Fatal("expected --strict to fail on warnings")
+	}
+}
+
+func TestPolicyShowMergesSystemUserAndProjectLayers(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	systemPath := filepath.Join(t.TempDir(), "policy.yaml")
+	t.Setenv(config.SystemPolicyEnvVar, systemPath)
+	system := "policy:\n  denylist: [\"vault read*\"]\n  enforce_denylist: true\n  lock: [denylist, enforce_denylist]\n"
+	if err := os.WriteFile(systemPath, []byte(system), 0o600); err != nil {
+		t.Fatalf("write system policy: %v", err)
+	}
+	user := "policy:\n  denylist: [printenv]\n  enforce_denylist: false\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(user), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	project := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(project, ".commandry"), 0o755); err != nil {
+		t.Fatalf("mkdir project: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ".commandry", "policy.yaml"), []byte("policy:\n  redaction_keywords: [db_pass]\n"), 0o600); err != nil {
+		t.Fatalf("write project policy: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("chdir project: %v", err)
+	}
+
+	out := mustExecute(t, "policy", "show")
+	for _, want := range []string{
+		"  system\t" + systemPath,
+		"  project\t" + filepath.Join(project, ".commandry", "policy.yaml"),
+		"enforce_denylist: true\t(system, locked)",
+		"  vault read*\t(system, locked)",
+		"  printenv\t(user)",
+		"  private_key\t(builtin)\n  db_pass\t(project)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("policy show missing %q:\n%s", want, out)
+		}
+	}
+
+	out = mustExecute(t, "policy", "test", "--", "vault", "read", "kv/db")
+	if !strings.Contains(out, "blocked by `cmdry run`") {
+		t.Fatalf("system deny entry should be enforced: %s", out)
+	}
+}
+
+func TestPolicyAuditSummarizesRecordedDecisions(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("policy:\n  enforce_denylist: true\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	mustExecute(t, "hooks", "enable")
+	mustExecute(t, "start", "Audit")
+	mustExecute(t, "hook", "record", "--command", "curl --token=hunter2 https://example.com")
+	root, err := NewRootCommand()
+	if err != nil {
+		t.Fatalf("NewRootCommand failed: %v", err)
+	}
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	root.SetArgs([]string{"run", "--", "printenv"})
+	if err := root.Execute(); err == nil {
+		t.Fatal("expected printenv to be blocked")
+	}
+	mustExecute(t, "stop")
+
+	payload, err := os.ReadFile(filepath.Join(dir, "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if strings.Contains(string(payload), "hunter2") || strings.Contains(string(payload), "curl") {
+		t.Fatalf("audit log must not hold command text: %s", payload)
+	}
+
+	out := mustExecute(t, "policy", "audit")
+	if !strings.Contains(out, "builtin\tisEnvDumpDenied\t0\t0\t1\t0\t") || !strings.Contains(out, "\tkeyword_flag_equals\t1\t0\t0\t0\t") {
+		t.Fatalf("unexpected audit summary: %s", out)
+	}
+
+	out = mustExecute(t, "policy", "audit", "--json", "--since", "2000-01-01", "--until", "2000-01-31")
+	var summary audit.Summary
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatalf("decode summary: %v\n%s", err, out)
+	}
+	if summary.Events != 0 || len(summary.Rules) != 0 {
+		t.Fatalf("range outside the log should be empty: %+v", summary)
+	}
+}
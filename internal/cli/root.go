@@ -3,62 +3,76 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/fixi2/Commandry/internal/audit"
 	"github.com/fixi2/Commandry/internal/buildinfo"
 	"github.com/fixi2/Commandry/internal/capture"
 	"github.com/fixi2/Commandry/internal/export"
-	"github.com/fixi2/Commandry/internal/hooks"
 	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/privacy"
 	"github.com/fixi2/Commandry/internal/store"
 	"github.com/fixi2/Commandry/internal/util"
 	"github.com/spf13/cobra"
 )
 
 func NewRootCommand() (*cobra.Command, error) {
-	rootDir, err := store.DefaultRootDir()
+	workingDir, _ := os.Getwd()
+	loc, err := store.ResolveLocation("", workingDir)
 	if err != nil {
 		return nil, fmt.Errorf("resolve config directory: %w", err)
 	}
-
-	s := store.NewJSONStore(rootDir)
-	policyPath := filepath.Join(rootDir, "config.yaml")
-	p, policyErr := policy.LoadFromConfigOrDefault(policyPath)
-	if policyErr != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to load policy config from %s (%v). Using defaults.\n", policyPath, policyErr)
-		p = policy.NewDefault()
-	}
-	hooksState := hooks.NewFileStateStore(rootDir)
+	rt := newStoreRuntime(loc, workingDir)
+	s := rt.store
 
 	rootCmd := &cobra.Command{
 		Use:     "cmdry",
 		Aliases: []string{"cmdr"},
 		Short:   "Capture explicit command sessions into deterministic markdown runbooks",
 	}
-	var noColor bool
+	var (
+		noColor  bool
+		storeDir string
+	)
 
 	rootCmd.SilenceUsage = true
 	rootCmd.SilenceErrors = true
-	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		configureOutput(noColor)
+		if storeDir != "" {
+			override, err := store.ResolveLocation(storeDir, "")
+			if err != nil {
+				return err
+			}
+			rt.use(override)
+		}
+		return nil
 	}
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored command output")
+	rootCmd.PersistentFlags().StringVar(&storeDir, "store", "", "Use this store directory (overrides CMDRY_HOME and project .commandry/)")
 	rootCmd.AddCommand(
-		newInitCmd(s),
+		newInitCmd(rt),
 		newSetupCmd(),
-		newStartCmd(s),
+		newStartCmd(rt),
 		newStopCmd(s),
-		newStatusCmd(s),
-		newDoctorCmd(s),
-		newRunCmd(s, p),
-		newExportCmd(s),
-		newSessionsCmd(s),
-		newHooksCmd(s, hooksState),
-		newHookCmd(s, p, hooksState),
+		newStatusCmd(rt),
+		newDoctorCmd(rt),
+		newRunCmd(rt),
+		newExportCmd(rt),
+		newSessionsCmd(rt),
+		newTagCmd(s),
+		newStoreCmd(rt),
+		newConfigCmd(rt),
+		newPolicyCmd(rt),
+		newAuditCmd(rt),
+		newHooksCmd(rt),
+		newHookCmd(rt),
 		newAliasCmd(),
 		newVersionCmd(),
 	)
@@ -66,12 +80,26 @@ func NewRootCommand() (*cobra.Command, error) {
 	return rootCmd, nil
 }
 
-func newInitCmd(s store.SessionStore) *cobra.Command {
-	return &cobra.Command{
+func newInitCmd(rt *storeRuntime) *cobra.Command {
+	var project bool
+
+	cmd := &cobra.Command{
 		Use:     "init",
 		Aliases: []string{"i"},
 		Short:   "Initialize local Commandry storage and config",
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			if project {
+				if rt.location.Source == store.SourceFlag || rt.location.Source == store.SourceEnv {
+					return errors.New("`--project` cannot be combined with `--store` or CMDRY_HOME")
+				}
+				workingDir, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("get working directory: %w", err)
+				}
+				rt.use(store.Location{Dir: filepath.Join(workingDir, store.ProjectDirName), Source: store.SourceProject})
+			}
+
+			s := rt.store
 			if err := s.Init(cmd.Context()); err != nil {
 				return fmt.Errorf("initialize storage: %w", err)
 			}
@@ -80,10 +108,18 @@ func newInitCmd(s store.SessionStore) *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&project, "project", false, "Create a project-local store in ./"+store.ProjectDirName)
+	return cmd
 }
 
-func newStartCmd(s store.SessionStore) *cobra.Command {
-	var env string
+func newStartCmd(rt *storeRuntime) *cobra.Command {
+	s := rt.store
+	var (
+		env      string
+		tagFlags []string
+		metaFlag []string
+	)
 
 	cmd := &cobra.Command{
 		Use:     "start <title>",
@@ -95,9 +131,23 @@ func newStartCmd(s store.SessionStore) *cobra.Command {
 			if title == "" {
 				return errors.New("title cannot be empty")
 			}
+			tags, err := parseTagFlags(tagFlags)
+			if err != nil {
+				return err
+			}
+			meta, err := parseMetaFlags(metaFlag)
+			if err != nil {
+				return err
+			}
 
+			// Label the session as it is created, so a failure never leaves an
+			// active session without its tags, metadata or fingerprint.
+			labels := sessionLabels{addTags: tags, setMeta: meta}
 			startedAt := time.Now().UTC()
-			session, err := s.StartSession(cmd.Context(), title, env, startedAt)
+			session, err := s.StartSession(cmd.Context(), title, env, startedAt, func(started *store.Session) error {
+				started.PolicyFingerprint = rt.profile(started.Env).Policy.Fingerprint()
+				return labels.apply(started)
+			})
 			if err != nil {
 				if errors.Is(err, store.ErrNotInitialized) {
 					return errors.New("Commandry is not initialized. Run `cmdry init` first")
@@ -124,6 +174,8 @@ func newStartCmd(s store.SessionStore) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&env, "env", "e", "", "Optional environment label (for example: staging, prod)")
+	cmd.Flags().StringArrayVarP(&tagFlags, "tag", "t", nil, "Tag the session (repeatable)")
+	cmd.Flags().StringArrayVar(&metaFlag, "meta", nil, "Attach key=value metadata, for example ticket=CHG-123 (repeatable)")
 	return cmd
 }
 
@@ -153,11 +205,12 @@ func newStopCmd(s store.SessionStore) *cobra.Command {
 	}
 }
 
-func newStatusCmd(s store.SessionStore) *cobra.Command {
+func newStatusCmd(rt *storeRuntime) *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
 		Short: "Show current Commandry session status",
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			s := rt.store
 			initialized, err := s.IsInitialized(cmd.Context())
 			if err != nil {
 				return fmt.Errorf("check initialization: %w", err)
@@ -165,6 +218,7 @@ func newStatusCmd(s store.SessionStore) *cobra.Command {
 
 			if !initialized {
 				fmt.Fprintln(cmd.OutOrStdout(), "Status: not initialized")
+				printStoreLocation(cmd.OutOrStdout(), rt.location)
 				fmt.Fprintln(cmd.OutOrStdout(), "Run `cmdry init` to create local config and storage")
 				return nil
 			}
@@ -173,6 +227,7 @@ func newStatusCmd(s store.SessionStore) *cobra.Command {
 			if err != nil {
 				if errors.Is(err, store.ErrNoActiveSession) {
 					fmt.Fprintln(cmd.OutOrStdout(), "Status: initialized, no active session")
+					printStoreLocation(cmd.OutOrStdout(), rt.location)
 					return nil
 				}
 
@@ -180,10 +235,20 @@ func newStatusCmd(s store.SessionStore) *cobra.Command {
 			}
 
 			fmt.Fprintf(cmd.OutOrStdout(), "Status: recording\n")
+			printStoreLocation(cmd.OutOrStdout(), rt.location)
 			fmt.Fprintf(cmd.OutOrStdout(), "Title: %s\n", active.Title)
 			if active.Env != "" {
 				fmt.Fprintf(cmd.OutOrStdout(), "Env: %s\n", active.Env)
 			}
+			if profile := rt.profile(active.Env); profile.Name != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Policy profile: %s (%s)\n", profile.Name, describeProfile(profile))
+			}
+			if len(active.Tags) > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Tags: %s\n", formatSessionTags(active.Tags))
+			}
+			for _, key := range sortedMetaKeys(active.Meta) {
+				fmt.Fprintf(cmd.OutOrStdout(), "Meta: %s=%s\n", key, active.Meta[key])
+			}
 			fmt.Fprintf(cmd.OutOrStdout(), "Started: %s\n", active.StartedAt.Format(time.RFC3339))
 			fmt.Fprintf(cmd.OutOrStdout(), "Recorded steps: %d\n", len(active.Steps))
 
@@ -192,7 +257,8 @@ func newStatusCmd(s store.SessionStore) *cobra.Command {
 	}
 }
 
-func newRunCmd(s store.SessionStore, p *policy.Policy) *cobra.Command {
+func newRunCmd(rt *storeRuntime) *cobra.Command {
+	s := rt.store
 	return &cobra.Command{
 		Use:     "run -- <command> [args...]",
 		Aliases: []string{"r"},
@@ -203,32 +269,56 @@ func newRunCmd(s store.SessionStore, p *policy.Policy) *cobra.Command {
 				return errors.New("usage: cmdry run -- <command> [args...]")
 			}
 
-			if _, err := s.GetActiveSession(cmd.Context()); err != nil {
+			active, err := s.GetActiveSession(cmd.Context())
+			if err != nil {
 				if errors.Is(err, store.ErrNoActiveSession) {
 					return errors.New("no active session. Run `cmdry start \"<title>\"` before `cmdry run`")
 				}
 				return fmt.Errorf("check active session: %w", err)
 			}
+			profile := rt.profile(active.Env)
+			p := profile.Policy
 
 			rawCommand := util.JoinCommand(args)
-			sanitized := p.Apply(rawCommand, args)
+			explained := p.Explain(rawCommand, args)
+			sanitized := explained.Result()
+			scrubber := rt.recordScrubber()
+			addStep := func(step store.Step) error {
+				if scrubber != nil {
+					scrubber.Step(&step)
+				}
+				return s.AddStep(cmd.Context(), step)
+			}
+			auditLog := audit.Open(s.RootDir())
+			recordAudit := func(events ...audit.Event) {
+				if err := auditLog.Append(events...); err != nil {
+					printWarn(cmd.ErrOrStderr(), "Policy decision not written to the audit log: %v", err)
+				}
+			}
 
 			cwd, err := os.Getwd()
 			if err != nil {
 				return fmt.Errorf("get working directory: %w", err)
 			}
+			recordedCWD := ""
+			if profile.RecordCWD {
+				recordedCWD = p.RedactCWD(cwd)
+			}
 			if sanitized.Denied && p.EnforceDenylist() {
 				step := store.Step{
-					Timestamp:  time.Now().UTC(),
-					Command:    sanitized.Command,
-					Status:     "REDACTED",
-					Reason:     "policy_blocked",
-					DurationMS: 0,
-					CWD:        cwd,
+					Timestamp:         time.Now().UTC(),
+					Command:           sanitized.Command,
+					Status:            "REDACTED",
+					Reason:            "policy_blocked",
+					DurationMS:        0,
+					CWD:               recordedCWD,
+					Argv:              true,
+					PolicyFingerprint: p.Fingerprint(),
 				}
-				if err := s.AddStep(cmd.Context(), step); err != nil {
+				if err := addStep(step); err != nil {
 					return fmt.Errorf("record blocked step: %w", err)
 				}
+				recordAudit(audit.StepEvents(active, audit.SourceRun, step.Timestamp, explained, true)...)
 				printWarn(cmd.ErrOrStderr(), "Command blocked by policy denylist. Step recorded as %s.", policy.DeniedPlaceholder)
 				return &ExitError{
 					Code: 2,
@@ -236,24 +326,72 @@ func newRunCmd(s store.SessionStore, p *policy.Policy) *cobra.Command {
 				}
 			}
 
+			var (
+				guard      *store.GuardCheck
+				guardEvent audit.Event
+			)
+			if profile.Protected {
+				if match, ok := p.Dangerous(rawCommand, args); ok {
+					guard = confirmDangerous(cmd.InOrStdin(), cmd.ErrOrStderr(), sanitized.Command, active.Env, match)
+					guardEvent = audit.Event{SessionID: active.ID, Env: active.Env, Source: audit.SourceRun, Kind: match.Kind, Rule: match.Rule, Action: audit.ActionConfirmed}
+				}
+			}
+			if guard != nil && guard.Decision != store.GuardConfirmed {
+				step := store.Step{
+					Timestamp:         time.Now().UTC(),
+					Command:           sanitized.Command,
+					Status:            "FAILED",
+					Reason:            "guard_refused",
+					CWD:               recordedCWD,
+					Argv:              true,
+					Guard:             guard,
+					PolicyFingerprint: p.Fingerprint(),
+				}
+				if err := addStep(step); err != nil {
+					return fmt.Errorf("record refused step: %w", err)
+				}
+				guardEvent.Time = step.Timestamp
+				guardEvent.Action = audit.ActionBlocked
+				recordAudit(guardEvent)
+				if guard.Decision == store.GuardRefused {
+					printHint(cmd.ErrOrStderr(), "Run it from an interactive terminal to confirm.")
+				}
+				return &ExitError{
+					Code: 2,
+					Err:  fmt.Errorf("command not confirmed (%s)", guard.Decision),
+				}
+			}
+
 			result, runErr := capture.RunCommand(cmd.Context(), args, cwd)
 			step := store.Step{
-				Timestamp:  result.StartedAt,
-				Command:    sanitized.Command,
-				Status:     result.Status,
-				Reason:     result.Reason,
-				ExitCode:   result.ExitCode,
-				DurationMS: result.Duration.Milliseconds(),
-				CWD:        cwd,
+				Timestamp:         result.StartedAt,
+				Command:           sanitized.Command,
+				Status:            result.Status,
+				Reason:            result.Reason,
+				ExitCode:          result.ExitCode,
+				DurationMS:        result.Duration.Milliseconds(),
+				CWD:               recordedCWD,
+				Argv:              true,
+				Guard:             guard,
+				PolicyFingerprint: p.Fingerprint(),
 			}
 			if sanitized.Denied {
 				step.Status = "REDACTED"
 				step.Reason = "policy_redacted"
 			}
 
-			if err := s.AddStep(cmd.Context(), step); err != nil {
+			if err := addStep(step); err != nil {
 				return fmt.Errorf("record step: %w", err)
 			}
+			events := audit.StepEvents(active, audit.SourceRun, step.Timestamp, explained, false)
+			if guard != nil {
+				guardEvent.Time = step.Timestamp.UTC()
+				events = append(events, guardEvent)
+			}
+			recordAudit(events...)
+			if len(sanitized.Detected) > 0 {
+				printWarn(cmd.ErrOrStderr(), "Redacted secret(s) detected as: %s", strings.Join(sanitized.Detected, ", "))
+			}
 
 			if runErr != nil {
 				if result.Reason == "command_not_found" && runtime.GOOS == "windows" {
@@ -300,7 +438,17 @@ func isWindowsShellBuiltin(cmd string) bool {
 	}
 }
 
-func newExportCmd(s store.SessionStore) *cobra.Command {
+// exportWriters maps `export --format` values to the writer for that format.
+var exportWriters = map[string]func(*store.Session, string, export.MarkdownOptions) (string, error){
+	"md":   export.WriteMarkdownToDir,
+	"html": export.WriteHTMLToDir,
+	"json": export.WriteJSONToDir,
+	"sh":   export.WriteShellToDir,
+	"ps1":  export.WritePowerShellToDir,
+}
+
+func newExportCmd(rt *storeRuntime) *cobra.Command {
+	s := rt.store
 	var (
 		exportLast bool
 		exportMD   bool
@@ -308,13 +456,25 @@ func newExportCmd(s store.SessionStore) *cobra.Command {
 		sessionID  string
 		annotate   bool
 		noAnnotate bool
+		scrub      bool
+		schema     bool
+		tmplPath   string
+		printTmpl  bool
 	)
 
 	cmd := &cobra.Command{
 		Use:     "export",
 		Aliases: []string{"x"},
-		Short:   "Export a completed session as markdown",
+		Short:   "Export a completed session as a runbook, JSON document or script",
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			if schema {
+				_, err := cmd.OutOrStdout().Write(export.JSONSchema())
+				return err
+			}
+			if printTmpl {
+				_, err := io.WriteString(cmd.OutOrStdout(), export.DefaultTemplate())
+				return err
+			}
 			if sessionID != "" && exportLast {
 				return errors.New("use either `--last` or `--session <id>`, not both")
 			}
@@ -328,16 +488,28 @@ func newExportCmd(s store.SessionStore) *cobra.Command {
 				exportFmt = "md"
 			}
 			if exportFmt == "" {
-				return errors.New("only markdown export is supported in MVP. Use `--md` or `--format md`")
+				return errors.New("provide an export format: `--md` or `--format md|html|json|sh|ps1`")
+			}
+			exportFmt = strings.ToLower(exportFmt)
+			var err error
+			write, ok := exportWriters[exportFmt]
+			if !ok {
+				return errors.New("unsupported format. Use `md`, `html`, `json`, `sh` or `ps1`")
 			}
-			if !strings.EqualFold(exportFmt, "md") {
-				return errors.New("unsupported format. MVP supports only markdown (`md`)")
+			if tmplPath != "" && exportFmt != "md" {
+				return errors.New("`--template` applies only to `--format md`")
+			}
+			if tmplPath == "" && exportFmt == "md" {
+				tmplPath = strings.TrimSpace(rt.config.Export.Template)
+			}
+			var tmpl *template.Template
+			if tmplPath != "" {
+				if tmpl, err = export.LoadTemplate(tmplPath); err != nil {
+					return fmt.Errorf("load runbook template %s: %w", tmplPath, err)
+				}
 			}
 
-			var (
-				session *store.Session
-				err     error
-			)
+			var session *store.Session
 			if sessionID != "" {
 				session, err = s.SessionByID(cmd.Context(), sessionID)
 				if err != nil {
@@ -364,17 +536,24 @@ func newExportCmd(s store.SessionStore) *cobra.Command {
 				return fmt.Errorf("get current directory: %w", err)
 			}
 
+			if !cmd.Flags().Changed("scrub") {
+				scrub = rt.config.Privacy.OnExport
+			}
+			if scrub {
+				session = privacy.New(rt.config.Privacy).Session(session)
+			}
+
 			opts := export.MarkdownOptions{}
 			flagged := collectFlaggedSteps(session)
 			shouldPrompt := (annotate || (isInteractiveSession() && !noAnnotate)) && len(flagged) > 0
 			if shouldPrompt {
 				opts = promptForExportAnnotations(cmd.InOrStdin(), cmd.OutOrStdout(), session)
 			}
+			opts.Template = tmpl
 
-			var outPath string
-			outPath, err = export.WriteMarkdownWithOptions(session, workingDir, opts)
+			outPath, err := write(session, rt.runbooksDir(workingDir), opts)
 			if err != nil {
-				return fmt.Errorf("export markdown: %w", err)
+				return fmt.Errorf("export %s: %w", exportFmt, err)
 			}
 
 			printOK(cmd.OutOrStdout(), "Exported runbook: %s", outPath)
@@ -385,45 +564,90 @@ func newExportCmd(s store.SessionStore) *cobra.Command {
 	cmd.Flags().BoolVarP(&exportLast, "last", "l", false, "Export the most recent completed session")
 	cmd.Flags().StringVar(&sessionID, "session", "", "Export a specific completed session by id")
 	cmd.Flags().BoolVar(&exportMD, "md", false, "Export markdown output")
-	cmd.Flags().StringVarP(&exportFmt, "format", "f", "", "Export format (MVP: md)")
+	cmd.Flags().StringVarP(&exportFmt, "format", "f", "", "Export format: md|html|json|sh|ps1")
 	cmd.Flags().BoolVar(&annotate, "annotate", false, "Prompt for export comments on failed/redacted steps")
 	cmd.Flags().BoolVar(&noAnnotate, "no-annotate", false, "Skip export comment prompt")
+	cmd.Flags().StringVar(&tmplPath, "template", "", "Render the markdown runbook with this text/template file (default: export.template)")
+	cmd.Flags().BoolVar(&printTmpl, "print-template", false, "Print the built-in markdown template and exit")
+	cmd.Flags().BoolVar(&schema, "schema", false, "Print the JSON Schema of the json format and exit")
+	cmd.Flags().BoolVar(&scrub, "scrub", false, "Apply the privacy settings to the runbook (default: privacy.on_export)")
 	return cmd
 }
 
-func newSessionsCmd(s store.SessionStore) *cobra.Command {
+func newSessionsCmd(rt *storeRuntime) *cobra.Command {
+	s := rt.store
 	cmd := &cobra.Command{
 		Use:   "sessions",
 		Short: "Inspect completed sessions",
 	}
-	cmd.AddCommand(newSessionsListCmd(s))
+	cmd.AddCommand(
+		newSessionsListCmd(s),
+		newSessionsShowCmd(s),
+		newSessionsMergeCmd(s),
+		newSessionsSplitCmd(s),
+		newSessionsRedactCmd(rt),
+	)
 	return cmd
 }
 
 func newSessionsListCmd(s store.SessionStore) *cobra.Command {
-	var limit int
+	var (
+		limit      int
+		tagFilter  []string
+		metaFilter []string
+	)
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List most recent completed sessions",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			sessions, err := s.ListSessions(cmd.Context(), limit)
+			tags, err := parseTagFlags(tagFilter)
+			if err != nil {
+				return err
+			}
+			meta, err := parseMetaFlags(metaFilter)
+			if err != nil {
+				return err
+			}
+			filtering := len(tags) > 0 || len(meta) > 0
+
+			fetchLimit := limit
+			if filtering {
+				fetchLimit = 0
+			}
+			sessions, err := s.ListSessions(cmd.Context(), fetchLimit)
 			if err != nil {
 				if errors.Is(err, store.ErrNoSessions) {
 					return errors.New("no completed sessions found")
 				}
 				return fmt.Errorf("list sessions: %w", err)
 			}
+			if filtering {
+				matched := make([]store.Session, 0, len(sessions))
+				for i := range sessions {
+					if limit > 0 && len(matched) >= limit {
+						break
+					}
+					if matchesLabelFilters(&sessions[i], tags, meta) {
+						matched = append(matched, sessions[i])
+					}
+				}
+				if len(matched) == 0 {
+					return errors.New("no completed sessions match the given filters")
+				}
+				sessions = matched
+			}
 
-			fmt.Fprintln(cmd.OutOrStdout(), "ID\tSTARTED\tTITLE\tSTEPS")
+			fmt.Fprintln(cmd.OutOrStdout(), "ID\tSTARTED\tTITLE\tSTEPS\tTAGS")
 			for _, session := range sessions {
 				fmt.Fprintf(
 					cmd.OutOrStdout(),
-					"%s\t%s\t%s\t%d\n",
+					"%s\t%s\t%s\t%d\t%s\n",
 					session.ID,
 					session.StartedAt.Format(time.RFC3339),
 					session.Title,
 					len(session.Steps),
+					valueOrDash(formatSessionTags(session.Tags)),
 				)
 			}
 
@@ -432,6 +656,8 @@ func newSessionsListCmd(s store.SessionStore) *cobra.Command {
 	}
 
 	cmd.Flags().IntVarP(&limit, "limit", "n", 10, "Number of most recent sessions to show")
+	cmd.Flags().StringArrayVarP(&tagFilter, "tag", "t", nil, "Only show sessions with this tag (repeatable)")
+	cmd.Flags().StringArrayVar(&metaFilter, "meta", nil, "Only show sessions with this key=value metadata (repeatable)")
 	return cmd
 }
 
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+	"github.com/spf13/cobra"
+)
+
+func newSessionsMergeCmd(s store.SessionStore) *cobra.Command {
+	var (
+		title   string
+		replace bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "merge <id> <id>...",
+		Short: "Merge completed sessions into a new session ordered by step time",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(title) == "" {
+				return errors.New("provide a title for the merged session with `--title`")
+			}
+
+			seen := make(map[string]bool, len(args))
+			sources := make([]store.Session, 0, len(args))
+			for _, id := range args {
+				if seen[id] {
+					return fmt.Errorf("session %q is listed more than once", id)
+				}
+				seen[id] = true
+				session, err := loadCompletedSession(cmd, s, id)
+				if err != nil {
+					return err
+				}
+				sources = append(sources, *session)
+			}
+
+			merged, err := store.MergeSessions(store.NewSessionID(time.Now().UTC()), title, sources)
+			if err != nil {
+				return err
+			}
+
+			var removeIDs []string
+			if replace {
+				removeIDs = args
+			}
+			if err := s.ReplaceSessions(cmd.Context(), removeIDs, []store.Session{merged}); err != nil {
+				return fmt.Errorf("save merged session: %w", err)
+			}
+
+			printOK(cmd.OutOrStdout(), "Merged %d session(s) into %s %q with %d step(s)", len(sources), merged.ID, merged.Title, len(merged.Steps))
+			if replace {
+				fmt.Fprintln(cmd.OutOrStdout(), "Original sessions were removed.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&title, "title", "", "Title for the merged session")
+	cmd.Flags().BoolVar(&replace, "replace", false, "Remove the original sessions after merging")
+	return cmd
+}
+
+func newSessionsSplitCmd(s store.SessionStore) *cobra.Command {
+	var (
+		atStep  int
+		replace bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "split <id>",
+		Short: "Split a completed session into two sessions at a step",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if atStep == 0 {
+				return errors.New("provide the first step of the second session with `--at-step N`")
+			}
+
+			session, err := loadCompletedSession(cmd, s, args[0])
+			if err != nil {
+				return err
+			}
+
+			now := time.Now().UTC()
+			first, second, err := store.SplitSession(*session, atStep, store.NewSessionID(now), store.NewSessionID(now.Add(time.Nanosecond)))
+			if err != nil {
+				return err
+			}
+
+			var removeIDs []string
+			if replace {
+				removeIDs = []string{session.ID}
+			}
+			if err := s.ReplaceSessions(cmd.Context(), removeIDs, []store.Session{first, second}); err != nil {
+				return fmt.Errorf("save split sessions: %w", err)
+			}
+
+			printOK(cmd.OutOrStdout(), "Split session %s into %s (%d step(s)) and %s (%d step(s))", session.ID, first.ID, len(first.Steps), second.ID, len(second.Steps))
+			if replace {
+				fmt.Fprintln(cmd.OutOrStdout(), "Original session was removed.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&atStep, "at-step", 0, "Step number (1-based) that starts the second session")
+	cmd.Flags().BoolVar(&replace, "replace", false, "Remove the original session after splitting")
+	return cmd
+}
+
+func loadCompletedSession(cmd *cobra.Command, s store.SessionStore, id string) (*store.Session, error) {
+	session, err := s.SessionByID(cmd.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrSessionNotFound) {
+			return nil, fmt.Errorf("session %q not found", id)
+		}
+		if errors.Is(err, store.ErrNoSessions) {
+			return nil, errors.New("no completed sessions found")
+		}
+		return nil, fmt.Errorf("load session by id: %w", err)
+	}
+	return session, nil
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/shellwords"
+	"github.com/fixi2/Commandry/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// stepChange is one stored value rewritten by `sessions redact`.
+type stepChange struct {
+	SessionID string
+	Title     string
+	Step      int
+	Field     string
+	Before    string
+	After     string
+}
+
+func newSessionsRedactCmd(rt *storeRuntime) *cobra.Command {
+	var (
+		all       bool
+		sessionID string
+		dryRun    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "redact",
+		Short: "Re-apply the current policy to stored commands and working directories",
+		Long: "Run stored steps through the current policy and secret detectors again, so\n" +
+			"values recorded before a policy change are redacted too. Each session uses\n" +
+			"the policy profile of its environment. Rewritten files are backed up under\n" +
+			"backups/ in the store first.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if all == (sessionID != "") {
+				return errors.New("use either `--all` or `--session <id>`")
+			}
+
+			policies := make(map[string]*policy.Policy)
+			var (
+				changes  []stepChange
+				sessions = make(map[string]bool)
+				found    bool
+			)
+			result, err := rt.store.RewriteSessions(cmd.Context(), "redact", time.Now().UTC(), func(session *store.Session) bool {
+				if sessionID != "" && session.ID != sessionID {
+					return false
+				}
+				found = true
+				p, ok := policies[session.Env]
+				if !ok {
+					p = rt.profile(session.Env).Policy
+					policies[session.Env] = p
+				}
+				changed := redactStoredSession(session, p)
+				if len(changed) == 0 {
+					return false
+				}
+				changes = append(changes, changed...)
+				sessions[session.ID] = true
+				return !dryRun
+			})
+			if err != nil {
+				if errors.Is(err, store.ErrNotInitialized) {
+					return errors.New("Commandry is not initialized. Run `cmdry init` first")
+				}
+				return fmt.Errorf("redact sessions: %w", err)
+			}
+			if sessionID != "" && !found {
+				return fmt.Errorf("session %q not found", sessionID)
+			}
+
+			out := cmd.OutOrStdout()
+			if len(changes) == 0 {
+				fmt.Fprintln(out, "No stored steps need redaction under the current policy.")
+				return nil
+			}
+			printStepChanges(out, changes)
+			if dryRun {
+				fmt.Fprintf(out, "Dry run: %d change(s) in %d session(s). Nothing was written.\n", len(changes), len(sessions))
+				return nil
+			}
+			printOK(out, "Redacted %d value(s) in %d session(s)", len(changes), len(sessions))
+			fmt.Fprintf(out, "Backup: %s\n", result.BackupDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Redact every stored session, archives and the active session included")
+	cmd.Flags().StringVar(&sessionID, "session", "", "Redact a single session by id")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change without writing")
+	return cmd
+}
+
+// redactStoredSession runs each step of session through p and returns what it
+// changed. Stored commands are parsed as POSIX shell lines because the shell
+// they were typed into is not recorded.
+func redactStoredSession(session *store.Session, p *policy.Policy) []stepChange {
+	var changes []stepChange
+	record := func(i int, field, before, after string) {
+		changes = append(changes, stepChange{
+			SessionID: session.ID,
+			Title:     session.Title,
+			Step:      i + 1,
+			Field:     field,
+			Before:    before,
+			After:     after,
+		})
+	}
+
+	for i := range session.Steps {
+		step := &session.Steps[i]
+		if step.Command != policy.DeniedPlaceholder {
+			result := p.ApplyLine(step.Command, shellwords.POSIX)
+			if result.Command != step.Command {
+				record(i, "command", step.Command, result.Command)
+				step.Command = result.Command
+				if result.Denied {
+					step.Status = "REDACTED"
+					step.Reason = "policy_redacted"
+				}
+			}
+		}
+		if step.CWD != "" {
+			if cwd := p.RedactCWD(step.CWD); cwd != step.CWD {
+				record(i, "cwd", step.CWD, cwd)
+				step.CWD = cwd
+			}
+		}
+	}
+	return changes
+}
+
+func printStepChanges(out io.Writer, changes []stepChange) {
+	for _, c := range changes {
+		fmt.Fprintf(out, "%s %q step %d %s:\n", c.SessionID, c.Title, c.Step, c.Field)
+		fmt.Fprintf(out, "  - %s\n", c.Before)
+		fmt.Fprintf(out, "  + %s\n", c.After)
+	}
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/export"
+	"github.com/fixi2/Commandry/internal/store"
+	"github.com/spf13/cobra"
+)
+
+func newSessionsShowCmd(s store.SessionStore) *cobra.Command {
+	var (
+		showLast   bool
+		showActive bool
+		stepNumber int
+		format     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "show [id]",
+		Short: "Show a recorded session and its steps",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			selectors := 0
+			if len(args) == 1 {
+				selectors++
+			}
+			if showLast {
+				selectors++
+			}
+			if showActive {
+				selectors++
+			}
+			if selectors == 0 {
+				return errors.New("provide a session id, `--last` or `--active`")
+			}
+			if selectors > 1 {
+				return errors.New("use only one of <id>, `--last` or `--active`")
+			}
+			format = strings.ToLower(strings.TrimSpace(format))
+			if format != "table" && format != "json" {
+				return errors.New("unsupported format. Use `table` or `json`")
+			}
+
+			session, err := loadSessionForShow(cmd, s, args, showLast, showActive)
+			if err != nil {
+				return err
+			}
+
+			if stepNumber != 0 {
+				if stepNumber < 1 || stepNumber > len(session.Steps) {
+					return fmt.Errorf("step %d is out of range (session has %d step(s))", stepNumber, len(session.Steps))
+				}
+				step := session.Steps[stepNumber-1]
+				if format == "json" {
+					return writeJSON(cmd.OutOrStdout(), step)
+				}
+				renderStepDetail(cmd.OutOrStdout(), stepNumber, step)
+				return nil
+			}
+
+			if format == "json" {
+				return writeJSON(cmd.OutOrStdout(), session)
+			}
+			renderSessionTable(cmd.OutOrStdout(), session)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&showLast, "last", "l", false, "Show the most recent completed session")
+	cmd.Flags().BoolVar(&showActive, "active", false, "Show the active recording session")
+	cmd.Flags().IntVar(&stepNumber, "step", 0, "Show full detail for step N (1-based)")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format: table|json")
+	return cmd
+}
+
+func loadSessionForShow(cmd *cobra.Command, s store.SessionStore, args []string, last, active bool) (*store.Session, error) {
+	switch {
+	case active:
+		session, err := s.GetActiveSession(cmd.Context())
+		if err != nil {
+			if errors.Is(err, store.ErrNoActiveSession) {
+				return nil, errors.New("no active session. Start one with `cmdry start \"<title>\"`")
+			}
+			if errors.Is(err, store.ErrNotInitialized) {
+				return nil, errors.New("Commandry is not initialized. Run `cmdry init` first")
+			}
+			return nil, fmt.Errorf("read active session: %w", err)
+		}
+		return session, nil
+	case last:
+		session, err := s.LastSession(cmd.Context())
+		if err != nil {
+			if errors.Is(err, store.ErrNoSessions) {
+				return nil, errors.New("no completed sessions found")
+			}
+			return nil, fmt.Errorf("load last session: %w", err)
+		}
+		return session, nil
+	default:
+		return loadCompletedSession(cmd, s, args[0])
+	}
+}
+
+func renderSessionTable(out io.Writer, session *store.Session) {
+	fmt.Fprintf(out, "Session: %s\n", session.ID)
+	fmt.Fprintf(out, "Title: %s\n", session.Title)
+	if session.Env != "" {
+		fmt.Fprintf(out, "Env: %s\n", session.Env)
+	}
+	if len(session.Tags) > 0 {
+		fmt.Fprintf(out, "Tags: %s\n", formatSessionTags(session.Tags))
+	}
+	for _, key := range sortedMetaKeys(session.Meta) {
+		fmt.Fprintf(out, "Meta: %s=%s\n", key, session.Meta[key])
+	}
+	fmt.Fprintf(out, "Started: %s\n", session.StartedAt.Format(time.RFC3339))
+	if session.EndedAt != nil {
+		fmt.Fprintf(out, "Ended: %s\n", session.EndedAt.Format(time.RFC3339))
+	} else {
+		fmt.Fprintln(out, "Ended: (recording)")
+	}
+	if session.PolicyFingerprint != "" {
+		fmt.Fprintf(out, "Policy fingerprint: %s\n", session.PolicyFingerprint)
+	}
+	fmt.Fprintf(out, "Recorded steps: %d\n", len(session.Steps))
+	if len(session.Steps) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out)
+	// Columns two spaces apart, aligned over every row.
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tTIME\tSTATUS\tEXIT\tDURATION\tCWD\tCOMMAND")
+	for i, step := range session.Steps {
+		status, _ := export.NormalizeResult(step)
+		fmt.Fprintf(
+			tw,
+			"%d\t%s\t%s\t%s\t%d ms\t%s\t%s\n",
+			i+1,
+			step.Timestamp.Format(time.RFC3339),
+			status,
+			formatExitCode(step.ExitCode),
+			step.DurationMS,
+			valueOrDash(step.CWD),
+			previewCommand(step.Command),
+		)
+	}
+	_ = tw.Flush()
+}
+
+func renderStepDetail(out io.Writer, number int, step store.Step) {
+	status, reason := export.NormalizeResult(step)
+	fmt.Fprintf(out, "Step: %d\n", number)
+	fmt.Fprintf(out, "Time: %s\n", step.Timestamp.Format(time.RFC3339))
+	fmt.Fprintf(out, "Status: %s\n", status)
+	if reason != "" {
+		fmt.Fprintf(out, "Reason: %s\n", reason)
+	}
+	fmt.Fprintf(out, "Exit code: %s\n", formatExitCode(step.ExitCode))
+	fmt.Fprintf(out, "Duration: %d ms\n", step.DurationMS)
+	fmt.Fprintf(out, "CWD: %s\n", valueOrDash(step.CWD))
+	if step.PolicyFingerprint != "" {
+		fmt.Fprintf(out, "Policy fingerprint: %s (changed)\n", step.PolicyFingerprint)
+	}
+	if step.Guard != nil {
+		fmt.Fprintf(out, "Guard: %s (%s)\n", step.Guard.Decision, step.Guard.Rule)
+	}
+	fmt.Fprintln(out, "Command:")
+	fmt.Fprintf(out, "   %s\n", step.Command)
+}
+
+func writeJSON(out io.Writer, value any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(value); err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	return nil
+}
+
+func valueOrDash(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "-"
+	}
+	return v
+}
@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/config"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestRenderSessionTable(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC)
+	ended := started.Add(time.Minute)
+	session := &store.Session{
+		ID:        "42",
+		Title:     "Deploy",
+		Env:       "staging",
+		StartedAt: started,
+		EndedAt:   &ended,
+		Steps: []store.Step{
+			{Timestamp: started, Command: "kubectl apply -f deploy.yaml", Status: "OK", ExitCode: intPtr(0), DurationMS: 12, CWD: "/repo"},
+			{Timestamp: started, Command: "[REDACTED BY POLICY]", Status: "REDACTED", Reason: "policy_redacted"},
+		},
+	}
+
+	var out bytes.Buffer
+	renderSessionTable(&out, session)
+	text := out.String()
+	for _, want := range []string{
+		"Session: 42",
+		"Env: staging",
+		"Recorded steps: 2",
+		"#  TIME                  STATUS    EXIT  DURATION  CWD    COMMAND\n",
+		"1  2026-02-03T10:00:00Z  OK        0     12 ms     /repo  kubectl apply -f deploy.yaml\n",
+		"2  2026-02-03T10:00:00Z  REDACTED  n/a   0 ms      -      [REDACTED BY POLICY]\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("table output missing %q in %q", want, text)
+		}
+	}
+}
+
+func TestRenderStepDetail(t *testing.T) {
+	t.Parallel()
+
+	step := store.Step{
+		Timestamp:  time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		Command:    "terraform apply",
+		Status:     "FAILED",
+		ExitCode:   intPtr(1),
+		DurationMS: 900,
+		CWD:        "/infra",
+	}
+
+	var out bytes.Buffer
+	renderStepDetail(&out, 3, step)
+	text := out.String()
+	for _, want := range []string{"Step: 3", "Status: FAILED", "Reason: nonzero_exit", "Exit code: 1", "CWD: /infra", "   terraform apply"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("step detail missing %q in %q", want, text)
+		}
+	}
+}
+
+func TestSessionsShowCommand(t *testing.T) {
+	isolateConfigDirs(t)
+
+	mustExecute(t, "init")
+	mustExecute(t, "hooks", "enable")
+	mustExecute(t, "start", "show-me")
+	mustExecute(t, "hook", "record", "--command", "echo two", "--cwd", "/tmp", "--exit-code", "3")
+
+	active := mustExecute(t, "sessions", "show", "--active")
+	if !strings.Contains(active, "Ended: (recording)") || !strings.Contains(active, "echo two") {
+		t.Fatalf("unexpected active output: %s", active)
+	}
+
+	mustExecute(t, "stop")
+
+	detail := mustExecute(t, "sessions", "show", "--last", "--step", "1")
+	if !strings.Contains(detail, "Status: FAILED") || !strings.Contains(detail, "Exit code: 3") {
+		t.Fatalf("unexpected step detail: %s", detail)
+	}
+
+	raw := mustExecute(t, "sessions", "show", "--last", "--format", "json")
+	var session store.Session
+	if err := json.Unmarshal([]byte(raw), &session); err != nil {
+		t.Fatalf("decode json output: %v\n%s", err, raw)
+	}
+	if session.Title != "show-me" || len(session.Steps) != 1 {
+		t.Fatalf("unexpected json session: %+v", session)
+	}
+
+	root, err := NewRootCommand()
+	if err != nil {
+		t.Fatalf("NewRootCommand failed: %v", err)
+	}
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"sessions", "show", "--last", "--step", "5"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Fatalf("expected out of range error, got %v", err)
+	}
+}
+
+func TestSessionTagsAndListFilters(t *testing.T) {
+	isolateConfigDirs(t)
+
+	mustExecute(t, "init")
+	mustExecute(t, "start", "tagged", "--tag", "incident", "--meta", "ticket=CHG-7")
+	status := mustExecute(t, "status")
+	if !strings.Contains(status, "Tags: incident") || !strings.Contains(status, "Meta: ticket=CHG-7") {
+		t.Fatalf("status missing labels: %s", status)
+	}
+	mustExecute(t, "tag", "--tag", "db", "--meta", "service=billing")
+	mustExecute(t, "stop")
+
+	mustExecute(t, "start", "untagged")
+	mustExecute(t, "stop")
+	mustExecute(t, "tag", "--last", "--tag", "later")
+
+	list := mustExecute(t, "sessions", "list", "--tag", "incident", "--meta", "service=billing")
+	if !strings.Contains(list, "tagged") || strings.Contains(list, "untagged") {
+		t.Fatalf("unexpected filtered list: %s", list)
+	}
+	if !strings.Contains(list, "incident,db") {
+		t.Fatalf("expected tags column in list: %s", list)
+	}
+
+	list = mustExecute(t, "sessions", "list", "--tag", "later")
+	if !strings.Contains(list, "untagged") {
+		t.Fatalf("expected retagged completed session: %s", list)
+	}
+
+	root, err := NewRootCommand()
+	if err != nil {
+		t.Fatalf("NewRootCommand failed: %v", err)
+	}
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"start", "bad", "--meta", "novalue"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "key=value") {
+		t.Fatalf("expected key=value error, got %v", err)
+	}
+}
+
+func TestSessionsMergeAndSplit(t *testing.T) {
+	isolateConfigDirs(t)
+
+	mustExecute(t, "init")
+	mustExecute(t, "hooks", "enable")
+	var ids []string
+	for i, title := range []string{"left", "right"} {
+		mustExecute(t, "start", title)
+		for j := 0; j < 2; j++ {
+			ts := time.Date(2026, 3, 1, 10, 0, 2*j+i, 0, time.UTC).Format(time.RFC3339)
+			mustExecute(t, "hook", "record", "--command", fmt.Sprintf("echo %s-%d", title, j+1), "--timestamp", ts)
+		}
+		mustExecute(t, "stop")
+		raw := mustExecute(t, "sessions", "show", "--last", "--format", "json")
+		var session store.Session
+		if err := json.Unmarshal([]byte(raw), &session); err != nil {
+			t.Fatalf("decode session: %v", err)
+		}
+		ids = append(ids, session.ID)
+	}
+
+	mustExecute(t, "sessions", "merge", ids[0], ids[1], "--title", "combined", "--replace")
+	raw := mustExecute(t, "sessions", "show", "--last", "--format", "json")
+	var merged store.Session
+	if err := json.Unmarshal([]byte(raw), &merged); err != nil {
+		t.Fatalf("decode merged: %v", err)
+	}
+	var order []string
+	for _, step := range merged.Steps {
+		order = append(order, step.Command)
+	}
+	if strings.Join(order, ",") != "echo left-1,echo right-1,echo left-2,echo right-2" {
+		t.Fatalf("unexpected merged order: %v", order)
+	}
+	list := mustExecute(t, "sessions", "list")
+	if strings.Contains(list, "\tleft\t") || strings.Contains(list, "\tright\t") {
+		t.Fatalf("expected originals to be replaced: %s", list)
+	}
+
+	mustExecute(t, "sessions", "split", merged.ID, "--at-step", "3")
+	list = mustExecute(t, "sessions", "list")
+	for _, want := range []string{"combined (1/2)\t2", "combined (2/2)\t2", "combined\t4"} {
+		if !strings.Contains(list, want) {
+			t.Fatalf("sessions list missing %q: %s", want, list)
+		}
+	}
+}
+
+func isolateConfigDirs(t *testing.T) {
+	t.Helper()
+
+	rootBase := t.TempDir()
+	appData := filepath.Join(rootBase, "appdata")
+	home := filepath.Join(rootBase, "home")
+	for _, dir := range []string{appData, home} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+	t.Setenv("APPDATA", appData)
+	t.Setenv("XDG_CONFIG_HOME", appData)
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(config.SystemPolicyEnvVar, filepath.Join(rootBase, "system", config.PolicyFileName))
+}
+
+func mustExecute(t *testing.T, args ...string) string {
+	t.Helper()
+
+	root, err := NewRootCommand()
+	if err != nil {
+		t.Fatalf("NewRootCommand failed: %v", err)
+	}
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("%v failed: %v\n%s", args, err, out.String())
+	}
+	return out.String()
+}
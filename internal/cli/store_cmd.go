@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+	"github.com/spf13/cobra"
+)
+
+func newStoreCmd(rt *storeRuntime) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "store",
+		Short: "Maintain the session store",
+	}
+	cmd.AddCommand(newStoreCompactCmd(rt))
+	return cmd
+}
+
+func newStoreCompactCmd(rt *storeRuntime) *cobra.Command {
+	return &cobra.Command{
+		Use:   "compact",
+		Short: "Move sessions from earlier months into compressed monthly archives",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			result, err := rt.store.Compact(cmd.Context(), time.Now().UTC())
+			if err != nil {
+				if errors.Is(err, store.ErrNotInitialized) {
+					return errors.New("Commandry is not initialized. Run `cmdry init` first")
+				}
+				return fmt.Errorf("compact store: %w", err)
+			}
+
+			printStoreLocation(cmd.OutOrStdout(), rt.location)
+			if result.Archived == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "Nothing to compact: all sessions belong to the current month.")
+				return nil
+			}
+			printOK(cmd.OutOrStdout(), "Archived %d session(s)", result.Archived)
+			for _, name := range result.Archives {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", name)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestStoreCompactKeepsArchivedSessionsVisible(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	started := time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC)
+	ended := started.Add(time.Hour)
+	old := store.Session{ID: store.NewSessionID(started), Title: "November rollout", StartedAt: started, EndedAt: &ended}
+	if err := store.NewJSONStore(dir).ReplaceSessions(context.Background(), nil, []store.Session{old}); err != nil {
+		t.Fatalf("seed session: %v", err)
+	}
+
+	out := mustExecute(t, "store", "compact")
+	if !strings.Contains(out, "Archived 1 session(s)") || !strings.Contains(out, "sessions-2025-11.jsonl.gz") {
+		t.Fatalf("unexpected compact output: %s", out)
+	}
+
+	out = mustExecute(t, "store", "compact")
+	if !strings.Contains(out, "Nothing to compact") {
+		t.Fatalf("expected no-op compact output: %s", out)
+	}
+
+	list := mustExecute(t, "sessions", "list")
+	if !strings.Contains(list, "November rollout") {
+		t.Fatalf("expected archived session in list: %s", list)
+	}
+	show := mustExecute(t, "sessions", "show", old.ID)
+	if !strings.Contains(show, "November rollout") {
+		t.Fatalf("expected archived session in show: %s", show)
+	}
+}
+
+func TestSessionsRedactRewritesStoredSteps(t *testing.T) {
+	isolateConfigDirs(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	t.Setenv("CMDRY_HOME", dir)
+	mustExecute(t, "init")
+
+	started := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	ended := started.Add(time.Hour)
+	seeded := store.Session{
+		ID:        store.NewSessionID(started),
+		Title:     "Old deploy",
+		StartedAt: started,
+		EndedAt:   &ended,
+		Steps: []store.Step{
+			{Timestamp: started, Command: "deploy --key acme_live_0123456789abcdefABCDEF0123456789", Status: "OK"},
+			{Timestamp: started, Command: "curl --token [REDACTED] https://example.com", Status: "OK", CWD: "/home/alice/src"},
+			{Timestamp: started, Command: "echo hi | printenv", Status: "OK"},
+		},
+	}
+	if err := store.NewJSONStore(dir).ReplaceSessions(context.Background(), nil, []store.Session{seeded}); err != nil {
+		t.Fatalf("seed session: %v", err)
+	}
+	content := "policy:\n  redaction_rules:\n    - name: acme\n      pattern: 'acme_live_[A-Za-z0-9]{32}'\n    - name: home\n      pattern: '^/home/(?P<secret>[^/]+)'\n      applies_to: cwd\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	out := mustExecute(t, "sessions", "redact", "--all", "--dry-run")
+	for _, want := range []string{
+		"step 1 command:\n  - deploy --key acme_live_0123456789abcdefABCDEF0123456789\n  + deploy --key [REDACTED]",
+		"step 2 cwd:\n  - /home/alice/src\n  + /home/[REDACTED]/src",
+		"step 3 command:\n  - echo hi | printenv\n  + [REDACTED BY POLICY]",
+		"Dry run: 3 change(s) in 1 session(s)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("dry run output missing %q:\n%s", want, out)
+		}
+	}
+	if show := mustExecute(t, "sessions", "show", seeded.ID, "--format", "json"); !strings.Contains(show, "acme_live_") {
+		t.Fatalf("dry run must not rewrite the store: %s", show)
+	}
+
+	out = mustExecute(t, "sessions", "redact", "--session", seeded.ID)
+	if !strings.Contains(out, "Redacted 3 value(s) in 1 session(s)") || !strings.Contains(out, "Backup: "+filepath.Join(dir, "backups", "redact-")) {
+		t.Fatalf("unexpected redact output: %s", out)
+	}
+	show := mustExecute(t, "sessions", "show", seeded.ID, "--format", "json")
+	if strings.Contains(show, "acme_live_") || strings.Contains(show, "alice") || !strings.Contains(show, `"reason": "policy_redacted"`) {
+		t.Fatalf("expected redacted session: %s", show)
+	}
+	backups, err := filepath.Glob(filepath.Join(dir, "backups", "redact-*", "sessions.jsonl"))
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected one backup, got %v (%v)", backups, err)
+	}
+	if original, err := os.ReadFile(backups[0]); err != nil || !strings.Contains(string(original), "acme_live_") {
+		t.Fatalf("backup should keep the original session: %v", err)
+	}
+
+	if out := mustExecute(t, "sessions", "redact", "--all"); !strings.Contains(out, "No stored steps need redaction") {
+		t.Fatalf("expected a second pass to be a no-op: %s", out)
+	}
+}
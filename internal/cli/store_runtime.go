@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/config"
+	"github.com/fixi2/Commandry/internal/hooks"
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/privacy"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+// storeRuntime holds the store-bound dependencies shared by all commands.
+// Commands keep pointers to these values, so relocating the runtime after
+// flag parsing (for example `--store`) is visible everywhere.
+type storeRuntime struct {
+	location   store.Location
+	workingDir string
+	config     config.Config
+	// layers is the merged policy with the origin of each entry; config.Policy
+	// holds the same values flattened.
+	layers     policy.Merged
+	store      *store.JSONStore
+	policy     *policy.Policy
+	hooksState *hooks.FileStateStore
+}
+
+func newStoreRuntime(loc store.Location, workingDir string) *storeRuntime {
+	rt := &storeRuntime{
+		location:   loc,
+		workingDir: workingDir,
+		store:      store.NewJSONStore(loc.Dir),
+		policy:     policy.NewDefault(),
+		hooksState: hooks.NewFileStateStore(loc.Dir),
+	}
+	rt.loadConfig()
+	return rt
+}
+
+func (rt *storeRuntime) use(loc store.Location) {
+	if loc == rt.location {
+		return
+	}
+	rt.location = loc
+	rt.store.SetRootDir(loc.Dir)
+	rt.hooksState.SetRootDir(loc.Dir)
+	rt.loadConfig()
+}
+
+// chdir points project policy discovery at dir.
+func (rt *storeRuntime) chdir(dir string) {
+	if dir == rt.workingDir {
+		return
+	}
+	rt.workingDir = dir
+	rt.loadConfig()
+}
+
+// loadConfig reads the user's config.yaml and merges its policy section with
+// the system and project policy files, then applies the result to the
+// runtime. In a project store, the project's config.yaml is part of the
+// project layer and its other sections replace the user's. A broken file is
+// skipped with a warning so recording keeps working; `cmdry config validate`
+// reports the details.
+func (rt *storeRuntime) loadConfig() {
+	path := config.Path(rt.location.Dir)
+	var projectConfig *policy.Layer
+	if rt.location.Source == store.SourceProject {
+		project := policy.Layer{Name: policy.LayerProject, Path: path, Config: loadConfigFile(path)}
+		projectConfig = &project
+		path = ""
+		if dir, err := store.DefaultRootDir(); err == nil {
+			path = config.Path(dir)
+		}
+	}
+	cfg := config.Default()
+	if path != "" {
+		cfg = loadConfigFile(path)
+	}
+
+	system, hasSystem := loadPolicyLayer(policy.LayerSystem, config.SystemPolicyPath())
+	layers := []policy.Layer{{Name: policy.LayerUser, Path: path, Config: cfg}}
+	if hasSystem {
+		layers = append([]policy.Layer{system}, layers...)
+	}
+	if projectConfig != nil {
+		layers = append(layers, *projectConfig)
+		cfg = cfg.Overlay(projectConfig.Config)
+	}
+	if dir, ok := store.FindProjectStore(rt.workingDir); ok && rt.workingDir != "" {
+		if project, ok := loadPolicyLayer(policy.LayerProject, filepath.Join(dir, config.PolicyFileName)); ok {
+			layers = append(layers, project)
+		}
+	}
+
+	merged := policy.MergeLayers(layers)
+	p, err := policy.NewFromConfig(merged.Config())
+	if err != nil {
+		// Keep the system baseline even when a user or project entry is broken.
+		fmt.Fprintf(os.Stderr, "Warning: failed to load policy config (%v). Using the built-in and system policy only.\n", err)
+		var baseline []policy.Layer
+		if hasSystem {
+			baseline = append(baseline, system)
+		}
+		merged = policy.MergeLayers(baseline)
+		if p, err = policy.NewFromConfig(merged.Config()); err != nil {
+			merged = policy.MergeLayers(nil)
+			p = policy.NewDefault()
+		}
+	}
+	cfg.Policy = merged.Config()
+	rt.config = cfg
+	rt.layers = merged
+	*rt.policy = *p
+	rt.store.SetAutoCompact(cfg.Retention.AutoCompact)
+}
+
+// loadConfigFile reads a store's config.yaml, falling back to the defaults
+// with a warning when it is broken.
+func loadConfigFile(path string) config.Config {
+	cfg, _, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load config from %s (%v). Using defaults.\n", path, err)
+		return config.Default()
+	}
+	return cfg
+}
+
+// loadPolicyLayer reads a system or project policy file. A missing file is not
+// a layer; a broken one is skipped with a warning.
+func loadPolicyLayer(name, path string) (policy.Layer, bool) {
+	if _, err := os.Stat(path); err != nil {
+		return policy.Layer{}, false
+	}
+	cfg, _, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load %s policy from %s (%v). Ignoring it.\n", name, path, err)
+		return policy.Layer{}, false
+	}
+	return policy.Layer{Name: name, Path: path, Config: cfg}, true
+}
+
+// profile returns the policy for sessions labelled env. Sessions without a
+// matching profile share rt.policy; a profile that fails to compile falls back
+// to it with a warning.
+func (rt *storeRuntime) profile(env string) policy.Profile {
+	base := policy.Profile{Policy: rt.policy, RecordCWD: rt.config.Capture.RecordCWD}
+	name, _, ok := rt.config.Policy.Profile(env)
+	if !ok {
+		return base
+	}
+	profile, err := policy.ResolveProfile(rt.config, env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load policy profile %q (%v). Using the base policy.\n", name, err)
+		return base
+	}
+	return profile
+}
+
+func describeProfile(profile policy.Profile) string {
+	desc := fmt.Sprintf("enforce_denylist: %s, record_cwd: %s", boolLabel(profile.Policy.EnforceDenylist()), boolLabel(profile.RecordCWD))
+	if profile.Protected {
+		desc += ", protected"
+	}
+	return desc
+}
+
+// recordScrubber returns the privacy scrubber to apply while recording, or nil
+// unless privacy.on_record is set.
+func (rt *storeRuntime) recordScrubber() *privacy.Scrubber {
+	if !rt.config.Privacy.OnRecord {
+		return nil
+	}
+	return privacy.New(rt.config.Privacy)
+}
+
+// runbooksDir resolves export.output_dir against workingDir.
+func (rt *storeRuntime) runbooksDir(workingDir string) string {
+	dir := strings.TrimSpace(rt.config.Export.OutputDir)
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(workingDir, dir)
+}
+
+func printStoreLocation(out io.Writer, loc store.Location) {
+	fmt.Fprintf(out, "Store: %s\n", loc)
+}
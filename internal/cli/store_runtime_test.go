@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestProjectStoreDiscoveryAndOverride(t *testing.T) {
+	isolateConfigDirs(t)
+	t.Setenv("CMDRY_HOME", "")
+
+	project := t.TempDir()
+	nested := filepath.Join(project, "svc")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("mkdir nested: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("chdir project: %v", err)
+	}
+	mustExecute(t, "init", "--project")
+	if _, err := os.Stat(filepath.Join(project, ".commandry", "config.yaml")); err != nil {
+		t.Fatalf("expected project config: %v", err)
+	}
+
+	if err := os.Chdir(nested); err != nil {
+		t.Fatalf("chdir nested: %v", err)
+	}
+	status := mustExecute(t, "status")
+	if !strings.Contains(status, filepath.Join(project, ".commandry")+" (project)") {
+		t.Fatalf("expected project store in status: %s", status)
+	}
+	doctor := mustExecute(t, "doctor")
+	if !strings.Contains(doctor, "Store source: project") {
+		t.Fatalf("expected project store in doctor output: %s", doctor)
+	}
+
+	override := filepath.Join(t.TempDir(), "explicit")
+	mustExecute(t, "--store", override, "init")
+	status = mustExecute(t, "--store", override, "status")
+	if !strings.Contains(status, override+" (flag)") {
+		t.Fatalf("expected flag store in status: %s", status)
+	}
+
+	t.Setenv("CMDRY_HOME", override)
+	status = mustExecute(t, "status")
+	if !strings.Contains(status, override+" (env)") {
+		t.Fatalf("expected env store in status: %s", status)
+	}
+}
+
+func TestProjectStoreLayersOverUserConfig(t *testing.T) {
+	isolateConfigDirs(t)
+	t.Setenv("CMDRY_HOME", "")
+
+	userDir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "commandry")
+	mustExecute(t, "--store", userDir, "init")
+	user := "policy:\n  denylist: [\"vault read*\"]\n  enforce_denylist: true\n  lock: [denylist, enforce_denylist]\nprivacy:\n  home: true\n"
+	if err := os.WriteFile(filepath.Join(userDir, "config.yaml"), []byte(user), 0o600); err != nil {
+		t.Fatalf("write user config: %v", err)
+	}
+
+	project := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("chdir project: %v", err)
+	}
+	mustExecute(t, "init", "--project")
+	projectConfig := filepath.Join(project, ".commandry", "config.yaml")
+	if err := os.WriteFile(projectConfig, []byte("policy:\n  denylist: [printenv]\n  enforce_denylist: false\nexport:\n  output_dir: docs\n"), 0o600); err != nil {
+		t.Fatalf("write project config: %v", err)
+	}
+
+	out := mustExecute(t, "policy", "show")
+	for _, want := range []string{
+		"  user\t" + filepath.Join(userDir, "config.yaml"),
+		"  project\t" + projectConfig,
+		"enforce_denylist: true\t(user, locked)",
+		"  vault read*\t(user, locked)",
+		"  printenv\t(project)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("policy show missing %q:\n%s", want, out)
+		}
+	}
+	rt := newStoreRuntime(store.Location{Dir: filepath.Join(project, ".commandry"), Source: store.SourceProject}, project)
+	if rt.config.Export.OutputDir != "docs" || !rt.config.Privacy.Home {
+		t.Fatalf("expected project export and user privacy settings: %+v", rt.config)
+	}
+}
@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var metaKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+type sessionLabels struct {
+	addTags    []string
+	removeTags []string
+	setMeta    map[string]string
+	removeMeta []string
+}
+
+func (l sessionLabels) empty() bool {
+	return len(l.addTags) == 0 && len(l.removeTags) == 0 && len(l.setMeta) == 0 && len(l.removeMeta) == 0
+}
+
+func (l sessionLabels) apply(session *store.Session) error {
+	for _, tag := range l.addTags {
+		if !session.HasTag(tag) {
+			session.Tags = append(session.Tags, tag)
+		}
+	}
+	if len(l.removeTags) > 0 {
+		kept := session.Tags[:0]
+		for _, tag := range session.Tags {
+			if !containsFold(l.removeTags, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		session.Tags = kept
+	}
+	if len(session.Tags) == 0 {
+		session.Tags = nil
+	}
+
+	if len(l.setMeta) > 0 && session.Meta == nil {
+		session.Meta = make(map[string]string, len(l.setMeta))
+	}
+	for key, value := range l.setMeta {
+		session.Meta[key] = value
+	}
+	for _, key := range l.removeMeta {
+		delete(session.Meta, key)
+	}
+	if len(session.Meta) == 0 {
+		session.Meta = nil
+	}
+	return nil
+}
+
+func parseTagFlags(values []string) ([]string, error) {
+	tags := make([]string, 0, len(values))
+	for _, raw := range values {
+		tag := strings.TrimSpace(raw)
+		if tag == "" {
+			return nil, errors.New("tag cannot be empty")
+		}
+		if strings.ContainsAny(tag, ", \t") {
+			return nil, fmt.Errorf("tag %q cannot contain spaces or commas", tag)
+		}
+		if !containsFold(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func parseMetaFlags(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	meta := make(map[string]string, len(values))
+	for _, raw := range values {
+		key, value, ok := strings.Cut(raw, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("metadata %q must use key=value form", raw)
+		}
+		if !metaKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("metadata key %q may only contain letters, digits, '.', '_' and '-'", key)
+		}
+		meta[key] = strings.TrimSpace(value)
+	}
+	return meta, nil
+}
+
+func parseMetaKeys(values []string) ([]string, error) {
+	keys := make([]string, 0, len(values))
+	for _, raw := range values {
+		key := strings.TrimSpace(raw)
+		if !metaKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("metadata key %q may only contain letters, digits, '.', '_' and '-'", key)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// matchesLabelFilters reports whether a session has every requested tag and
+// every requested key=value metadata pair.
+func matchesLabelFilters(session *store.Session, tags []string, meta map[string]string) bool {
+	for _, tag := range tags {
+		if !session.HasTag(tag) {
+			return false
+		}
+	}
+	for key, value := range meta {
+		got, ok := session.Meta[key]
+		if !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+func formatSessionTags(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+func sortedMetaKeys(meta map[string]string) []string {
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsFold(values []string, want string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTagCmd(s store.SessionStore) *cobra.Command {
+	var (
+		sessionID  string
+		tagLast    bool
+		addTags    []string
+		removeTags []string
+		setMeta    []string
+		removeMeta []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Add or remove tags and metadata on a session",
+		Long:  "Add or remove tags and key=value metadata. Targets the active session unless `--session <id>` or `--last` is given.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if sessionID != "" && tagLast {
+				return errors.New("use either `--last` or `--session <id>`, not both")
+			}
+
+			var (
+				labels sessionLabels
+				err    error
+			)
+			if labels.addTags, err = parseTagFlags(addTags); err != nil {
+				return err
+			}
+			if labels.removeTags, err = parseTagFlags(removeTags); err != nil {
+				return err
+			}
+			if labels.setMeta, err = parseMetaFlags(setMeta); err != nil {
+				return err
+			}
+			if labels.removeMeta, err = parseMetaKeys(removeMeta); err != nil {
+				return err
+			}
+			if labels.empty() {
+				return errors.New("nothing to change. Use `--tag`, `--meta`, `--remove-tag` or `--remove-meta`")
+			}
+
+			targetID := sessionID
+			switch {
+			case tagLast:
+				last, err := s.LastSession(cmd.Context())
+				if err != nil {
+					if errors.Is(err, store.ErrNoSessions) {
+						return errors.New("no completed sessions found")
+					}
+					return fmt.Errorf("load last session: %w", err)
+				}
+				targetID = last.ID
+			case targetID == "":
+				active, err := s.GetActiveSession(cmd.Context())
+				if err != nil {
+					if errors.Is(err, store.ErrNoActiveSession) {
+						return errors.New("no active session. Use `--session <id>` or `--last` to tag a completed session")
+					}
+					return fmt.Errorf("read active session: %w", err)
+				}
+				targetID = active.ID
+			}
+
+			session, err := s.UpdateSession(cmd.Context(), targetID, labels.apply)
+			if err != nil {
+				if errors.Is(err, store.ErrSessionNotFound) {
+					return fmt.Errorf("session %q not found", targetID)
+				}
+				return fmt.Errorf("update session: %w", err)
+			}
+
+			printOK(cmd.OutOrStdout(), "Updated session %q (tags: %s, metadata: %d key(s))", session.Title, valueOrDash(formatSessionTags(session.Tags)), len(session.Meta))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sessionID, "session", "", "Tag a specific completed session by id")
+	cmd.Flags().BoolVarP(&tagLast, "last", "l", false, "Tag the most recent completed session")
+	cmd.Flags().StringArrayVarP(&addTags, "tag", "t", nil, "Add a tag (repeatable)")
+	cmd.Flags().StringArrayVar(&setMeta, "meta", nil, "Set key=value metadata (repeatable)")
+	cmd.Flags().StringArrayVar(&removeTags, "remove-tag", nil, "Remove a tag (repeatable)")
+	cmd.Flags().StringArrayVar(&removeMeta, "remove-meta", nil, "Remove a metadata key (repeatable)")
+	return cmd
+}
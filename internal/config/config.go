@@ -0,0 +1,417 @@
+// Package config loads config.yaml into a typed model. Unknown keys are
+// reported as warnings rather than errors so older binaries keep working with
+// newer config files.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FileName is the config file inside a store directory.
+	FileName = "config.yaml"
+	// PolicyFileName is the policy file of the system and project layers. It
+	// uses the config.yaml format; only its policy section is read.
+	PolicyFileName = "policy.yaml"
+	// SystemPolicyEnvVar overrides the location of the system policy file.
+	SystemPolicyEnvVar = "CMDRY_SYSTEM_POLICY"
+)
+
+// LockableKeys are the policy keys a layer can lock against the layers above
+// it.
+var LockableKeys = []string{"denylist", "redaction_keywords", "redaction_rules", "detectors", "enforce_denylist", "guard", "profiles"}
+
+type Config struct {
+	Policy    PolicyConfig    `yaml:"policy" json:"policy"`
+	Capture   CaptureConfig   `yaml:"capture" json:"capture"`
+	Export    ExportConfig    `yaml:"export" json:"export"`
+	Hooks     HooksConfig     `yaml:"hooks" json:"hooks"`
+	Retention RetentionConfig `yaml:"retention" json:"retention"`
+	Privacy   PrivacyConfig   `yaml:"privacy" json:"privacy"`
+
+	// lines maps dotted key paths (e.g. "policy.redaction_rules[0].pattern")
+	// to the line they were defined on.
+	lines map[string]int
+}
+
+// Line returns the line a key path was defined on, or 0 if it was not set in
+// the file.
+func (c Config) Line(path string) int {
+	return c.lines[path]
+}
+
+// PolicyConfig mirrors policy.Options. Empty lists mean "use the built-in
+// defaults".
+type PolicyConfig struct {
+	Denylist          []string        `yaml:"denylist" json:"denylist"`
+	RedactionKeywords []string        `yaml:"redaction_keywords" json:"redaction_keywords"`
+	RedactionRules    []RedactionRule `yaml:"redaction_rules,omitempty" json:"redaction_rules,omitempty"`
+	// Detectors enables or disables built-in secret detectors by name; all
+	// detectors are on unless set to false here.
+	Detectors       map[string]bool `yaml:"detectors,omitempty" json:"detectors,omitempty"`
+	EnforceDenylist bool            `yaml:"enforce_denylist" json:"enforce_denylist"`
+	// Profiles tighten the policy for sessions started with a matching
+	// environment label (`cmdry start -e prod`).
+	Profiles map[string]PolicyProfile `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+	// Guard lists the dangerous commands that need typed confirmation in
+	// protected environments.
+	Guard GuardConfig `yaml:"guard,omitempty" json:"guard,omitempty"`
+	// Lock lists keys that higher policy layers cannot change; locked lists
+	// can only be extended.
+	Lock []string `yaml:"lock,omitempty" json:"lock,omitempty"`
+}
+
+// Locks reports whether key is in the lock list.
+func (pc PolicyConfig) Locks(key string) bool {
+	for _, locked := range pc.Lock {
+		if strings.TrimSpace(locked) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyProfile is layered on top of the policy section for one environment.
+// Lists are added to the base lists; unset switches keep the base value.
+type PolicyProfile struct {
+	Denylist          []string `yaml:"denylist,omitempty" json:"denylist,omitempty"`
+	RedactionKeywords []string `yaml:"redaction_keywords,omitempty" json:"redaction_keywords,omitempty"`
+	EnforceDenylist   *bool    `yaml:"enforce_denylist,omitempty" json:"enforce_denylist,omitempty"`
+	// RecordCWD overrides capture.record_cwd.
+	RecordCWD *bool `yaml:"record_cwd,omitempty" json:"record_cwd,omitempty"`
+	// Protected makes `cmdry run` ask for the environment name before running
+	// a command that matches a guard rule.
+	Protected bool `yaml:"protected,omitempty" json:"protected,omitempty"`
+}
+
+// GuardConfig adds dangerous-command patterns to the built-in guard rules.
+// Patterns use the denylist glob syntax; Rules turns built-in rules on or off
+// by name.
+type GuardConfig struct {
+	Patterns []string        `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+	Rules    map[string]bool `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// Profile returns the profile for an environment label. Labels match
+// case-insensitively; an empty label never matches.
+func (pc PolicyConfig) Profile(env string) (string, PolicyProfile, bool) {
+	env = strings.TrimSpace(env)
+	if env == "" {
+		return "", PolicyProfile{}, false
+	}
+	if profile, ok := pc.Profiles[env]; ok {
+		return env, profile, true
+	}
+	for _, name := range sortedKeys(pc.Profiles) {
+		if strings.EqualFold(name, env) {
+			return name, pc.Profiles[name], true
+		}
+	}
+	return "", PolicyProfile{}, false
+}
+
+// RedactionRule is a user-defined regex redaction. Replacement may reference
+// capture groups ($1, ${name}); when empty, the group named "secret" (or the
+// whole match) becomes [REDACTED]. AppliesTo is command (default), output or
+// cwd.
+type RedactionRule struct {
+	Name        string `yaml:"name" json:"name"`
+	Pattern     string `yaml:"pattern" json:"pattern"`
+	Replacement string `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+	AppliesTo   string `yaml:"applies_to,omitempty" json:"applies_to,omitempty"`
+}
+
+type CaptureConfig struct {
+	// IncludeStdout and IncludeStderr are accepted for compatibility with
+	// generated configs; command output is never stored.
+	IncludeStdout bool `yaml:"include_stdout" json:"include_stdout"`
+	IncludeStderr bool `yaml:"include_stderr" json:"include_stderr"`
+	// RecordCWD controls whether steps keep the working directory.
+	RecordCWD bool `yaml:"record_cwd" json:"record_cwd"`
+}
+
+type ExportConfig struct {
+	// OutputDir is where `cmdry export` writes runbooks; relative paths are
+	// resolved against the working directory.
+	OutputDir string `yaml:"output_dir" json:"output_dir"`
+	// Template is a text/template file for Markdown runbooks, resolved like
+	// OutputDir; empty means the built-in layout.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+}
+
+type HooksConfig struct {
+	// Ignore lists program names that shell hooks never record.
+	Ignore []string `yaml:"ignore" json:"ignore"`
+}
+
+type RetentionConfig struct {
+	// AutoCompact rotates sessions from earlier months into archives when a
+	// session stops.
+	AutoCompact bool `yaml:"auto_compact" json:"auto_compact"`
+}
+
+// PrivacyConfig scrubs personal and internal names from recorded steps:
+// commands, working directories, session titles and metadata.
+type PrivacyConfig struct {
+	// Home rewrites the user's home directory to ~.
+	Home bool `yaml:"home" json:"home"`
+	// Hosts and Users map names to placeholders; an empty placeholder
+	// becomes <host> or <user>. Hostnames match case-insensitively.
+	Hosts map[string]string `yaml:"hosts,omitempty" json:"hosts,omitempty"`
+	Users map[string]string `yaml:"users,omitempty" json:"users,omitempty"`
+	// RelativeCWD makes working directories inside a git repository relative
+	// to its root.
+	RelativeCWD bool `yaml:"relative_cwd" json:"relative_cwd"`
+	// OnRecord scrubs steps as they are recorded; OnExport scrubs runbooks
+	// on every export, as `cmdry export --scrub` does.
+	OnRecord bool `yaml:"on_record" json:"on_record"`
+	OnExport bool `yaml:"on_export" json:"on_export"`
+}
+
+// Warning is a non-fatal problem found while loading a config file.
+type Warning struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	if w.Line > 0 {
+		return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+	}
+	return w.Message
+}
+
+// Default returns the configuration used when config.yaml is missing.
+func Default() Config {
+	return Config{
+		Capture:   CaptureConfig{RecordCWD: true},
+		Export:    ExportConfig{OutputDir: "runbooks"},
+		Retention: RetentionConfig{AutoCompact: true},
+	}
+}
+
+// Overlay returns c with every section that upper sets, other than policy,
+// replaced by upper's. Policy sections are merged key by key as layers
+// instead.
+func (c Config) Overlay(upper Config) Config {
+	if upper.Line("capture") > 0 {
+		c.Capture = upper.Capture
+	}
+	if upper.Line("export") > 0 {
+		c.Export = upper.Export
+	}
+	if upper.Line("hooks") > 0 {
+		c.Hooks = upper.Hooks
+	}
+	if upper.Line("retention") > 0 {
+		c.Retention = upper.Retention
+	}
+	if upper.Line("privacy") > 0 {
+		c.Privacy = upper.Privacy
+	}
+	return c
+}
+
+// Path returns the config file path for a store directory.
+func Path(storeDir string) string {
+	return filepath.Join(storeDir, FileName)
+}
+
+// SystemPolicyPath returns the machine-wide policy file:
+// /etc/commandry/policy.yaml, or %ProgramData%\commandry\policy.yaml on
+// Windows. CMDRY_SYSTEM_POLICY overrides it.
+func SystemPolicyPath() string {
+	if path := strings.TrimSpace(os.Getenv(SystemPolicyEnvVar)); path != "" {
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		base := os.Getenv("ProgramData")
+		if base == "" {
+			base = `C:\ProgramData`
+		}
+		return filepath.Join(base, "commandry", PolicyFileName)
+	}
+	return filepath.Join("/etc", "commandry", PolicyFileName)
+}
+
+// Load reads and parses path. A missing file yields Default with no warnings.
+func Load(path string) (Config, []Warning, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Default(), nil, nil
+		}
+		return Config{}, nil, fmt.Errorf("read config: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes a config document on top of Default. Syntax and type errors
+// carry the offending line number.
+func Parse(data []byte) (Config, []Warning, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	cfg := Default()
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return Config{}, nil, fmt.Errorf("parse config: %w", err)
+	}
+	if root.Kind == 0 || len(root.Content) == 0 {
+		return cfg, nil, nil
+	}
+	doc := root.Content[0]
+	if doc.Kind == yaml.ScalarNode && doc.Tag == "!!null" {
+		return cfg, nil, nil
+	}
+	if doc.Kind != yaml.MappingNode {
+		return Config{}, nil, fmt.Errorf("parse config: line %d: top level must be a mapping of sections", doc.Line)
+	}
+
+	var warnings []Warning
+	lines := make(map[string]int)
+	checkKeys(doc, reflect.TypeOf(cfg), "", lines, &warnings)
+
+	if err := doc.Decode(&cfg); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return Config{}, nil, fmt.Errorf("parse config: %s", strings.Join(typeErr.Errors, "; "))
+		}
+		return Config{}, nil, fmt.Errorf("parse config: %w", err)
+	}
+	cfg.lines = lines
+	warnings = append(warnings, validate(cfg, lines)...)
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Line < warnings[j].Line })
+	return cfg, warnings, nil
+}
+
+// checkKeys walks a mapping node alongside the struct it decodes into and
+// records a warning for every key without a matching yaml tag.
+func checkKeys(node *yaml.Node, t reflect.Type, prefix string, lines map[string]int, warnings *[]Warning) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			path := key.Value
+			if prefix != "" {
+				path = prefix + "." + key.Value
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				*warnings = append(*warnings, Warning{Line: key.Line, Message: fmt.Sprintf("unknown key %q", path)})
+				continue
+			}
+			lines[path] = key.Line
+			checkKeys(value, field.Type, path, lines, warnings)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			lines[prefix+"."+key.Value] = key.Line
+			checkKeys(value, t.Elem(), prefix+"."+key.Value, lines, warnings)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", prefix, i), lines, warnings)
+		}
+	}
+}
+
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func validate(cfg Config, lines map[string]int) []Warning {
+	var warnings []Warning
+	if cfg.Capture.IncludeStdout {
+		warnings = append(warnings, Warning{Line: lines["capture.include_stdout"], Message: "capture.include_stdout is not supported; command output is never stored"})
+	}
+	if cfg.Capture.IncludeStderr {
+		warnings = append(warnings, Warning{Line: lines["capture.include_stderr"], Message: "capture.include_stderr is not supported; command output is never stored"})
+	}
+	if strings.TrimSpace(cfg.Export.OutputDir) == "" {
+		warnings = append(warnings, Warning{Line: lines["export.output_dir"], Message: "export.output_dir is empty; runbooks will be written to the working directory"})
+	}
+	for i, rule := range cfg.Policy.RedactionRules {
+		if strings.EqualFold(strings.TrimSpace(rule.AppliesTo), "output") {
+			path := fmt.Sprintf("policy.redaction_rules[%d].applies_to", i)
+			warnings = append(warnings, Warning{Line: lines[path], Message: path + ": command output is never stored by the CLI, so this rule only affects SDK callers"})
+		}
+	}
+	for i, pattern := range cfg.Policy.Denylist {
+		if strings.TrimSpace(pattern) == "" {
+			warnings = append(warnings, Warning{Line: lines["policy.denylist"], Message: fmt.Sprintf("policy.denylist[%d] is empty and will be ignored", i)})
+		}
+	}
+	for i, pattern := range cfg.Policy.Guard.Patterns {
+		if strings.TrimSpace(pattern) == "" {
+			warnings = append(warnings, Warning{Line: lines["policy.guard.patterns"], Message: fmt.Sprintf("policy.guard.patterns[%d] is empty and will be ignored", i)})
+		}
+	}
+	if p := cfg.Privacy; (p.OnRecord || p.OnExport) && !p.Home && !p.RelativeCWD && len(p.Hosts) == 0 && len(p.Users) == 0 {
+		warnings = append(warnings, Warning{Line: lines["privacy"], Message: "privacy has nothing to scrub; set home, hosts, users or relative_cwd"})
+	}
+	for i, key := range cfg.Policy.Lock {
+		if !isLockable(strings.TrimSpace(key)) {
+			warnings = append(warnings, Warning{Line: lines["policy.lock"], Message: fmt.Sprintf("policy.lock[%d]: %q cannot be locked; use one of %s", i, key, strings.Join(LockableKeys, ", "))})
+		}
+	}
+	// Walk profiles in file order so a duplicate is reported on the later one.
+	names := sortedKeys(cfg.Policy.Profiles)
+	sort.SliceStable(names, func(i, j int) bool {
+		return lines["policy.profiles."+names[i]] < lines["policy.profiles."+names[j]]
+	})
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		path := "policy.profiles." + name
+		switch key := strings.ToLower(strings.TrimSpace(name)); {
+		case key == "":
+			warnings = append(warnings, Warning{Line: lines[path], Message: "policy profile with an empty name never matches a session"})
+		case seen[key] != "":
+			warnings = append(warnings, Warning{Line: lines[path], Message: fmt.Sprintf("policy profile %q duplicates %q; environment labels match case-insensitively", name, seen[key])})
+		default:
+			seen[key] = name
+		}
+	}
+	return warnings
+}
+
+func isLockable(key string) bool {
+	for _, lockable := range LockableKeys {
+		if key == lockable {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
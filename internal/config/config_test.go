@@ -0,0 +1,249 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFullConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, warnings, err := Parse([]byte(strings.Join([]string{
+		"# team config",
+		"policy:",
+		"   denylist: [env, \"docker login\"]   # flow list with comment",
+		"   redaction_keywords:",
+		"   - session_token",
+		"   enforce_denylist: true",
+		"capture:",
+		"  record_cwd: false",
+		"export:",
+		"  output_dir: docs/runbooks",
+		"hooks:",
+		"  ignore: [ls, cd]",
+		"retention:",
+		"  auto_compact: false",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	if got := cfg.Policy.Denylist; len(got) != 2 || got[1] != "docker login" {
+		t.Fatalf("unexpected denylist: %v", got)
+	}
+	if len(cfg.Policy.RedactionKeywords) != 1 || !cfg.Policy.EnforceDenylist {
+		t.Fatalf("unexpected policy: %+v", cfg.Policy)
+	}
+	if cfg.Capture.RecordCWD || cfg.Export.OutputDir != "docs/runbooks" || len(cfg.Hooks.Ignore) != 2 || cfg.Retention.AutoCompact {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestParseKeepsDefaultsForMissingSections(t *testing.T) {
+	t.Parallel()
+
+	for _, content := range []string{"", "# only a comment\n", "\ufeffcapture:\n  include_stdout: false\n"} {
+		cfg, warnings, err := Parse([]byte(content))
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", content, err)
+		}
+		if len(warnings) != 0 {
+			t.Fatalf("Parse(%q) warnings: %v", content, warnings)
+		}
+		if !cfg.Capture.RecordCWD || cfg.Export.OutputDir != "runbooks" || !cfg.Retention.AutoCompact {
+			t.Fatalf("Parse(%q) lost defaults: %+v", content, cfg)
+		}
+	}
+}
+
+func TestParseWarnsAboutUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	_, warnings, err := Parse([]byte(strings.Join([]string{
+		"policy:",
+		"  denylist:",
+		"    - env",
+		"  enforce: true",
+		"capture:",
+		"  include_stdout: true",
+		"telemetry: on",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []string{
+		`line 4: unknown key "policy.enforce"`,
+		"line 6: capture.include_stdout is not supported; command output is never stored",
+		`line 7: unknown key "telemetry"`,
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("warnings = %v, want %v", warnings, want)
+	}
+	for i := range want {
+		if warnings[i].String() != want[i] {
+			t.Fatalf("warning %d = %q, want %q", i, warnings[i], want[i])
+		}
+	}
+}
+
+func TestParseReportsLineNumbers(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"policy:\n  enforce_denylist: maybe\n": "line 2",
+		"policy:\n  denylist: env\n":           "line 2",
+		"- policy\n":                           "line 1",
+		"policy:\n\tdenylist: []\n":            "line 2",
+	}
+	for content, wantLine := range cases {
+		_, _, err := Parse([]byte(content))
+		if err == nil {
+			t.Fatalf("Parse(%q) succeeded, want error", content)
+		}
+		if !strings.Contains(err.Error(), wantLine) {
+			t.Fatalf("Parse(%q) error %q does not mention %s", content, err, wantLine)
+		}
+	}
+}
+
+func TestLoadMissingFileUsesDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg, warnings, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("Load failed: %v %v", err, warnings)
+	}
+	if cfg.Export.OutputDir != "runbooks" {
+		t.Fatalf("expected defaults, got %+v", cfg)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(Path(dir), []byte("export:\n  output_dir: out\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, _, err = Load(Path(dir))
+	if err != nil || cfg.Export.OutputDir != "out" {
+		t.Fatalf("unexpected loaded config: %+v (%v)", cfg, err)
+	}
+}
+
+func TestParseRedactionRules(t *testing.T) {
+	t.Parallel()
+
+	cfg, warnings, err := Parse([]byte(strings.Join([]string{
+		"policy:",
+		"  redaction_rules:",
+		"    - name: acme-live-key",
+		`      pattern: 'acme_live_[A-Za-z0-9]{32}'`,
+		"    - name: home",
+		`      pattern: '^/home/[^/]+'`,
+		`      replacement: "~"`,
+		"      applies_to: cwd",
+		"      flags: i",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	rules := cfg.Policy.RedactionRules
+	if len(rules) != 2 || rules[0].Pattern != `acme_live_[A-Za-z0-9]{32}` || rules[1].AppliesTo != "cwd" || rules[1].Replacement != "~" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if len(warnings) != 1 || warnings[0].String() != `line 9: unknown key "policy.redaction_rules[1].flags"` {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	if got := cfg.Line("policy.redaction_rules[1].pattern"); got != 6 {
+		t.Fatalf("Line(pattern) = %d, want 6", got)
+	}
+}
+
+func TestParsePolicyProfiles(t *testing.T) {
+	t.Parallel()
+
+	cfg, warnings, err := Parse([]byte(strings.Join([]string{
+		"policy:",
+		"  profiles:",
+		"    prod:",
+		"      denylist: [\"terraform destroy*\"]",
+		"      enforce_denylist: true",
+		"      record_cwd: false",
+		"      capture_output: false",
+		"    PROD:",
+		"      redaction_keywords: [db_pass]",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []string{
+		`line 7: unknown key "policy.profiles.prod.capture_output"`,
+		`line 8: policy profile "PROD" duplicates "prod"; environment labels match case-insensitively`,
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("warnings = %v, want %v", warnings, want)
+	}
+	for i := range want {
+		if warnings[i].String() != want[i] {
+			t.Fatalf("warning %d = %q, want %q", i, warnings[i], want[i])
+		}
+	}
+
+	name, profile, ok := cfg.Policy.Profile("prod")
+	if !ok || name != "prod" || profile.EnforceDenylist == nil || !*profile.EnforceDenylist || profile.RecordCWD == nil || *profile.RecordCWD {
+		t.Fatalf("Profile(prod) = %q, %+v, %v", name, profile, ok)
+	}
+	if name, _, ok := cfg.Policy.Profile(" Prod "); !ok || name != "PROD" {
+		t.Fatalf("Profile(Prod) = %q, %v", name, ok)
+	}
+	if _, _, ok := cfg.Policy.Profile(""); ok {
+		t.Fatalf("empty env must not match a profile")
+	}
+}
+
+func TestParseWarnsAboutUnlockableKeys(t *testing.T) {
+	t.Parallel()
+
+	cfg, warnings, err := Parse([]byte("policy:\n  lock: [denylist, capture]\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !cfg.Policy.Locks("denylist") || cfg.Policy.Locks("enforce_denylist") {
+		t.Fatalf("unexpected locks: %v", cfg.Policy.Lock)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0].String(), `line 2: policy.lock[1]: "capture" cannot be locked`) {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+}
+
+func TestParsePrivacy(t *testing.T) {
+	t.Parallel()
+
+	cfg, warnings, err := Parse([]byte(strings.Join([]string{
+		"privacy:",
+		"  home: true",
+		"  hosts:",
+		"    db01.corp.example: db-primary",
+		"  users: {alice: \"\"}",
+		"  on_export: true",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	p := cfg.Privacy
+	if !p.Home || p.Hosts["db01.corp.example"] != "db-primary" || len(p.Users) != 1 || !p.OnExport || p.OnRecord {
+		t.Fatalf("unexpected privacy config: %+v", p)
+	}
+
+	_, warnings, err = Parse([]byte("privacy:\n  on_record: true\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].String() != "line 1: privacy has nothing to scrub; set home, hosts, users or relative_cwd" {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+}
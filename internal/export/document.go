@@ -0,0 +1,154 @@
+package export
+
+import (
+	"time"
+
+	"github.com/fixi2/Commandry/internal/buildinfo"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+// Document is the export model of a session, shared by every format: the
+// Markdown and HTML runbooks render it and the JSON export encodes it.
+type Document struct {
+	Schema        string          `json:"$schema"`
+	SchemaVersion int             `json:"schema_version"`
+	Generator     string          `json:"generator"`
+	Session       DocumentSession `json:"session"`
+	Summary       DocumentSummary `json:"summary"`
+	Steps         []DocumentStep  `json:"steps"`
+	Guidance      Guidance        `json:"guidance"`
+	// Comments are the export comments that apply to all flagged steps.
+	Comments []string `json:"comments"`
+}
+
+type DocumentSession struct {
+	ID                string            `json:"id"`
+	Title             string            `json:"title"`
+	Env               string            `json:"env,omitempty"`
+	Tags              []string          `json:"tags"`
+	Meta              map[string]string `json:"meta"`
+	StartedAt         time.Time         `json:"started_at"`
+	EndedAt           *time.Time        `json:"ended_at,omitempty"`
+	PolicyFingerprint string            `json:"policy_fingerprint,omitempty"`
+}
+
+type DocumentSummary struct {
+	Steps    int `json:"steps"`
+	OK       int `json:"ok"`
+	Failed   int `json:"failed"`
+	Redacted int `json:"redacted"`
+	// Unknown counts legacy steps recorded without a status or exit code.
+	Unknown         int   `json:"unknown"`
+	TotalDurationMS int64 `json:"total_duration_ms"`
+}
+
+// DocumentStep is a step with its status and reason normalized as in the
+// Markdown runbook.
+type DocumentStep struct {
+	Index     int       `json:"index"`
+	Timestamp time.Time `json:"timestamp"`
+	Command   string    `json:"command"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	ExitCode  *int      `json:"exit_code,omitempty"`
+	// DurationMS is 0 for steps without a measured duration.
+	DurationMS int64  `json:"duration_ms"`
+	CWD        string `json:"cwd,omitempty"`
+	// Argv marks a command recorded from an argument list; see store.Step.
+	Argv     bool              `json:"argv,omitempty"`
+	Redacted bool              `json:"redacted"`
+	Guard    *store.GuardCheck `json:"guard,omitempty"`
+	// PolicyFingerprint is the policy in effect for this step, filled in from
+	// earlier steps and the session.
+	PolicyFingerprint string   `json:"policy_fingerprint,omitempty"`
+	Comments          []string `json:"comments"`
+}
+
+type Guidance struct {
+	Preconditions []string `json:"preconditions"`
+	Verification  []string `json:"verification"`
+	Rollback      []string `json:"rollback"`
+}
+
+// BuildDocument assembles the export model of session. Annotations from opts
+// are carried over as step and document comments.
+func BuildDocument(session *store.Session, opts MarkdownOptions) Document {
+	summary := buildStepSummary(session.Steps)
+	_, rollback := detectRollback(session.Steps)
+	doc := Document{
+		Schema:        JSONSchemaID,
+		SchemaVersion: JSONSchemaVersion,
+		Generator:     "Commandry " + buildinfo.String(),
+		Session: DocumentSession{
+			ID:                session.ID,
+			Title:             session.Title,
+			Env:               session.Env,
+			Tags:              append([]string{}, session.Tags...),
+			Meta:              make(map[string]string, len(session.Meta)),
+			StartedAt:         session.StartedAt.UTC(),
+			PolicyFingerprint: session.PolicyFingerprint,
+		},
+		Summary: DocumentSummary{
+			Steps:           len(session.Steps),
+			OK:              summary.ok,
+			Failed:          summary.failed,
+			Redacted:        summary.redacted,
+			TotalDurationMS: summary.totalDurationMS,
+		},
+		Steps: make([]DocumentStep, 0, len(session.Steps)),
+		Guidance: Guidance{
+			Preconditions: detectPreconditions(session.Steps),
+			Verification:  detectVerificationChecks(session.Steps),
+			Rollback:      rollback,
+		},
+		Comments: append([]string{}, opts.GlobalComments...),
+	}
+	for key, value := range session.Meta {
+		doc.Session.Meta[key] = value
+	}
+	if session.EndedAt != nil {
+		ended := session.EndedAt.UTC()
+		doc.Session.EndedAt = &ended
+	}
+
+	for i, step := range session.Steps {
+		status, reason := NormalizeResult(step)
+		if status == "UNKNOWN" {
+			doc.Summary.Unknown++
+		}
+		duration := step.DurationMS
+		if duration < 0 {
+			duration = 0
+		}
+		doc.Steps = append(doc.Steps, DocumentStep{
+			Index:             i + 1,
+			Timestamp:         step.Timestamp.UTC(),
+			Command:           step.Command,
+			Status:            status,
+			Reason:            reason,
+			ExitCode:          step.ExitCode,
+			DurationMS:        duration,
+			CWD:               step.CWD,
+			Argv:              step.Argv,
+			Redacted:          status == "REDACTED" || hasInlineRedaction(step.Command),
+			Guard:             step.Guard,
+			PolicyFingerprint: session.PolicyFingerprintAt(i),
+			Comments:          append([]string{}, opts.StepComments[i]...),
+		})
+	}
+	return doc
+}
+
+// policyChanges returns the steps recorded under a different policy than the
+// step before them.
+func (d Document) policyChanges() []DocumentStep {
+	var changes []DocumentStep
+	previous := d.Session.PolicyFingerprint
+	for _, step := range d.Steps {
+		if step.PolicyFingerprint != previous {
+			changes = append(changes, step)
+			previous = step.PolicyFingerprint
+		}
+	}
+	return changes
+}
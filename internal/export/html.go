@@ -0,0 +1,64 @@
+package export
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+//go:embed templates/runbook.html.tmpl
+var htmlTemplateText string
+
+var htmlTemplate = template.Must(template.New("runbook.html").Funcs(template.FuncMap{
+	"lower":     strings.ToLower,
+	"snippet":   stepTitleSnippet,
+	"markdown":  inlineCodeHTML,
+	"timestamp": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	"deref":     func(p *int) int { return *p },
+}).Parse(htmlTemplateText))
+
+// RenderHTML renders session as a single self-contained HTML page: styles
+// and the copy buttons' script are inlined, and nothing depends on the time
+// of rendering, so the same session always gives the same bytes.
+func RenderHTML(session *store.Session, opts MarkdownOptions) (string, error) {
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, NewTemplateData(session, opts)); err != nil {
+		return "", fmt.Errorf("render html: %w", err)
+	}
+	return b.String(), nil
+}
+
+// WriteHTMLToDir writes the HTML runbook into runbooksDir.
+func WriteHTMLToDir(session *store.Session, runbooksDir string, opts MarkdownOptions) (string, error) {
+	body, err := RenderHTML(session, opts)
+	if err != nil {
+		return "", err
+	}
+	return writeRunbook(runbooksDir, runbookFilename(session, ".html"), []byte(body), 0o644, "html")
+}
+
+// inlineCodeHTML escapes s and turns `code spans`, as used in the guidance
+// text, into <code> elements.
+func inlineCodeHTML(s string) template.HTML {
+	parts := strings.Split(s, "`")
+	if len(parts)%2 == 0 {
+		// Unbalanced backticks are kept as text.
+		return template.HTML(template.HTMLEscapeString(s))
+	}
+	var b strings.Builder
+	for i, part := range parts {
+		if i%2 == 1 {
+			b.WriteString("<code>")
+			b.WriteString(template.HTMLEscapeString(part))
+			b.WriteString("</code>")
+			continue
+		}
+		b.WriteString(template.HTMLEscapeString(part))
+	}
+	return template.HTML(b.String())
+}
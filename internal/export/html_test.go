@@ -0,0 +1,66 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestRenderHTMLGolden(t *testing.T) {
+	t.Parallel()
+
+	session := &store.Session{
+		ID:                "1",
+		Title:             "Deploy <api> to staging",
+		Env:               "staging",
+		Meta:              map[string]string{"ticket": "CHG-1", "owner": "sre"},
+		StartedAt:         time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		PolicyFingerprint: "3f2a91c0be44",
+		Steps: []store.Step{
+			{Timestamp: time.Date(2026, 2, 3, 10, 0, 5, 0, time.UTC), Command: "kubectl rollout restart deployment/api", Status: "OK", ExitCode: intPtr(0), DurationMS: 820, CWD: "/repo"},
+			{Timestamp: time.Date(2026, 2, 3, 10, 0, 9, 0, time.UTC), Command: `echo "<script>alert(1)</script>" && false`, Status: "FAILED", ExitCode: intPtr(1), DurationMS: 5},
+			{Timestamp: time.Date(2026, 2, 3, 10, 0, 12, 0, time.UTC), Command: "[REDACTED BY POLICY]", Status: "REDACTED", PolicyFingerprint: "a07d5e19c2b8"},
+		},
+	}
+	opts := MarkdownOptions{StepComments: map[int][]string{1: {"expected, see CHG-1"}}, GlobalComments: []string{"reviewed"}}
+
+	got, err := RenderHTML(session, opts)
+	if err != nil {
+		t.Fatalf("RenderHTML failed: %v", err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "session.golden.html"))
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if normalizeNewlines(got) != normalizeNewlines(string(want)) {
+		t.Fatalf("html mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+
+	again, err := RenderHTML(session, opts)
+	if err != nil || again != got {
+		t.Fatalf("rendering must be deterministic (%v)", err)
+	}
+	if strings.Contains(got, "<script>alert") {
+		t.Fatal("commands must be escaped")
+	}
+}
+
+func TestInlineCodeHTML(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"`kubectl` is installed.":     "<code>kubectl</code> is installed.",
+		"Use <b> `a&b`":               "Use &lt;b&gt; <code>a&amp;b</code>",
+		"unbalanced ` tick":           "unbalanced ` tick",
+		"Document the rollback plan.": "Document the rollback plan.",
+	}
+	for in, want := range tests {
+		if got := string(inlineCodeHTML(in)); got != want {
+			t.Errorf("inlineCodeHTML(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
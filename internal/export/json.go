@@ -0,0 +1,45 @@
+package export
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+// JSONSchemaVersion is bumped only for changes that break existing readers:
+// removing or renaming a field or changing its type. New optional fields keep
+// the version.
+const JSONSchemaVersion = 1
+
+// JSONSchemaID is the $id of the published schema and the $schema of every
+// exported document.
+const JSONSchemaID = "https://github.com/fixi2/Commandry/schema/runbook.v1.json"
+
+//go:embed schema/runbook.v1.json
+var jsonSchema []byte
+
+// JSONSchema returns the JSON Schema that exported documents conform to.
+func JSONSchema() []byte {
+	return append([]byte(nil), jsonSchema...)
+}
+
+// RenderJSON returns the indented JSON export of session.
+func RenderJSON(session *store.Session, opts MarkdownOptions) ([]byte, error) {
+	data, err := json.MarshalIndent(BuildDocument(session, opts), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode json export: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// WriteJSONToDir writes the JSON export into runbooksDir, next to where the
+// Markdown runbook would go.
+func WriteJSONToDir(session *store.Session, runbooksDir string, opts MarkdownOptions) (string, error) {
+	body, err := RenderJSON(session, opts)
+	if err != nil {
+		return "", err
+	}
+	return writeRunbook(runbooksDir, runbookFilename(session, ".json"), body, 0o644, "json")
+}
@@ -0,0 +1,103 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestBuildDocumentNormalizesSteps(t *testing.T) {
+	t.Parallel()
+
+	ended := time.Date(2026, 2, 3, 10, 5, 0, 0, time.UTC)
+	session := &store.Session{
+		ID:                "1",
+		Title:             "Deploy to staging",
+		Env:               "staging",
+		Meta:              map[string]string{"ticket": "CHG-1"},
+		StartedAt:         time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		EndedAt:           &ended,
+		PolicyFingerprint: "aaaaaaaaaaaa",
+		Steps: []store.Step{
+			{Command: "kubectl rollout restart deployment/api", Status: "OK", ExitCode: intPtr(0), DurationMS: 820},
+			{Command: "curl -H 'Authorization: Bearer [REDACTED]' https://example.com", ExitCode: intPtr(7), DurationMS: 40, PolicyFingerprint: "bbbbbbbbbbbb"},
+			{Command: "legacy"},
+		},
+	}
+
+	doc := BuildDocument(session, MarkdownOptions{StepComments: map[int][]string{1: {"token rotated"}}, GlobalComments: []string{"reviewed"}})
+	if doc.SchemaVersion != JSONSchemaVersion || doc.Schema != JSONSchemaID || doc.Session.Env != "staging" || doc.Session.EndedAt == nil {
+		t.Fatalf("unexpected document header: %+v", doc)
+	}
+	want := DocumentSummary{Steps: 3, OK: 1, Failed: 1, Redacted: 1, Unknown: 1, TotalDurationMS: 860}
+	if doc.Summary != want {
+		t.Fatalf("summary = %+v, want %+v", doc.Summary, want)
+	}
+	second := doc.Steps[1]
+	if second.Index != 2 || second.Status != "FAILED" || second.Reason != "nonzero_exit" || !second.Redacted || len(second.Comments) != 1 {
+		t.Fatalf("unexpected step: %+v", second)
+	}
+	if doc.Steps[0].PolicyFingerprint != "aaaaaaaaaaaa" || doc.Steps[2].PolicyFingerprint != "bbbbbbbbbbbb" {
+		t.Fatalf("steps should carry the policy in effect: %+v", doc.Steps)
+	}
+	if doc.Steps[2].Status != "UNKNOWN" || doc.Steps[0].Comments == nil {
+		t.Fatalf("unexpected step: %+v", doc.Steps[2])
+	}
+	if len(doc.Guidance.Rollback) != 2 || doc.Guidance.Rollback[1] != "`kubectl rollout undo deployment/api`" || len(doc.Comments) != 1 {
+		t.Fatalf("unexpected guidance: %+v", doc.Guidance)
+	}
+}
+
+func TestRenderJSONHasSchemaRequiredFields(t *testing.T) {
+	t.Parallel()
+
+	var schema map[string]any
+	if err := json.Unmarshal(JSONSchema(), &schema); err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+	if schema["$id"] != JSONSchemaID {
+		t.Fatalf("schema $id = %v, want %s", schema["$id"], JSONSchemaID)
+	}
+
+	// An empty session leaves out every optional field.
+	data, err := RenderJSON(&store.Session{ID: "1", Title: "Empty"}, MarkdownOptions{})
+	if err != nil {
+		t.Fatalf("RenderJSON failed: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	checkRequired(t, "document", schema, doc)
+	properties := schema["properties"].(map[string]any)
+	for _, name := range []string{"session", "summary", "guidance"} {
+		checkRequired(t, name, properties[name].(map[string]any), doc[name].(map[string]any))
+	}
+
+	data, err = RenderJSON(&store.Session{ID: "1", Title: "One", Steps: []store.Step{{Command: "make"}}}, MarkdownOptions{})
+	if err != nil {
+		t.Fatalf("RenderJSON failed: %v", err)
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	stepSchema := schema["$defs"].(map[string]any)["step"].(map[string]any)
+	checkRequired(t, "step", stepSchema, doc["steps"].([]any)[0].(map[string]any))
+}
+
+func checkRequired(t *testing.T, name string, schema, value map[string]any) {
+	t.Helper()
+	properties := schema["properties"].(map[string]any)
+	for _, key := range schema["required"].([]any) {
+		if _, ok := value[key.(string)]; !ok {
+			t.Errorf("%s: required field %q missing", name, key)
+		}
+	}
+	for key := range value {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("%s: field %q is not in the schema", name, key)
+		}
+	}
+}
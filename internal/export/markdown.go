@@ -5,10 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 	"unicode/utf8"
 
-	"github.com/fixi2/Commandry/internal/buildinfo"
 	"github.com/fixi2/Commandry/internal/store"
 )
 
@@ -37,129 +38,84 @@ func WriteMarkdown(session *store.Session, workingDir string) (string, error) {
 type MarkdownOptions struct {
 	StepComments   map[int][]string
 	GlobalComments []string
+	// Template replaces the built-in Markdown template; see RenderTemplate.
+	Template *template.Template
 }
 
 func WriteMarkdownWithOptions(session *store.Session, workingDir string, opts MarkdownOptions) (string, error) {
-	runbooksDir := filepath.Join(workingDir, "runbooks")
+	return WriteMarkdownToDir(session, filepath.Join(workingDir, "runbooks"), opts)
+}
+
+// WriteMarkdownToDir writes the runbook into runbooksDir, creating it if needed.
+func WriteMarkdownToDir(session *store.Session, runbooksDir string, opts MarkdownOptions) (string, error) {
+	body, err := RenderTemplate(session, opts)
+	if err != nil {
+		return "", err
+	}
+	return writeRunbook(runbooksDir, RunbookFilename(session), []byte(body), 0o644, "markdown")
+}
+
+// writeRunbook writes one export format of a runbook into runbooksDir,
+// creating it if needed.
+func writeRunbook(runbooksDir, filename string, body []byte, perm os.FileMode, format string) (string, error) {
 	if err := os.MkdirAll(runbooksDir, 0o755); err != nil {
 		return "", fmt.Errorf("create runbooks directory: %w", err)
 	}
 
-	filename := RunbookFilename(session)
 	outputPath := filepath.Join(runbooksDir, filename)
-
-	body := RenderMarkdownWithOptions(session, opts)
-	if err := os.WriteFile(outputPath, []byte(body), 0o644); err != nil {
-		return "", fmt.Errorf("write markdown file: %w", err)
+	if err := os.WriteFile(outputPath, body, perm); err != nil {
+		return "", fmt.Errorf("write %s file: %w", format, err)
 	}
 
 	return outputPath, nil
 }
 
 func RunbookFilename(session *store.Session) string {
+	return runbookFilename(session, ".md")
+}
+
+func runbookFilename(session *store.Session, ext string) string {
 	ts := session.StartedAt.UTC().Format("20060102-150405")
 	slug := slugify(session.Title)
-	return fmt.Sprintf("%s-%s.md", ts, slug)
+	return fmt.Sprintf("%s-%s%s", ts, slug, ext)
 }
 
 func RenderMarkdown(session *store.Session) string {
 	return RenderMarkdownWithOptions(session, MarkdownOptions{})
 }
 
+// RenderMarkdownWithOptions renders the built-in Markdown template; a custom
+// opts.Template is ignored, use RenderTemplate for that.
 func RenderMarkdownWithOptions(session *store.Session, opts MarkdownOptions) string {
 	var b strings.Builder
+	// The built-in template is parsed at init and golden-tested against every
+	// model field it uses, so executing it into a buffer does not fail.
+	_ = markdownTemplate.Execute(&b, NewTemplateData(session, opts))
+	return b.String()
+}
 
-	summary := buildStepSummary(session.Steps)
-
-	b.WriteString("# ")
-	b.WriteString(session.Title)
-	b.WriteString("\n\n")
-
-	b.WriteString("## Summary\n")
-	b.WriteString("This runbook was generated from an explicit Commandry session.\n")
-	b.WriteString(fmt.Sprintf("Recorded %d step(s).\n", len(session.Steps)))
-	b.WriteString(fmt.Sprintf("Results: OK %d | FAILED %d | REDACTED %d\n", summary.ok, summary.failed, summary.redacted))
-	b.WriteString(fmt.Sprintf("Total duration: %d ms\n\n", summary.totalDurationMS))
-
-	b.WriteString("## Before You Run\n")
-	for _, precondition := range detectPreconditions(session.Steps) {
-		b.WriteString("- [ ] ")
-		b.WriteString(precondition)
-		b.WriteString("\n")
-	}
-	b.WriteString("\n")
-
-	b.WriteString("## Steps\n")
-	if len(session.Steps) == 0 {
-		b.WriteString("1. TODO: No recorded steps.\n\n")
-		b.WriteString("```sh\n")
-		b.WriteString("# TODO: add command\n")
-		b.WriteString("```\n\n")
-	} else {
-		for i, step := range session.Steps {
-			status, reason := normalizeResult(step)
-			b.WriteString(fmt.Sprintf("%d. [%s] %s\n\n", i+1, status, stepTitleSnippet(step.Command)))
-			b.WriteString("```sh\n")
-			b.WriteString(step.Command)
-			b.WriteString("\n```\n")
-			b.WriteString(fmt.Sprintf("Result: %s", status))
-			if reason != "" {
-				b.WriteString(fmt.Sprintf(" (%s)", reason))
-			}
-			b.WriteString("\n")
-			if step.ExitCode != nil {
-				b.WriteString(fmt.Sprintf("Exit code: %d\n", *step.ExitCode))
-			}
-			b.WriteString(fmt.Sprintf("Duration: %d ms\n\n", step.DurationMS))
-			if comments := opts.StepComments[i]; len(comments) > 0 {
-				if len(comments) == 1 {
-					b.WriteString("Reviewer note:\n")
-				} else {
-					b.WriteString("Reviewer notes:\n")
-				}
-				for _, comment := range comments {
-					b.WriteString("- ")
-					b.WriteString(comment)
-					b.WriteString("\n")
-				}
-				b.WriteString("\n")
-			}
-		}
+func metadataRows(session DocumentSession) []MetadataRow {
+	rows := make([]MetadataRow, 0, len(session.Meta)+2)
+	if session.Env != "" {
+		rows = append(rows, MetadataRow{Key: "env", Value: session.Env})
 	}
-
-	b.WriteString("## Verification\n")
-	for _, check := range detectVerificationChecks(session.Steps) {
-		b.WriteString("- [ ] ")
-		b.WriteString(check)
-		b.WriteString("\n")
+	if len(session.Tags) > 0 {
+		rows = append(rows, MetadataRow{Key: "tags", Value: strings.Join(session.Tags, ", ")})
 	}
-	b.WriteString("\n")
-
-	rollbackSectionTitle, rollbackItems := detectRollback(session.Steps)
-	b.WriteString("## ")
-	b.WriteString(rollbackSectionTitle)
-	b.WriteString("\n")
-	for _, item := range rollbackItems {
-		b.WriteString("- ")
-		b.WriteString(item)
-		b.WriteString("\n")
+	keys := make([]string, 0, len(session.Meta))
+	for key := range session.Meta {
+		keys = append(keys, key)
 	}
-	b.WriteString("\n")
-
-	if len(opts.GlobalComments) > 0 {
-		b.WriteString("## Export Comments\n")
-		for _, comment := range opts.GlobalComments {
-			b.WriteString("- Applies to all flagged steps: ")
-			b.WriteString(comment)
-			b.WriteString("\n")
-		}
-		b.WriteString("\n")
+	sort.Strings(keys)
+	for _, key := range keys {
+		rows = append(rows, MetadataRow{Key: key, Value: session.Meta[key]})
 	}
+	return rows
+}
 
-	b.WriteString("## Notes\n")
-	b.WriteString(fmt.Sprintf("- Generated by Commandry %s.\n", buildinfo.String()))
-
-	return b.String()
+func escapeTableCell(v string) string {
+	v = strings.ReplaceAll(v, "\n", " ")
+	return strings.ReplaceAll(v, "|", `\|`)
 }
 
 type stepSummary struct {
@@ -172,7 +128,7 @@ type stepSummary struct {
 func buildStepSummary(steps []store.Step) stepSummary {
 	s := stepSummary{}
 	for _, step := range steps {
-		status, _ := normalizeResult(step)
+		status, _ := NormalizeResult(step)
 		switch status {
 		case "OK":
 			s.ok++
@@ -211,7 +167,9 @@ func stepTitleSnippet(command string) string {
 	return string(rs[:maxRunes-3]) + "..."
 }
 
-func normalizeResult(step store.Step) (string, string) {
+// NormalizeResult returns the display status and reason for a step, filling in
+// values for legacy records that predate explicit statuses.
+func NormalizeResult(step store.Step) (string, string) {
 	status := step.Status
 	reason := step.Reason
 
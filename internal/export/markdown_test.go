@@ -58,6 +58,40 @@ func normalizeNewlines(s string) string {
 	return strings.ReplaceAll(s, "\r\n", "\n")
 }
 
+func TestRenderMarkdownMetadataTable(t *testing.T) {
+	t.Parallel()
+
+	session := &store.Session{
+		Title: "Rotate certs",
+		Env:   "prod",
+		Tags:  []string{"incident", "tls"},
+		Meta:  map[string]string{"ticket": "CHG-42", "owner": "team|sre"},
+	}
+
+	got := RenderMarkdown(session)
+	want := strings.Join([]string{
+		"# Rotate certs",
+		"",
+		"## Metadata",
+		"| Key | Value |",
+		"| --- | --- |",
+		"| env | prod |",
+		"| tags | incident, tls |",
+		`| owner | team\|sre |`,
+		"| ticket | CHG-42 |",
+		"",
+		"## Summary",
+	}, "\n")
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("unexpected metadata section:\n%s", got)
+	}
+
+	plain := RenderMarkdown(&store.Session{Title: "Plain", Env: "prod"})
+	if strings.Contains(plain, "## Metadata") {
+		t.Fatalf("did not expect metadata section without tags or meta")
+	}
+}
+
 func TestRunbookFilename(t *testing.T) {
 	t.Parallel()
 
@@ -268,3 +302,27 @@ func TestRenderMarkdownSummaryCountsInlineRedaction(t *testing.T) {
 		t.Fatalf("summary must count inline redaction: %s", got)
 	}
 }
+
+func TestRenderMarkdownNotesPolicyFingerprints(t *testing.T) {
+	t.Parallel()
+
+	session := &store.Session{
+		ID:                "1",
+		Title:             "Policy change",
+		StartedAt:         time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC),
+		PolicyFingerprint: "3f2a91c0be44",
+		Steps: []store.Step{
+			{Command: "make build", Status: "OK", ExitCode: intPtr(0)},
+			{Command: "make deploy", Status: "OK", ExitCode: intPtr(0), PolicyFingerprint: "a07d5e19c2b8"},
+		},
+	}
+
+	got := RenderMarkdown(session)
+	notes := got[strings.Index(got, "## Notes"):]
+	if !strings.Contains(notes, "- Recorded under policy `3f2a91c0be44`.\n") || !strings.Contains(notes, "- Policy changed to `a07d5e19c2b8` at step 2.\n") {
+		t.Fatalf("notes must list the policy fingerprints: %s", notes)
+	}
+	if plain := RenderMarkdown(&store.Session{Title: "Legacy"}); strings.Contains(plain, "policy `") {
+		t.Fatalf("sessions without a fingerprint must not mention one: %s", plain)
+	}
+}
@@ -0,0 +1,198 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/shellwords"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+// cmdletName matches Verb-Noun cmdlet and function names.
+var cmdletName = regexp.MustCompile(`^[a-z]+-[a-z0-9]+$`)
+
+// powerShellBuiltins are default PowerShell aliases and functions; unlike
+// native programs they do not set $LASTEXITCODE.
+var powerShellBuiltins = map[string]bool{
+	"cd": true, "chdir": true, "sl": true, "pushd": true, "popd": true, "pwd": true,
+	"ls": true, "dir": true, "gci": true, "cat": true, "type": true, "gc": true,
+	"cp": true, "copy": true, "mv": true, "move": true, "rm": true, "del": true, "erase": true,
+	"ren": true, "rename": true, "mkdir": true, "md": true, "rmdir": true, "rd": true,
+	"echo": true, "write": true, "cls": true, "clear": true, "set": true, "sleep": true,
+	"select": true, "where": true, "foreach": true, "sort": true, "tee": true,
+}
+
+// RenderPowerShell turns session back into a PowerShell script. It follows
+// RenderShell: failed steps are commented out and redacted or scrubbed steps
+// throw until they are filled in. Native commands are followed by a
+// $LASTEXITCODE check, since $ErrorActionPreference does not cover them.
+func RenderPowerShell(session *store.Session, opts MarkdownOptions) string {
+	doc := BuildDocument(session, opts)
+	var b strings.Builder
+
+	writeComment(&b, "", doc.Session.Title)
+	writeComment(&b, "", fmt.Sprintf("Generated by %s from session %s.", doc.Generator, doc.Session.ID))
+	for _, row := range metadataRows(doc.Session) {
+		writeComment(&b, "", row.Key+": "+row.Value)
+	}
+	for _, comment := range doc.Comments {
+		writeComment(&b, "", "Export comment: "+comment)
+	}
+	writeComment(&b, "", "Review every step before running this script.")
+	b.WriteString("$ErrorActionPreference = 'Stop'\n")
+	if hasRelativeCWD(doc.Steps) {
+		b.WriteString("\n$repoRoot = if ($env:CMDRY_REPO_ROOT) { $env:CMDRY_REPO_ROOT } else { (Get-Location).Path }\n")
+	}
+
+	if len(doc.Steps) == 0 {
+		b.WriteString("\n# TODO: No recorded steps.\n")
+		return b.String()
+	}
+
+	cwd := ""
+	for _, step := range doc.Steps {
+		b.WriteString("\n")
+		b.WriteString(scriptStepHeader(step))
+		for _, comment := range step.Comments {
+			writeComment(&b, "", "Reviewer note: "+comment)
+		}
+
+		switch note, message := scriptStepTODO(step); {
+		case step.Status == "FAILED" || step.Status == "UNKNOWN":
+			writeComment(&b, "$ ", step.Command)
+		case note != "":
+			writeComment(&b, "$ ", step.Command)
+			writeComment(&b, "", note)
+			fmt.Fprintf(&b, "throw %s\n", psQuote(message))
+		default:
+			if step.CWD != "" && step.CWD != cwd {
+				b.WriteString("Set-Location -LiteralPath " + psPath(step.CWD) + "\n")
+				cwd = step.CWD
+			}
+			b.WriteString(psCommand(step.Command, step.Argv))
+			b.WriteString("\n")
+			if isNativeCommand(step.Command) {
+				fmt.Fprintf(&b, "if ($LASTEXITCODE -ne 0) { throw \"Step %d failed with exit code $LASTEXITCODE\" }\n", step.Index)
+			}
+		}
+	}
+	return b.String()
+}
+
+// WritePowerShellToDir writes the script into runbooksDir.
+func WritePowerShellToDir(session *store.Session, runbooksDir string, opts MarkdownOptions) (string, error) {
+	body := RenderPowerShell(session, opts)
+	return writeRunbook(runbooksDir, runbookFilename(session, ".ps1"), []byte(body), 0o644, "powershell script")
+}
+
+// psCommand re-quotes a command made only of literal words for PowerShell.
+// `cmdry run` records arguments in double quotes with backslash escapes,
+// which PowerShell reads differently. Unless argv marks a command recorded
+// from an argument list, lines with variables, expressions, single quotes,
+// pipelines or several commands are written as recorded.
+func psCommand(command string, argv bool) string {
+	if !argv && strings.ContainsAny(command, "$@`'(){}[];|&<>#,\n") {
+		return command
+	}
+	args, ok := splitRecorded(command)
+	if !ok || len(args) == 0 {
+		return command
+	}
+	parts := make([]string, 0, len(args)+1)
+	if program := psQuote(args[0]); program != args[0] {
+		// A quoted program name is a string to PowerShell, not a command.
+		parts = append(parts, "&", program)
+	} else {
+		parts = append(parts, program)
+	}
+	for _, arg := range args[1:] {
+		parts = append(parts, psQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitRecorded reverses util.JoinCommand: words are separated by blanks, and
+// double-quoted words unescape \\ and \". Backslashes elsewhere are literal,
+// as in Windows paths.
+func splitRecorded(command string) ([]string, bool) {
+	var (
+		args   []string
+		word   strings.Builder
+		inWord bool
+		quoted bool
+	)
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(command) && (command[i+1] == '\\' || command[i+1] == '"'):
+			i++
+			word.WriteByte(command[i])
+		case c == '"':
+			quoted = !quoted
+			inWord = true
+		case !quoted && (c == ' ' || c == '\t'):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, false
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, true
+}
+
+// isNativeCommand reports whether the first command of a line runs an
+// external program rather than a cmdlet, alias or function.
+func isNativeCommand(command string) bool {
+	commands := shellwords.Commands(command, shellwords.PowerShell)
+	if len(commands) == 0 || len(commands[0]) == 0 {
+		return false
+	}
+	name := shellwords.Program(commands[0][0])
+	if strings.HasSuffix(strings.ToLower(commands[0][0]), ".ps1") {
+		return false
+	}
+	return !cmdletName.MatchString(name) && !powerShellBuiltins[name]
+}
+
+// psQuote quotes s as a PowerShell verbatim string when it is not a plain
+// word. Embedded single quotes are doubled.
+func psQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return !isPowerShellSafe(r) }) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func isPowerShellSafe(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune(`-_./:\=+%`, r)
+}
+
+// psPath quotes a recorded working directory, anchoring "~" and
+// repository-relative paths as shellPath does.
+func psPath(dir string) string {
+	switch {
+	case dir == "~":
+		return "$HOME"
+	case strings.HasPrefix(dir, "~/"):
+		return "(Join-Path $HOME " + psQuote(dir[2:]) + ")"
+	case dir == ".":
+		return "$repoRoot"
+	case isRelativeCWD(dir):
+		return "(Join-Path $repoRoot " + psQuote(dir) + ")"
+	}
+	return psQuote(dir)
+}
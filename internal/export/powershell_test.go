@@ -0,0 +1,98 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestRenderPowerShellGolden(t *testing.T) {
+	t.Parallel()
+
+	session := &store.Session{
+		ID:        "1",
+		Title:     "Deploy to staging",
+		Env:       "staging",
+		StartedAt: time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		Steps: []store.Step{
+			{Command: "kubectl apply -f deploy.yaml", Status: "OK", ExitCode: intPtr(0), CWD: `C:\Users\ops\it's here`},
+			{Command: "Get-ChildItem -Path $env:TEMP | Select-Object -First 1", Status: "OK", ExitCode: intPtr(0), CWD: `C:\Users\ops\it's here`},
+			{Command: "kubectl rollout status deployment/api", Status: "FAILED", ExitCode: intPtr(1)},
+			{Command: `"C:\\Program Files\\Tool\\tool.exe" --name "say \"hi\""`, Status: "OK", ExitCode: intPtr(0), CWD: "deploy"},
+			{Command: "[REDACTED BY POLICY]", Status: "REDACTED"},
+		},
+	}
+
+	got := RenderPowerShell(session, MarkdownOptions{StepComments: map[int][]string{2: {"flaky, rerun"}}})
+	want, err := os.ReadFile(filepath.Join("testdata", "session.golden.ps1"))
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if normalizeNewlines(got) != normalizeNewlines(string(want)) {
+		t.Fatalf("script mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestPSCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"kubectl get pods":              "kubectl get pods",
+		`git commit -m "fix the thing"`: "git commit -m 'fix the thing'",
+		`echo "it\"s"`:                  `echo 'it"s'`,
+		`C:\tools\x.exe --path C:\data`: `C:\tools\x.exe --path C:\data`,
+		`"C:\\Program Files\\x.exe" ""`: `& 'C:\Program Files\x.exe' ''`,
+		"Write-Host \"$env:USER\"":      "Write-Host \"$env:USER\"",
+		"git commit -m 'it''s'":         "git commit -m 'it''s'",
+		`bad "unterminated`:             `bad "unterminated`,
+	}
+	for in, want := range tests {
+		if got := psCommand(in, false); got != want {
+			t.Errorf("psCommand(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	// cmdry run -- echo 'a|wc' '$0 ok' "it's"
+	if got, want := psCommand(`echo a|wc "$0 ok" it's`, true), `echo 'a|wc' '$0 ok' 'it''s'`; got != want {
+		t.Errorf("psCommand of recorded arguments = %q, want %q", got, want)
+	}
+}
+
+func TestRenderPowerShellThrowsAtScrubbedSteps(t *testing.T) {
+	t.Parallel()
+
+	session := &store.Session{
+		ID:        "1",
+		Title:     "Check uptime",
+		StartedAt: time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		Steps:     []store.Step{{Command: "ssh <user>@<host> uptime", Status: "OK"}},
+	}
+	got := RenderPowerShell(session, MarkdownOptions{})
+	want := "# Step 1: OK, scrubbed names\n" +
+		"# $ ssh <user>@<host> uptime\n" +
+		"# TODO: replace the placeholders above with the real names.\n" +
+		"throw 'TODO: step 1 has placeholders for scrubbed names; fill them in before running this script.'\n"
+	if !strings.HasSuffix(got, want) {
+		t.Fatalf("scrubbed step should throw:\n%s", got)
+	}
+}
+
+func TestIsNativeCommand(t *testing.T) {
+	t.Parallel()
+
+	for command, want := range map[string]bool{
+		"kubectl get pods":           true,
+		`& 'C:\tools\x.exe' run`:     true,
+		"Get-Service | Stop-Service": false,
+		"cd C:\\src":                 false,
+		".\\deploy.ps1 -Env prod":    false,
+	} {
+		if got := isNativeCommand(command); got != want {
+			t.Errorf("isNativeCommand(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/privacy"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+// RenderShell turns session back into a bash script. Successful steps run as
+// recorded, with the arguments of `cmdry run` steps quoted again; failed
+// steps are kept as comments, and redacted or scrubbed steps stop the script
+// until someone fills them in.
+func RenderShell(session *store.Session, opts MarkdownOptions) string {
+	doc := BuildDocument(session, opts)
+	var b strings.Builder
+
+	b.WriteString("#!/usr/bin/env bash\n")
+	writeComment(&b, "", doc.Session.Title)
+	writeComment(&b, "", fmt.Sprintf("Generated by %s from session %s.", doc.Generator, doc.Session.ID))
+	for _, row := range metadataRows(doc.Session) {
+		writeComment(&b, "", row.Key+": "+row.Value)
+	}
+	for _, comment := range doc.Comments {
+		writeComment(&b, "", "Export comment: "+comment)
+	}
+	writeComment(&b, "", "Review every step before running this script.")
+	b.WriteString("set -euo pipefail\n")
+	if hasRelativeCWD(doc.Steps) {
+		// Working directories were recorded relative to the repository root.
+		b.WriteString("\nrepo_root=\"${CMDRY_REPO_ROOT:-$PWD}\"\n")
+	}
+
+	if len(doc.Steps) == 0 {
+		b.WriteString("\n# TODO: No recorded steps.\n")
+		return b.String()
+	}
+
+	cwd := ""
+	for _, step := range doc.Steps {
+		b.WriteString("\n")
+		b.WriteString(scriptStepHeader(step))
+		for _, comment := range step.Comments {
+			writeComment(&b, "", "Reviewer note: "+comment)
+		}
+
+		switch note, message := scriptStepTODO(step); {
+		case step.Status == "FAILED" || step.Status == "UNKNOWN":
+			writeComment(&b, "$ ", step.Command)
+		case note != "":
+			writeComment(&b, "$ ", step.Command)
+			writeComment(&b, "", note)
+			fmt.Fprintf(&b, "echo %s >&2\nexit 1\n", shellQuote(message))
+		default:
+			if step.CWD != "" && step.CWD != cwd {
+				b.WriteString("cd " + shellPath(step.CWD) + "\n")
+				cwd = step.CWD
+			}
+			b.WriteString(shellCommand(step))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// shellCommand returns the command line of step. A step recorded from an
+// argument list is split back into its arguments and each is quoted, so
+// that characters such as | or $ inside an argument stay literal.
+func shellCommand(step DocumentStep) string {
+	if !step.Argv {
+		return step.Command
+	}
+	args, ok := splitRecorded(step.Command)
+	if !ok || len(args) == 0 {
+		return step.Command
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// WriteShellToDir writes the script into runbooksDir as an executable file.
+func WriteShellToDir(session *store.Session, runbooksDir string, opts MarkdownOptions) (string, error) {
+	body := RenderShell(session, opts)
+	return writeRunbook(runbooksDir, runbookFilename(session, ".sh"), []byte(body), 0o755, "shell script")
+}
+
+// scriptStepHeader is the comment that opens a step in the shell and
+// PowerShell scripts.
+func scriptStepHeader(step DocumentStep) string {
+	detail := step.Status
+	var extra []string
+	if step.Reason != "" {
+		extra = append(extra, step.Reason)
+	}
+	if step.ExitCode != nil && *step.ExitCode != 0 {
+		extra = append(extra, fmt.Sprintf("exit %d", *step.ExitCode))
+	}
+	if len(extra) > 0 {
+		detail += " (" + strings.Join(extra, ", ") + ")"
+	}
+	switch {
+	case step.Status == "FAILED" || step.Status == "UNKNOWN":
+		detail += ", commented out"
+	case step.Status != "REDACTED" && step.Redacted:
+		detail += ", redacted values"
+	case privacy.HasPlaceholder(step.Command):
+		detail += ", scrubbed names"
+	}
+	return fmt.Sprintf("# Step %d: %s\n", step.Index, detail)
+}
+
+// scriptStepTODO returns the note and the error message that stop a script
+// at a step which cannot run as recorded: a redacted one, or one whose names
+// were scrubbed to placeholders. Both are empty for other steps.
+func scriptStepTODO(step DocumentStep) (note, message string) {
+	switch {
+	case step.Redacted:
+		return "TODO: replace the redacted command or values above.",
+			fmt.Sprintf("TODO: step %d was redacted when it was recorded; fill it in before running this script.", step.Index)
+	case privacy.HasPlaceholder(step.Command):
+		return "TODO: replace the placeholders above with the real names.",
+			fmt.Sprintf("TODO: step %d has placeholders for scrubbed names; fill them in before running this script.", step.Index)
+	}
+	return "", ""
+}
+
+// writeComment writes text as shell comment lines. prefix is added after "# ";
+// commented-out commands use "$ " to stand apart from notes.
+func writeComment(b *strings.Builder, prefix, text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		b.WriteString(strings.TrimRight("# "+prefix+strings.TrimRight(line, "\r"), " "))
+		b.WriteString("\n")
+	}
+}
+
+// shellQuote quotes s for POSIX shells: the whole string in single quotes,
+// each embedded single quote closed, escaped and reopened.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return !isShellSafe(r) }) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func isShellSafe(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@%+=,", r)
+}
+
+// shellPath quotes a recorded working directory. "~" and paths made relative
+// to the repository by the privacy settings are anchored to $HOME and
+// $repo_root.
+func shellPath(dir string) string {
+	switch {
+	case dir == "~":
+		return `"$HOME"`
+	case strings.HasPrefix(dir, "~/"):
+		return `"$HOME"/` + shellQuote(dir[2:])
+	case dir == ".":
+		return `"$repo_root"`
+	case isRelativeCWD(dir):
+		return `"$repo_root"/` + shellQuote(dir)
+	}
+	return shellQuote(dir)
+}
+
+func isRelativeCWD(dir string) bool {
+	return dir != "" && !strings.HasPrefix(dir, "/") && !strings.HasPrefix(dir, "~") && !strings.HasPrefix(dir, `\`) && !(len(dir) >= 2 && dir[1] == ':')
+}
+
+func hasRelativeCWD(steps []DocumentStep) bool {
+	for _, step := range steps {
+		if isRelativeCWD(step.CWD) {
+			return true
+		}
+	}
+	return false
+}
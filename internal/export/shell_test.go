@@ -0,0 +1,141 @@
+package export
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func shellTestSession() *store.Session {
+	return &store.Session{
+		ID:        "1",
+		Title:     "Deploy to staging",
+		Env:       "staging",
+		StartedAt: time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		Steps: []store.Step{
+			{Command: "kubectl apply -f deploy.yaml", Status: "OK", ExitCode: intPtr(0), CWD: "/srv/it's here"},
+			{Command: "kubectl rollout status deployment/api", Status: "FAILED", ExitCode: intPtr(1), CWD: "/srv/it's here"},
+			{Command: "curl -H 'Authorization: Bearer [REDACTED]' https://example.com", Status: "OK", ExitCode: intPtr(0)},
+			{Command: "[REDACTED BY POLICY]", Status: "REDACTED"},
+			{Command: "make test", Status: "OK", ExitCode: intPtr(0), CWD: "deploy/k8s"},
+		},
+	}
+}
+
+func TestRenderShellGolden(t *testing.T) {
+	t.Parallel()
+
+	got := RenderShell(shellTestSession(), MarkdownOptions{StepComments: map[int][]string{1: {"flaky, rerun"}}})
+	want, err := os.ReadFile(filepath.Join("testdata", "session.golden.sh"))
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if normalizeNewlines(got) != normalizeNewlines(string(want)) {
+		t.Fatalf("script mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	if out, err := exec.Command(bash, "-n", "-c", got).CombinedOutput(); err != nil {
+		t.Fatalf("bash -n: %v\n%s", err, out)
+	}
+}
+
+func TestRenderShellQuotesRecordedArguments(t *testing.T) {
+	t.Parallel()
+
+	session := &store.Session{
+		ID:        "1",
+		Title:     "Literal arguments",
+		StartedAt: time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		Steps: []store.Step{
+			// cmdry run -- echo 'a|wc' 'b;c' '$0 ok' '*' "it's"
+			{Command: `echo a|wc b;c "$0 ok" * it's`, Status: "OK", Argv: true},
+			// cmdry run -- sh -c 'echo $0 ok'
+			{Command: `sh -c "echo $0 ok"`, Status: "OK", Argv: true},
+			{Command: "ls *.go | wc -l", Status: "OK"},
+		},
+	}
+	got := RenderShell(session, MarkdownOptions{})
+	for _, want := range []string{
+		"\necho 'a|wc' 'b;c' '$0 ok' '*' 'it'\\''s'\n",
+		"\nsh -c 'echo $0 ok'\n",
+		"\nls *.go | wc -l\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("script missing %q:\n%s", want, got)
+		}
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	out, err := exec.Command(bash, "-c", got).CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %v\n%s", err, out)
+	}
+	if want := "a|wc b;c $0 ok * it's\nsh ok\n"; !strings.Contains(string(out), want) {
+		t.Fatalf("arguments were not kept literal: %q", out)
+	}
+}
+
+func TestRenderShellStopsAtScrubbedSteps(t *testing.T) {
+	t.Parallel()
+
+	session := &store.Session{
+		ID:        "1",
+		Title:     "Check uptime",
+		StartedAt: time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		Steps: []store.Step{
+			{Command: "ssh <user>@<host> uptime", Status: "OK"},
+			{Command: "ssh deployer@db-primary uptime", Status: "OK"},
+		},
+	}
+	got := RenderShell(session, MarkdownOptions{})
+	want := "# Step 1: OK, scrubbed names\n" +
+		"# $ ssh <user>@<host> uptime\n" +
+		"# TODO: replace the placeholders above with the real names.\n" +
+		"echo 'TODO: step 1 has placeholders for scrubbed names; fill them in before running this script.' >&2\n" +
+		"exit 1\n"
+	if !strings.Contains(got, want) {
+		t.Fatalf("scrubbed step should stop the script:\n%s", got)
+	}
+	if !strings.Contains(got, "# Step 2: OK\nssh deployer@db-primary uptime\n") {
+		t.Fatalf("renamed hosts should run as recorded:\n%s", got)
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	if out, err := exec.Command(bash, "-n", "-c", got).CombinedOutput(); err != nil {
+		t.Fatalf("bash -n: %v\n%s", err, out)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":               "''",
+		"/srv/app":       "/srv/app",
+		"my dir":         "'my dir'",
+		"it's":           `'it'\''s'`,
+		"$HOME/`x`":      "'$HOME/`x`'",
+		"a\nb":           "'a\nb'",
+		"user@host:22/x": "user@host:22/x",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+//go:embed templates/runbook.md.tmpl
+var markdownTemplateText string
+
+var markdownTemplate = template.Must(ParseTemplate("runbook.md", markdownTemplateText))
+
+// TemplateData is what runbook templates receive: the export model plus
+// values derived from it that templates would otherwise have to compute.
+type TemplateData struct {
+	Document
+	// Metadata lists env, tags and meta keys in the order of the Markdown
+	// metadata table.
+	Metadata []MetadataRow
+	// PolicyChanges are the steps recorded under a different policy than the
+	// step before them.
+	PolicyChanges []DocumentStep
+}
+
+type MetadataRow struct {
+	Key   string
+	Value string
+}
+
+// NewTemplateData builds the template view model of session.
+func NewTemplateData(session *store.Session, opts MarkdownOptions) TemplateData {
+	doc := BuildDocument(session, opts)
+	return TemplateData{
+		Document:      doc,
+		Metadata:      metadataRows(doc.Session),
+		PolicyChanges: doc.policyChanges(),
+	}
+}
+
+// TemplateFuncs are the helpers available to runbook templates, in addition
+// to the text/template builtins.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"snippet":   stepTitleSnippet,
+		"cell":      escapeTableCell,
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"join":      strings.Join,
+		"indent":    indent,
+		"shquote":   shellQuote,
+		"psquote":   psQuote,
+		"deref":     func(p *int) int { return *p },
+		"timestamp": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+		"duration":  formatDuration,
+	}
+}
+
+// ParseTemplate parses a runbook template with TemplateFuncs.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs()).Option("missingkey=error").Parse(text)
+}
+
+// LoadTemplate reads and parses a runbook template file.
+func LoadTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read template: %w", err)
+	}
+	tmpl, err := ParseTemplate(filepath.Base(path), string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// DefaultTemplate returns the source of the built-in Markdown template, as a
+// starting point for a custom one.
+func DefaultTemplate() string {
+	return markdownTemplateText
+}
+
+// RenderTemplate renders session with opts.Template, or with the built-in
+// Markdown template when it is nil.
+func RenderTemplate(session *store.Session, opts MarkdownOptions) (string, error) {
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = markdownTemplate
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, NewTemplateData(session, opts)); err != nil {
+		return "", fmt.Errorf("render template: %w", err)
+	}
+	return b.String(), nil
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// formatDuration renders milliseconds for people: 850ms, 1.4s, 2m5s.
+func formatDuration(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", ms)
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	default:
+		return d.Round(time.Second).String()
+	}
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestDefaultTemplateMatchesGolden(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := ParseTemplate("default", DefaultTemplate())
+	if err != nil {
+		t.Fatalf("ParseTemplate failed: %v", err)
+	}
+	session := &store.Session{
+		ID:        "1",
+		Title:     "Deploy to staging",
+		StartedAt: time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		Steps: []store.Step{
+			{Command: "kubectl apply -f deploy.yaml", Status: "OK", ExitCode: intPtr(0), DurationMS: 820, CWD: "/repo"},
+			{Command: "kubectl rollout status deploy/api", Status: "OK", ExitCode: intPtr(0), DurationMS: 1450, CWD: "/repo"},
+		},
+	}
+	got, err := RenderTemplate(session, MarkdownOptions{Template: tmpl})
+	if err != nil {
+		t.Fatalf("RenderTemplate failed: %v", err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "session.golden.md"))
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if normalizeNewlines(got) != normalizeNewlines(string(want)) {
+		t.Fatalf("default template drifted from the golden runbook\n--- got ---\n%s", got)
+	}
+}
+
+func TestRenderTemplateWithCustomTemplate(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := ParseTemplate("company", strings.Join([]string{
+		`= {{upper .Session.Title}} ({{range .Metadata}}{{.Key}}={{.Value}} {{end}})`,
+		`{{range .Steps}}{{.Index}} {{lower .Status}} {{duration .DurationMS}}: {{shquote .Command}}`,
+		`{{with .Comments}}{{indent 2 (join . "\n")}}`,
+		`{{end}}{{end}}OK {{.Summary.OK}}/{{.Summary.Steps}}; {{len .Guidance.Rollback}} rollback item(s)`,
+	}, "\n"))
+	if err != nil {
+		t.Fatalf("ParseTemplate failed: %v", err)
+	}
+	session := &store.Session{
+		Title: "Rotate certs",
+		Env:   "prod",
+		Steps: []store.Step{
+			{Command: "echo it's done", Status: "OK", ExitCode: intPtr(0), DurationMS: 1450},
+			{Command: "make verify", ExitCode: intPtr(2), DurationMS: 125000},
+		},
+	}
+	got, err := RenderTemplate(session, MarkdownOptions{Template: tmpl, StepComments: map[int][]string{1: {"flaky", "rerun"}}})
+	if err != nil {
+		t.Fatalf("RenderTemplate failed: %v", err)
+	}
+	want := strings.Join([]string{
+		"= ROTATE CERTS (env=prod )",
+		`1 ok 1.4s: 'echo it'\''s done'`,
+		"2 failed 2m5s: 'make verify'",
+		"  flaky",
+		"  rerun",
+		"OK 1/2; 1 rollback item(s)",
+	}, "\n")
+	if got != want {
+		t.Fatalf("unexpected output\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+
+	broken, err := ParseTemplate("broken", "{{.Session.Missing}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate failed: %v", err)
+	}
+	if _, err := RenderTemplate(session, MarkdownOptions{Template: broken}); err == nil || !strings.Contains(err.Error(), "Missing") {
+		t.Fatalf("expected an execution error naming the field, got %v", err)
+	}
+}
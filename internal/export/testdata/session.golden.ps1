@@ -0,0 +1,29 @@
+# Deploy to staging
+# Generated by Commandry dev from session 1.
+# env: staging
+# Review every step before running this script.
+$ErrorActionPreference = 'Stop'
+
+$repoRoot = if ($env:CMDRY_REPO_ROOT) { $env:CMDRY_REPO_ROOT } else { (Get-Location).Path }
+
+# Step 1: OK
+Set-Location -LiteralPath 'C:\Users\ops\it''s here'
+kubectl apply -f deploy.yaml
+if ($LASTEXITCODE -ne 0) { throw "Step 1 failed with exit code $LASTEXITCODE" }
+
+# Step 2: OK
+Get-ChildItem -Path $env:TEMP | Select-Object -First 1
+
+# Step 3: FAILED (nonzero_exit, exit 1), commented out
+# Reviewer note: flaky, rerun
+# $ kubectl rollout status deployment/api
+
+# Step 4: OK
+Set-Location -LiteralPath (Join-Path $repoRoot deploy)
+& 'C:\Program Files\Tool\tool.exe' --name 'say "hi"'
+if ($LASTEXITCODE -ne 0) { throw "Step 4 failed with exit code $LASTEXITCODE" }
+
+# Step 5: REDACTED (policy_redacted)
+# $ [REDACTED BY POLICY]
+# TODO: replace the redacted command or values above.
+throw 'TODO: step 5 was redacted when it was recorded; fill it in before running this script.'
@@ -0,0 +1,32 @@
+#!/usr/bin/env bash
+# Deploy to staging
+# Generated by Commandry dev from session 1.
+# env: staging
+# Review every step before running this script.
+set -euo pipefail
+
+repo_root="${CMDRY_REPO_ROOT:-$PWD}"
+
+# Step 1: OK
+cd '/srv/it'\''s here'
+kubectl apply -f deploy.yaml
+
+# Step 2: FAILED (nonzero_exit, exit 1), commented out
+# Reviewer note: flaky, rerun
+# $ kubectl rollout status deployment/api
+
+# Step 3: OK, redacted values
+# $ curl -H 'Authorization: Bearer [REDACTED]' https://example.com
+# TODO: replace the redacted command or values above.
+echo 'TODO: step 3 was redacted when it was recorded; fill it in before running this script.' >&2
+exit 1
+
+# Step 4: REDACTED (policy_redacted)
+# $ [REDACTED BY POLICY]
+# TODO: replace the redacted command or values above.
+echo 'TODO: step 4 was redacted when it was recorded; fill it in before running this script.' >&2
+exit 1
+
+# Step 5: OK
+cd "$repo_root"/deploy/k8s
+make test
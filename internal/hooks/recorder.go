@@ -9,12 +9,18 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fixi2/Commandry/internal/audit"
 	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/privacy"
+	"github.com/fixi2/Commandry/internal/shellwords"
 	"github.com/fixi2/Commandry/internal/store"
 )
 
 type RecordInput struct {
-	Command    string
+	Command string
+	// Shell names the shell the command was typed into (bash, zsh, pwsh, ...)
+	// and selects how the line is tokenized; empty means POSIX.
+	Shell      string
 	CWD        string
 	ExitCode   int
 	DurationMS int64
@@ -26,12 +32,19 @@ type RecordResult struct {
 	SkippedReason string
 	Reminder      bool
 	Step          store.Step
+	// AuditErr is set when the step was recorded but its policy decisions
+	// could not be appended to the audit log.
+	AuditErr error
 }
 
 type Recorder struct {
 	store      store.SessionStore
 	policy     *policy.Policy
 	stateStore StateStore
+	ignore     map[string]bool
+	profiles   func(env string) policy.Profile
+	audit      *audit.Log
+	privacy    *privacy.Scrubber
 }
 
 func NewRecorder(sessionStore store.SessionStore, pol *policy.Policy, stateStore StateStore) *Recorder {
@@ -42,6 +55,33 @@ func NewRecorder(sessionStore store.SessionStore, pol *policy.Policy, stateStore
 	}
 }
 
+// IgnoreCommands skips commands whose program name (case-insensitive, without
+// path or extension) is in names.
+func (r *Recorder) IgnoreCommands(names []string) {
+	r.ignore = make(map[string]bool, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			r.ignore[programName(name)] = true
+		}
+	}
+}
+
+// UseProfiles selects the policy, and whether the working directory is kept,
+// from the environment label of the active session.
+func (r *Recorder) UseProfiles(resolve func(env string) policy.Profile) {
+	r.profiles = resolve
+}
+
+// UseAudit appends the policy decisions for each recorded step to log.
+func (r *Recorder) UseAudit(log *audit.Log) {
+	r.audit = log
+}
+
+// UsePrivacy scrubs each step with s after the policy has been applied.
+func (r *Recorder) UsePrivacy(s *privacy.Scrubber) {
+	r.privacy = s
+}
+
 func (r *Recorder) Record(ctx context.Context, input RecordInput) (RecordResult, error) {
 	raw := strings.TrimSpace(input.Command)
 	if raw == "" {
@@ -58,24 +98,46 @@ func (r *Recorder) Record(ctx context.Context, input RecordInput) (RecordResult,
 		}
 	}
 
-	args := splitCommand(raw)
+	dialect := shellwords.ParseDialect(input.Shell)
+	var args []string
+	if commands := shellwords.Commands(raw, dialect); len(commands) > 0 {
+		args = shellwords.StripWrappers(commands[0])
+	}
 	if isSelfInvocation(args) {
 		return RecordResult{Recorded: false, SkippedReason: "self_command"}, nil
 	}
+	if len(args) > 0 && r.ignore[programName(args[0])] {
+		return RecordResult{Recorded: false, SkippedReason: "ignored_command"}, nil
+	}
 
-	if _, err := r.store.GetActiveSession(ctx); err != nil {
+	session, err := r.store.GetActiveSession(ctx)
+	if err != nil {
 		if errors.Is(err, store.ErrNoActiveSession) || errors.Is(err, store.ErrNotInitialized) {
 			return RecordResult{Recorded: false, SkippedReason: "no_active_session"}, nil
 		}
 		return RecordResult{}, fmt.Errorf("check active session: %w", err)
 	}
 
-	sanitized := r.policy.Apply(raw, args)
+	pol, cwd := r.policy, input.CWD
+	if r.profiles != nil {
+		profile := r.profiles(session.Env)
+		pol = profile.Policy
+		if !profile.RecordCWD {
+			cwd = ""
+		}
+	}
+
+	explained := pol.ExplainLine(raw, dialect)
+	sanitized := explained.Result()
 	step := store.Step{
-		Timestamp:  normalizeTimestamp(input.Timestamp),
-		Command:    sanitized.Command,
-		DurationMS: clampDuration(input.DurationMS),
-		CWD:        input.CWD,
+		Timestamp:         normalizeTimestamp(input.Timestamp),
+		Command:           sanitized.Command,
+		DurationMS:        clampDuration(input.DurationMS),
+		CWD:               pol.RedactCWD(cwd),
+		PolicyFingerprint: pol.Fingerprint(),
+	}
+	if r.privacy != nil {
+		r.privacy.Step(&step)
 	}
 	if sanitized.Denied {
 		step.Status = "REDACTED"
@@ -94,6 +156,10 @@ func (r *Recorder) Record(ctx context.Context, input RecordInput) (RecordResult,
 	if err := r.store.AddStep(ctx, step); err != nil {
 		return RecordResult{}, fmt.Errorf("record hook step: %w", err)
 	}
+	var auditErr error
+	if r.audit != nil {
+		auditErr = r.audit.Append(audit.StepEvents(session, audit.SourceHook, step.Timestamp, explained, false)...)
+	}
 
 	reminder, err := r.bumpCounter(ctx)
 	if err != nil {
@@ -104,6 +170,7 @@ func (r *Recorder) Record(ctx context.Context, input RecordInput) (RecordResult,
 		Recorded: true,
 		Reminder: reminder,
 		Step:     step,
+		AuditErr: auditErr,
 	}, nil
 }
 
@@ -125,14 +192,6 @@ func (r *Recorder) bumpCounter(ctx context.Context) (bool, error) {
 	return state.Enabled && state.RemindEvery > 0 && state.CommandCount%int64(state.RemindEvery) == 0, nil
 }
 
-func splitCommand(raw string) []string {
-	parts := strings.Fields(raw)
-	if len(parts) == 0 {
-		return nil
-	}
-	return parts
-}
-
 func isSelfInvocation(args []string) bool {
 	if len(args) == 0 {
 		return false
@@ -141,6 +200,11 @@ func isSelfInvocation(args []string) bool {
 	return binary == "cmdry" || binary == "cmdry.exe" || binary == "cmdr" || binary == "infratrack" || binary == "infratrack.exe" || binary == "it"
 }
 
+func programName(arg string) string {
+	name := strings.ToLower(filepath.Base(strings.Trim(arg, `"'`)))
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
 func normalizeTimestamp(ts time.Time) time.Time {
 	if ts.IsZero() {
 		return time.Now().UTC()
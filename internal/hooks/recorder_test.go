@@ -3,9 +3,11 @@ package hooks
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/fixi2/Commandry/internal/audit"
 	"github.com/fixi2/Commandry/internal/policy"
 	"github.com/fixi2/Commandry/internal/store"
 )
@@ -19,7 +21,7 @@ func TestRecorderRecord(t *testing.T) {
 	if err := sessionStore.Init(ctx); err != nil {
 		t.Fatalf("init store: %v", err)
 	}
-	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC()); err != nil {
+	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC(), nil); err != nil {
 		t.Fatalf("start session: %v", err)
 	}
 
@@ -68,7 +70,7 @@ func TestRecorderNoReminderWhenDisabled(t *testing.T) {
 	if err := sessionStore.Init(ctx); err != nil {
 		t.Fatalf("init store: %v", err)
 	}
-	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC()); err != nil {
+	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC(), nil); err != nil {
 		t.Fatalf("start session: %v", err)
 	}
 
@@ -106,7 +108,7 @@ func TestRecorderSkipsSelfCommands(t *testing.T) {
 	if err := sessionStore.Init(ctx); err != nil {
 		t.Fatalf("init store: %v", err)
 	}
-	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC()); err != nil {
+	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC(), nil); err != nil {
 		t.Fatalf("start session: %v", err)
 	}
 
@@ -188,7 +190,7 @@ func TestRecorderAppliesPolicy(t *testing.T) {
 	if err := sessionStore.Init(ctx); err != nil {
 		t.Fatalf("init store: %v", err)
 	}
-	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC()); err != nil {
+	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC(), nil); err != nil {
 		t.Fatalf("start session: %v", err)
 	}
 
@@ -217,6 +219,42 @@ func TestRecorderAppliesPolicy(t *testing.T) {
 	}
 }
 
+func TestRecorderKeepsStepWhenAuditLogFails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	root := newRetryTempDir(t)
+	sessionStore := store.NewJSONStore(root)
+	if err := sessionStore.Init(ctx); err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC(), nil); err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	// A file where the audit directory should be makes every append fail.
+	blocker := filepath.Join(root, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatalf("write blocker: %v", err)
+	}
+
+	rec := NewRecorder(sessionStore, policy.NewDefault(), nil)
+	rec.UseAudit(audit.Open(filepath.Join(blocker, "audit")))
+	result, err := rec.Record(ctx, RecordInput{Command: "export API_TOKEN=abc123", DurationMS: 5})
+	if err != nil {
+		t.Fatalf("record command: %v", err)
+	}
+	if !result.Recorded || result.AuditErr == nil {
+		t.Fatalf("expected a recorded step and an audit error: %+v", result)
+	}
+	active, err := sessionStore.GetActiveSession(ctx)
+	if err != nil {
+		t.Fatalf("active session: %v", err)
+	}
+	if len(active.Steps) != 1 {
+		t.Fatalf("expected the step to be kept, got %d step(s)", len(active.Steps))
+	}
+}
+
 func TestRecorderSkipsWhenHooksDisabled(t *testing.T) {
 	t.Parallel()
 
@@ -226,7 +264,7 @@ func TestRecorderSkipsWhenHooksDisabled(t *testing.T) {
 	if err := sessionStore.Init(ctx); err != nil {
 		t.Fatalf("init store: %v", err)
 	}
-	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC()); err != nil {
+	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC(), nil); err != nil {
 		t.Fatalf("start session: %v", err)
 	}
 
@@ -259,3 +297,71 @@ func newRetryTempDir(t *testing.T) string {
 	})
 	return dir
 }
+
+func TestRecorderSkipsIgnoredCommands(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sessionStore := store.NewMemoryStore()
+	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC(), nil); err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+
+	rec := NewRecorder(sessionStore, policy.NewDefault(), nil)
+	rec.IgnoreCommands([]string{"ls", " Clear "})
+	for _, command := range []string{"ls -la", "/usr/bin/ls", "CLEAR", "clear.exe"} {
+		result, err := rec.Record(ctx, RecordInput{Command: command})
+		if err != nil {
+			t.Fatalf("record %q: %v", command, err)
+		}
+		if result.Recorded || result.SkippedReason != "ignored_command" {
+			t.Fatalf("expected %q to be ignored, got %+v", command, result)
+		}
+	}
+
+	result, err := rec.Record(ctx, RecordInput{Command: "lsof -i :8080"})
+	if err != nil {
+		t.Fatalf("record lsof: %v", err)
+	}
+	if !result.Recorded {
+		t.Fatalf("expected lsof to be recorded, got %+v", result)
+	}
+}
+
+func TestRecorderTokenizesCompoundCommands(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sessionStore := store.NewMemoryStore()
+	if _, err := sessionStore.StartSession(ctx, "hooks", "", time.Now().UTC(), nil); err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+
+	rec := NewRecorder(sessionStore, policy.NewDefault(), nil)
+	rec.IgnoreCommands([]string{"ls"})
+
+	tests := []struct {
+		input  RecordInput
+		reason string
+		denied bool
+	}{
+		{input: RecordInput{Command: "echo hi | printenv"}, denied: true},
+		{input: RecordInput{Command: "sudo printenv PATH"}, denied: true},
+		{input: RecordInput{Command: "sudo ls /root"}, reason: "ignored_command"},
+		{input: RecordInput{Command: "FOO=1 cmdry status"}, reason: "self_command"},
+		{input: RecordInput{Command: `Get-Item C:\temp; & "printenv.exe"`, Shell: "pwsh"}, denied: true},
+		{input: RecordInput{Command: `git commit -m "docs: a | b && c"`}},
+	}
+	for _, tt := range tests {
+		result, err := rec.Record(ctx, tt.input)
+		if err != nil {
+			t.Fatalf("record %q: %v", tt.input.Command, err)
+		}
+		if result.SkippedReason != tt.reason {
+			t.Fatalf("%q: skipped reason = %q, want %q", tt.input.Command, result.SkippedReason, tt.reason)
+		}
+		if tt.reason == "" && (result.Step.Command == policy.DeniedPlaceholder) != tt.denied {
+			t.Fatalf("%q: recorded as %q, denied want %v", tt.input.Command, result.Step.Command, tt.denied)
+		}
+	}
+}
@@ -36,6 +36,11 @@ func NewFileStateStore(rootPath string) *FileStateStore {
 	}
 }
 
+// SetRootDir points the state store at a different root directory.
+func (s *FileStateStore) SetRootDir(rootPath string) {
+	*s = *NewFileStateStore(rootPath)
+}
+
 func (s *FileStateStore) Load(_ context.Context) (State, error) {
 	data, err := os.ReadFile(s.path)
 	if err != nil {
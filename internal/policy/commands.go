@@ -0,0 +1,30 @@
+package policy
+
+import "github.com/fixi2/Commandry/internal/shellwords"
+
+// maxScriptDepth bounds how many nested `sh -c` scripts are unpacked.
+const maxScriptDepth = 4
+
+// splitCommandLine returns the simple commands of a shell line with wrappers
+// such as sudo and env removed.
+func splitCommandLine(line string, dialect shellwords.Dialect, depth int) [][]string {
+	var out [][]string
+	for _, args := range shellwords.Commands(line, dialect) {
+		out = append(out, expandCommand(args, depth)...)
+	}
+	return out
+}
+
+// expandCommand strips wrappers from one argument vector and, for shells
+// started with an inline script (bash -c '...'), adds the script's commands.
+func expandCommand(args []string, depth int) [][]string {
+	if len(args) == 0 {
+		return nil
+	}
+	args = shellwords.StripWrappers(args)
+	out := [][]string{args}
+	if script, dialect, ok := shellwords.InlineScript(args); ok && depth < maxScriptDepth {
+		out = append(out, splitCommandLine(script, dialect, depth+1)...)
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fixi2/Commandry/internal/shellwords"
+)
+
+func TestApplyLineChecksEverySimpleCommand(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Options{DenylistPatterns: []string{"vault read*"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	denied := []string{
+		"echo hi | env",
+		"sudo printenv",
+		"sudo -E env FOO=1 printenv PATH",
+		"make build && (cd deploy; printenv)",
+		`echo "$(kubectl get secret db -o yaml)"`,
+		"time nice -n 5 kubectl get secret db -o json > db.json",
+		`bash -c "kubectl get secret db -o yaml | base64 -d"`,
+		"sudo  -u  ops  vault   read kv/db",
+	}
+	for _, line := range denied {
+		if got := p.ApplyLine(line, shellwords.POSIX); !got.Denied {
+			t.Errorf("ApplyLine(%q) was not denied: %+v", line, got)
+		}
+	}
+
+	allowed := []string{
+		"kubectl get secrets -n prod",
+		`grep -rn "printenv" docs`,
+		"sudo systemctl restart nginx",
+	}
+	for _, line := range allowed {
+		if got := p.ApplyLine(line, shellwords.POSIX); got.Denied || got.Command != line {
+			t.Errorf("ApplyLine(%q) = %+v, want unchanged", line, got)
+		}
+	}
+
+	if got := p.ApplyLine("Get-Process | Out-Null; & printenv.exe", shellwords.PowerShell); !got.Denied {
+		t.Fatalf("PowerShell call operator hid printenv: %+v", got)
+	}
+}
+
+func TestApplyWithExactArgsDoesNotReparse(t *testing.T) {
+	t.Parallel()
+
+	// cmdry run passes argv; a quoted pipe is data, not a pipeline.
+	p, err := New(Options{DenylistPatterns: []string{"vault read*"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	args := []string{"grep", "-E", "a|env", "notes.txt"}
+	got := p.Apply(strings.Join(args, " "), args)
+	if got.Denied {
+		t.Fatalf("argument text must not be split into commands: %+v", got)
+	}
+}
+
+func TestKubectlSetImageInPipeline(t *testing.T) {
+	t.Parallel()
+
+	line := "sudo kubectl set image deployment/api api=ghcr.io/acme/api:1.4.2 && kubectl rollout status deployment/api"
+	if got := NewDefault().ApplyLine(line, shellwords.POSIX); got.Command != line {
+		t.Fatalf("image assignment was redacted: %q", got.Command)
+	}
+}
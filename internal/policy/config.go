@@ -3,13 +3,19 @@ package policy
 import (
 	"fmt"
 	"os"
-	"strings"
+	"sort"
+
+	"github.com/fixi2/Commandry/internal/config"
 )
 
 type Config struct {
 	Denylist          []string
 	RedactionKeywords []string
+	RedactionRules    []RedactionRule
+	DisabledDetectors []string
 	EnforceDenylist   bool
+	GuardPatterns     []string
+	DisabledGuards    []string
 }
 
 func ParseConfigFile(path string) (Config, error) {
@@ -20,115 +26,66 @@ func ParseConfigFile(path string) (Config, error) {
 	return ParseConfig(string(data))
 }
 
+// ParseConfig extracts the policy section of a config.yaml document. Lists
+// that are missing or empty fall back to the built-in defaults.
 func ParseConfig(content string) (Config, error) {
-	content = strings.TrimPrefix(content, "\ufeff")
+	cfg, _, err := config.Parse([]byte(content))
+	if err != nil {
+		return Config{}, err
+	}
+	return FromConfig(cfg.Policy), nil
+}
+
+// FromConfig resolves a parsed policy section against the built-in defaults.
+func FromConfig(pc config.PolicyConfig) Config {
 	cfg := Config{
 		Denylist:          append([]string(nil), defaultDenylistPatterns...),
 		RedactionKeywords: append([]string(nil), defaultRedactionKeywords...),
-		EnforceDenylist:   false,
+		EnforceDenylist:   pc.EnforceDenylist,
 	}
-
-	var (
-		inPolicy      bool
-		currentList   string
-		denylistSeen  bool
-		keywordsSeen  bool
-		parsedDeny    []string
-		parsedKeyword []string
-	)
-
-	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
-	for idx, raw := range lines {
-		raw = strings.TrimPrefix(raw, "\ufeff")
-		line := strings.TrimRight(raw, " \t")
-		trim := strings.TrimSpace(line)
-		if trim == "" || strings.HasPrefix(trim, "#") {
-			continue
-		}
-
-		if !strings.HasPrefix(line, " ") {
-			currentList = ""
-			inPolicy = strings.HasPrefix(trim, "policy:")
-			continue
-		}
-		if !inPolicy {
-			continue
-		}
-
-		if strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "    ") {
-			currentList = ""
-			key, value, hasValue := splitKeyValue(strings.TrimSpace(line))
-			switch key {
-			case "denylist":
-				denylistSeen = true
-				parsedDeny = parsedDeny[:0]
-				currentList = "denylist"
-			case "redaction_keywords":
-				keywordsSeen = true
-				parsedKeyword = parsedKeyword[:0]
-				currentList = "redaction_keywords"
-			case "enforce_denylist":
-				if !hasValue {
-					return Config{}, fmt.Errorf("parse policy config line %d: enforce_denylist requires a boolean value", idx+1)
-				}
-				value = strings.ToLower(strings.TrimSpace(value))
-				switch value {
-				case "true":
-					cfg.EnforceDenylist = true
-				case "false":
-					cfg.EnforceDenylist = false
-				default:
-					return Config{}, fmt.Errorf("parse policy config line %d: enforce_denylist must be true or false", idx+1)
-				}
-			}
-			continue
-		}
-
-		if strings.HasPrefix(line, "    - ") && currentList != "" {
-			item := strings.TrimSpace(strings.TrimPrefix(line, "    - "))
-			item = trimMatchingQuotes(item)
-			if item == "" {
-				continue
-			}
-			switch currentList {
-			case "denylist":
-				parsedDeny = append(parsedDeny, item)
-			case "redaction_keywords":
-				parsedKeyword = append(parsedKeyword, item)
-			}
-		}
+	if len(pc.Denylist) > 0 {
+		cfg.Denylist = append([]string(nil), pc.Denylist...)
 	}
-
-	if denylistSeen && len(parsedDeny) > 0 {
-		cfg.Denylist = parsedDeny
+	if len(pc.RedactionKeywords) > 0 {
+		cfg.RedactionKeywords = append([]string(nil), pc.RedactionKeywords...)
 	}
-	if keywordsSeen && len(parsedKeyword) > 0 {
-		cfg.RedactionKeywords = parsedKeyword
+	for _, rule := range pc.RedactionRules {
+		cfg.RedactionRules = append(cfg.RedactionRules, RedactionRule{
+			Name:        rule.Name,
+			Pattern:     rule.Pattern,
+			Replacement: rule.Replacement,
+			AppliesTo:   rule.AppliesTo,
+		})
 	}
-
-	return cfg, nil
-}
-
-func splitKeyValue(line string) (key string, value string, hasValue bool) {
-	parts := strings.SplitN(line, ":", 2)
-	if len(parts) != 2 {
-		return strings.TrimSpace(line), "", false
+	for name, enabled := range pc.Detectors {
+		if !enabled {
+			cfg.DisabledDetectors = append(cfg.DisabledDetectors, name)
+		}
 	}
-	key = strings.TrimSpace(parts[0])
-	value = strings.TrimSpace(parts[1])
-	if value == "" {
-		return key, "", false
+	sort.Strings(cfg.DisabledDetectors)
+	cfg.GuardPatterns = append([]string(nil), pc.Guard.Patterns...)
+	for name, enabled := range pc.Guard.Rules {
+		if !enabled {
+			cfg.DisabledGuards = append(cfg.DisabledGuards, name)
+		}
 	}
-	return key, trimMatchingQuotes(value), true
+	sort.Strings(cfg.DisabledGuards)
+	return cfg
 }
 
-func trimMatchingQuotes(s string) string {
-	s = strings.TrimSpace(s)
-	if len(s) < 2 {
-		return s
-	}
-	if (s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\'') {
-		return s[1 : len(s)-1]
-	}
-	return s
+// NewFromConfig builds a policy from a parsed policy section.
+func NewFromConfig(pc config.PolicyConfig) (*Policy, error) {
+	return FromConfig(pc).build()
+}
+
+func (c Config) build() (*Policy, error) {
+	return New(Options{
+		DenylistPatterns:  c.Denylist,
+		RedactionKeywords: c.RedactionKeywords,
+		RedactionRules:    c.RedactionRules,
+		DisabledDetectors: c.DisabledDetectors,
+		EnforceDenylist:   c.EnforceDenylist,
+		GuardPatterns:     c.GuardPatterns,
+		DisabledGuards:    c.DisabledGuards,
+	})
 }
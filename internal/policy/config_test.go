@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/fixi2/Commandry/internal/shellwords"
 )
 
 func TestParseConfigUsesDefaultsWhenPolicySectionMissing(t *testing.T) {
@@ -34,6 +36,9 @@ func TestLoadFromConfigParsesRuntimePolicy(t *testing.T) {
 		"  redaction_keywords:",
 		"    - session_token",
 		"  enforce_denylist: true",
+		"  guard:",
+		"    patterns: [\"aws s3 rb *\"]",
+		"    rules: {helm_uninstall: false}",
 	}, "\n")
 	if err := osWriteFile(path, []byte(content)); err != nil {
 		t.Fatalf("write config: %v", err)
@@ -52,6 +57,13 @@ func TestLoadFromConfigParsesRuntimePolicy(t *testing.T) {
 		t.Fatalf("expected denylist to deny docker login")
 	}
 
+	if _, ok := p.DangerousLine("aws s3 rb s3://logs", shellwords.POSIX); !ok {
+		t.Fatalf("expected the configured guard pattern to apply")
+	}
+	if _, ok := p.DangerousLine("helm uninstall api", shellwords.POSIX); ok {
+		t.Fatalf("expected the disabled guard rule to be skipped")
+	}
+
 	sanitized := p.Apply("run --session-token=abc123", []string{"run", "--session-token=abc123"})
 	if strings.Contains(sanitized.Command, "abc123") {
 		t.Fatalf("expected session token to be redacted, got %q", sanitized.Command)
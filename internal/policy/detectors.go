@@ -0,0 +1,247 @@
+package policy
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Detector names. They are stable because config.yaml refers to them under
+// policy.detectors.
+const (
+	DetectorAWSAccessKey = "aws_access_key_id"
+	DetectorGitHubToken  = "github_token"
+	DetectorGitLabToken  = "gitlab_token"
+	DetectorSlackToken   = "slack_token"
+	DetectorStripeKey    = "stripe_key"
+	DetectorGoogleAPIKey = "google_api_key"
+	DetectorNPMToken     = "npm_token"
+	DetectorJWT          = "jwt"
+	DetectorPrivateKey   = "private_key_block"
+	DetectorHighEntropy  = "high_entropy"
+)
+
+const (
+	highEntropyMinLength  = 24
+	highEntropyMinBitsPer = 4.0
+)
+
+type detector struct {
+	name        string
+	description string
+	re          *regexp.Regexp
+	// accept filters regex matches; nil accepts every match.
+	accept func(string) bool
+}
+
+// Detectors are ordered from most to least specific so that a token is
+// reported under its format name rather than as high entropy.
+var builtinDetectors = []detector{
+	{
+		name:        DetectorPrivateKey,
+		description: "PEM private key block",
+		re:          regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY-----(?:[\s\S]*?-----END[A-Z ]*PRIVATE KEY-----|[\s\S]*)`),
+	},
+	{
+		name:        DetectorAWSAccessKey,
+		description: "AWS access key id (AKIA/ASIA...)",
+		re:          regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA)[0-9A-Z]{16}\b`),
+	},
+	{
+		name:        DetectorGitHubToken,
+		description: "GitHub token (ghp_, gho_, ghu_, ghs_, ghr_, github_pat_)",
+		re:          regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`),
+	},
+	{
+		name:        DetectorGitLabToken,
+		description: "GitLab personal access token (glpat-)",
+		re:          regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`),
+	},
+	{
+		name:        DetectorSlackToken,
+		description: "Slack token (xoxb-, xoxp-, xoxa-, xoxr-, xoxs-)",
+		re:          regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	},
+	{
+		name:        DetectorStripeKey,
+		description: "Stripe secret or restricted key (sk_live_, rk_test_, ...)",
+		re:          regexp.MustCompile(`\b[sr]k_(?:live|test)_[A-Za-z0-9]{16,}\b`),
+	},
+	{
+		name:        DetectorGoogleAPIKey,
+		description: "Google API key (AIza...)",
+		re:          regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`),
+	},
+	{
+		name:        DetectorNPMToken,
+		description: "npm access token (npm_)",
+		re:          regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`),
+	},
+	{
+		name:        DetectorJWT,
+		description: "JSON Web Token (eyJ...eyJ...signature)",
+		re:          regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`),
+	},
+	{
+		name:        DetectorHighEntropy,
+		description: "long random-looking token (Shannon entropy check)",
+		re:          regexp.MustCompile(`[A-Za-z0-9+/_-]{24,}={0,2}`),
+		accept:      looksRandom,
+	},
+}
+
+// DetectorInfo describes a built-in detector.
+type DetectorInfo struct {
+	Name        string
+	Description string
+}
+
+// Detectors lists the built-in detectors in evaluation order.
+func Detectors() []DetectorInfo {
+	out := make([]DetectorInfo, 0, len(builtinDetectors))
+	for _, d := range builtinDetectors {
+		out = append(out, DetectorInfo{Name: d.name, Description: d.description})
+	}
+	return out
+}
+
+// IsDetector reports whether name is a built-in detector.
+func IsDetector(name string) bool {
+	for _, d := range builtinDetectors {
+		if d.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func enabledDetectors(disabled []string) []detector {
+	off := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		off[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	out := make([]detector, 0, len(builtinDetectors))
+	for _, d := range builtinDetectors {
+		if !off[d.name] {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// redact replaces accepted matches with RedactedValue and reports whether
+// anything was replaced.
+func (d detector) redact(s string) (string, bool) {
+	matches := d.re.FindAllStringIndex(s, -1)
+	if len(matches) == 0 {
+		return s, false
+	}
+	var b strings.Builder
+	last, hit := 0, false
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		// Option prefixes such as --key are part of the character class.
+		for start < end && s[start] == '-' {
+			start++
+		}
+		candidate := s[start:end]
+		if candidate == RedactedValue || (d.accept != nil && !d.accept(candidate)) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(RedactedValue)
+		last, hit = end, true
+	}
+	if !hit {
+		return s, false
+	}
+	b.WriteString(s[last:])
+	return b.String(), true
+}
+
+// looksRandom accepts long tokens that mix letters and digits with high
+// per-character entropy. Hex strings (commit SHAs, digests, UUIDs) and
+// dash-separated names are skipped because they are rarely secrets in
+// commands; paths and URLs are judged one segment at a time.
+func looksRandom(s string) bool {
+	s = strings.Trim(strings.TrimRight(s, "="), "/")
+	if strings.Count(s, "/") >= 2 {
+		for _, segment := range strings.Split(s, "/") {
+			if looksRandom(segment) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(s) < highEntropyMinLength {
+		return false
+	}
+	if isHexLike(s) || isCompoundName(s) {
+		return false
+	}
+	var lower, upper, digit bool
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	if !digit || !(lower || upper) {
+		return false
+	}
+	return shannonEntropy(s) >= highEntropyMinBitsPer
+}
+
+func isHexLike(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// isCompoundName reports whether s is built from three or more short parts
+// joined by '-' or '_', like Kubernetes pod names and release labels.
+func isCompoundName(s string) bool {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) < 3 {
+		return false
+	}
+	for _, part := range parts {
+		if len(part) > 12 {
+			return false
+		}
+	}
+	return true
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	keys := make([]rune, 0, len(counts))
+	for r := range counts {
+		keys = append(keys, r)
+	}
+	// Sum in a fixed order so results are identical across runs.
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	var entropy float64
+	for _, r := range keys {
+		p := float64(counts[r]) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/shellwords"
+)
+
+// Decisions reported by Explain.
+const (
+	DecisionAllowed  = "allowed"
+	DecisionRedacted = "redacted"
+	DecisionDenied   = "denied"
+)
+
+// Rule kinds reported by Explain.
+const (
+	RuleBuiltin  = "builtin"
+	RuleDenylist = "denylist"
+	RuleCustom   = "rule"
+	RuleDetector = "detector"
+)
+
+// DenyMatch identifies what denied a command: a built-in rule by its function
+// name, or a denylist entry as written in config.yaml.
+type DenyMatch struct {
+	Kind string `json:"kind"`
+	Rule string `json:"rule"`
+}
+
+// Redaction is one redactor that changed the command, with the text before
+// and after it ran.
+type Redaction struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Explanation describes how the policy treated a command.
+type Explanation struct {
+	Input      string      `json:"input"`
+	Output     string      `json:"output"`
+	Decision   string      `json:"decision"`
+	Denied     bool        `json:"denied"`
+	Enforced   bool        `json:"enforced"`
+	DeniedBy   *DenyMatch  `json:"denied_by,omitempty"`
+	Redactions []Redaction `json:"redactions"`
+	// Preserved lists kubectl set image assignments kept verbatim.
+	Preserved []string `json:"preserved,omitempty"`
+}
+
+// Detected returns the names of the secret detectors that fired.
+func (e Explanation) Detected() []string {
+	var names []string
+	for _, r := range e.Redactions {
+		if r.Kind == RuleDetector {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+// Result is the sanitized command Apply would return.
+func (e Explanation) Result() Result {
+	return Result{
+		Command:  e.Output,
+		Denied:   e.Denied,
+		Detected: e.Detected(),
+	}
+}
+
+// Explain runs a command through the policy the same way Apply does and
+// records each decision along the way.
+func (p *Policy) Explain(rawCommand string, args []string) Explanation {
+	if args == nil {
+		return p.ExplainLine(rawCommand, shellwords.POSIX)
+	}
+	return p.explain(rawCommand, expandCommand(args, 0))
+}
+
+// ExplainLine is Explain for a command line typed into a shell.
+func (p *Policy) ExplainLine(line string, dialect shellwords.Dialect) Explanation {
+	return p.explain(line, splitCommandLine(line, dialect, 0))
+}
+
+func (p *Policy) explain(rawCommand string, commands [][]string) Explanation {
+	e := Explanation{
+		Input:      rawCommand,
+		Redactions: []Redaction{},
+	}
+	if match, ok := p.denyMatch(rawCommand, commands); ok {
+		e.Output = DeniedPlaceholder
+		e.Decision = DecisionDenied
+		e.Denied = true
+		e.Enforced = p.enforceDenylist
+		e.DeniedBy = &match
+		return e
+	}
+
+	e.Output, e.Preserved = p.redactPreserving(rawCommand, commands, func(kind, name, before, after string) {
+		e.Redactions = append(e.Redactions, Redaction{Kind: kind, Name: name, Before: before, After: after})
+	})
+	e.Decision = DecisionAllowed
+	if len(e.Redactions) > 0 {
+		e.Decision = DecisionRedacted
+	}
+	return e
+}
+
+// redactPreserving runs redactCommand over rawCommand with the image
+// assignments of `kubectl set image` kept out of its reach, and returns the
+// result and the assignments it kept. changed sees the text with them put
+// back.
+func (p *Policy) redactPreserving(rawCommand string, commands [][]string, changed func(kind, name, before, after string)) (string, []string) {
+	sanitized, preserved := preserveKubectlSetImageAssignments(rawCommand, commands)
+	restore := func(s string) string {
+		for placeholder, original := range preserved {
+			s = strings.ReplaceAll(s, placeholder, original)
+		}
+		return s
+	}
+	sanitized = p.redactCommand(sanitized, func(kind, name, before, after string) {
+		changed(kind, name, restore(before), restore(after))
+	})
+	var kept []string
+	for _, original := range preserved {
+		kept = append(kept, original)
+	}
+	sort.Strings(kept)
+	return restore(sanitized), kept
+}
+
+// redactCommand runs the command redactors over s in order and returns the
+// result. changed is called for each redactor that altered the text.
+func (p *Policy) redactCommand(s string, changed func(kind, name, before, after string)) string {
+	step := func(kind, name, after string) {
+		if after != s {
+			changed(kind, name, s, after)
+		}
+		s = after
+	}
+	for i, rule := range p.custom {
+		if rule.target != TargetCommand {
+			continue
+		}
+		name := rule.name
+		if name == "" {
+			name = "#" + strconv.Itoa(i+1)
+		}
+		step(RuleCustom, name, rule.apply(s))
+	}
+	for _, d := range p.detectors {
+		after, _ := d.redact(s)
+		step(RuleDetector, d.name, after)
+	}
+	for _, rule := range p.redact {
+		step(RuleBuiltin, rule.name, rule.re.ReplaceAllString(s, rule.repl))
+	}
+	return s
+}
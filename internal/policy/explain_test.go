@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplainDenied(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Options{DenylistPatterns: []string{"terraform output*"}, EnforceDenylist: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	cases := map[string]DenyMatch{
+		"kubectl get secret db -o json": {Kind: RuleBuiltin, Rule: "isKubectlSecretOutputDenied"},
+		"printenv HOME":                 {Kind: RuleBuiltin, Rule: "isEnvDumpDenied"},
+		"terraform output -json":        {Kind: RuleDenylist, Rule: "terraform output*"},
+	}
+	for input, want := range cases {
+		e := p.Explain(input, strings.Fields(input))
+		if e.Decision != DecisionDenied || !e.Enforced || e.DeniedBy == nil || *e.DeniedBy != want {
+			t.Fatalf("Explain(%q) = %+v, want %+v", input, e, want)
+		}
+		if e.Output != DeniedPlaceholder || len(e.Redactions) != 0 {
+			t.Fatalf("unexpected output for %q: %+v", input, e)
+		}
+	}
+}
+
+func TestExplainListsRedactorsInOrder(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Options{RedactionRules: []RedactionRule{{Name: "tenant", Pattern: `tenant-\d+`}}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	input := "deploy tenant-42 --password hunter2 AKIA7WBOBYXE1TVG6JGR"
+	e := p.Explain(input, strings.Fields(input))
+
+	if e.Decision != DecisionRedacted {
+		t.Fatalf("decision = %q", e.Decision)
+	}
+	want := []Redaction{
+		{Kind: RuleCustom, Name: "tenant", Before: input, After: "deploy [REDACTED] --password hunter2 AKIA7WBOBYXE1TVG6JGR"},
+		{Kind: RuleDetector, Name: DetectorAWSAccessKey, Before: "deploy [REDACTED] --password hunter2 AKIA7WBOBYXE1TVG6JGR", After: "deploy [REDACTED] --password hunter2 [REDACTED]"},
+		{Kind: RuleBuiltin, Name: "keyword_flag_value", Before: "deploy [REDACTED] --password hunter2 [REDACTED]", After: "deploy [REDACTED] --password [REDACTED] [REDACTED]"},
+	}
+	if len(e.Redactions) != len(want) {
+		t.Fatalf("redactions = %+v", e.Redactions)
+	}
+	for i := range want {
+		if e.Redactions[i] != want[i] {
+			t.Fatalf("redaction %d = %+v, want %+v", i, e.Redactions[i], want[i])
+		}
+	}
+	if got := p.Apply(input, strings.Fields(input)); got.Command != e.Output {
+		t.Fatalf("Apply and Explain disagree: %q vs %q", got.Command, e.Output)
+	}
+}
+
+func TestExplainAllowed(t *testing.T) {
+	t.Parallel()
+
+	input := "kubectl set image deployment/api api=ghcr.io/acme/api:1.4.2"
+	e := NewDefault().Explain(input, strings.Fields(input))
+	if e.Decision != DecisionAllowed || e.Output != input || len(e.Redactions) != 0 {
+		t.Fatalf("unexpected explanation: %+v", e)
+	}
+	if len(e.Preserved) != 1 || e.Preserved[0] != "api=ghcr.io/acme/api:1.4.2" {
+		t.Fatalf("preserved = %v", e.Preserved)
+	}
+}
@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// fingerprintVersion is bumped when the canonical form below changes.
+const fingerprintVersion = 1
+
+// fingerprintInput is the canonical form of the resolved options: defaults
+// filled in, empty entries dropped and built-in rules listed by name.
+type fingerprintInput struct {
+	Version           int             `json:"v"`
+	Denylist          []string        `json:"denylist"`
+	RedactionKeywords []string        `json:"redaction_keywords"`
+	RedactionRules    []RedactionRule `json:"redaction_rules"`
+	Detectors         []string        `json:"detectors"`
+	EnforceDenylist   bool            `json:"enforce_denylist"`
+	GuardPatterns     []string        `json:"guard_patterns"`
+	GuardRules        []string        `json:"guard_rules"`
+}
+
+// computeFingerprint hashes the compiled policy together with the keywords
+// and rules it was built from, which the compiled form does not keep.
+func (p *Policy) computeFingerprint(keywords []string, rules []RedactionRule) string {
+	in := fingerprintInput{
+		Version:           fingerprintVersion,
+		Denylist:          make([]string, 0, len(p.denylist)),
+		RedactionKeywords: make([]string, 0, len(keywords)),
+		RedactionRules:    make([]RedactionRule, 0, len(rules)),
+		EnforceDenylist:   p.enforceDenylist,
+	}
+	for _, rule := range p.denylist {
+		in.Denylist = append(in.Denylist, rule.pattern)
+	}
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			in.RedactionKeywords = append(in.RedactionKeywords, keyword)
+		}
+	}
+	for _, rule := range rules {
+		rule.AppliesTo = strings.ToLower(strings.TrimSpace(rule.AppliesTo))
+		if rule.AppliesTo == "" {
+			rule.AppliesTo = TargetCommand
+		}
+		in.RedactionRules = append(in.RedactionRules, rule)
+	}
+	for _, d := range p.detectors {
+		in.Detectors = append(in.Detectors, d.name)
+	}
+	for _, rule := range p.guard {
+		in.GuardPatterns = append(in.GuardPatterns, rule.pattern)
+	}
+	for _, rule := range p.guardRules {
+		in.GuardRules = append(in.GuardRules, rule.name)
+	}
+
+	payload, _ := json.Marshal(in)
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:6])
+}
+
+// Fingerprint identifies the effective policy: two policies with the same
+// fingerprint treat every command the same way.
+func (p *Policy) Fingerprint() string {
+	return p.fingerprint
+}
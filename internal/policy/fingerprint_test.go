@@ -0,0 +1,40 @@
+package policy
+
+import "testing"
+
+func TestFingerprintFollowsEffectiveOptions(t *testing.T) {
+	t.Parallel()
+
+	mustNew := func(opts Options) string {
+		t.Helper()
+		p, err := New(opts)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		return p.Fingerprint()
+	}
+
+	base := mustNew(Options{})
+	if len(base) != 12 {
+		t.Fatalf("unexpected fingerprint %q", base)
+	}
+	if got := NewDefault().Fingerprint(); got != base {
+		t.Fatalf("explicit defaults should match empty options: %s != %s", got, base)
+	}
+	if got := mustNew(Options{DenylistPatterns: []string{" vault read* ", ""}}); got != mustNew(Options{DenylistPatterns: []string{"vault read*"}}) {
+		t.Fatalf("whitespace and empty entries should not change the fingerprint")
+	}
+
+	for name, opts := range map[string]Options{
+		"enforce":  {EnforceDenylist: true},
+		"denylist": {DenylistPatterns: []string{"vault read*"}},
+		"keywords": {RedactionKeywords: []string{"db_pass"}},
+		"rule":     {RedactionRules: []RedactionRule{{Name: "acme", Pattern: `acme_[a-z]+`}}},
+		"detector": {DisabledDetectors: []string{"high_entropy"}},
+		"guard":    {GuardPatterns: []string{"aws s3 rb *"}},
+	} {
+		if got := mustNew(opts); got == base {
+			t.Errorf("%s: fingerprint did not change", name)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package policy
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/shellwords"
+)
+
+// RuleGuard is the kind of a guard pattern from config.yaml.
+const RuleGuard = "guard"
+
+// GuardMatch names the dangerous-command rule a command matched: a built-in
+// rule by name, or a guard pattern as written in config.yaml.
+type GuardMatch struct {
+	Kind string `json:"kind"`
+	Rule string `json:"rule"`
+}
+
+type guardRule struct {
+	name  string
+	match func(args []string) bool
+}
+
+// builtinGuardRules catch commands that destroy data or infrastructure. They
+// only take effect in sessions whose env profile is protected.
+var builtinGuardRules = []guardRule{
+	{name: "rm_recursive_root", match: isRecursiveRootRemove},
+	{name: "kubectl_delete_namespace", match: isKubectlDeleteNamespace},
+	{name: "terraform_destroy", match: isTerraformDestroy},
+	{name: "sql_drop_database", match: isSQLDropDatabase},
+	{name: "helm_uninstall", match: isHelmUninstall},
+}
+
+// IsGuardRule reports whether name is a built-in guard rule.
+func IsGuardRule(name string) bool {
+	for _, rule := range builtinGuardRules {
+		if rule.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GuardRuleNames lists the built-in guard rules in evaluation order.
+func GuardRuleNames() []string {
+	names := make([]string, 0, len(builtinGuardRules))
+	for _, rule := range builtinGuardRules {
+		names = append(names, rule.name)
+	}
+	return names
+}
+
+func enabledGuardRules(disabled []string) []guardRule {
+	off := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		off[strings.TrimSpace(name)] = true
+	}
+	rules := make([]guardRule, 0, len(builtinGuardRules))
+	for _, rule := range builtinGuardRules {
+		if !off[rule.name] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Dangerous reports the guard rule a command matches. args is the exact
+// argument vector when the caller has one; when nil, rawCommand is parsed as
+// a POSIX shell line.
+func (p *Policy) Dangerous(rawCommand string, args []string) (GuardMatch, bool) {
+	if args == nil {
+		return p.DangerousLine(rawCommand, shellwords.POSIX)
+	}
+	return p.guardMatch(rawCommand, expandCommand(args, 0))
+}
+
+// DangerousLine is Dangerous for a command line typed into a shell.
+func (p *Policy) DangerousLine(line string, dialect shellwords.Dialect) (GuardMatch, bool) {
+	return p.guardMatch(line, splitCommandLine(line, dialect, 0))
+}
+
+func (p *Policy) guardMatch(rawCommand string, commands [][]string) (GuardMatch, bool) {
+	for _, args := range commands {
+		for _, rule := range p.guardRules {
+			if rule.match(args) {
+				return GuardMatch{Kind: RuleBuiltin, Rule: rule.name}, true
+			}
+		}
+	}
+	for _, rule := range p.guard {
+		if rule.re.MatchString(rawCommand) {
+			return GuardMatch{Kind: RuleGuard, Rule: rule.pattern}, true
+		}
+		for _, args := range commands {
+			if rule.re.MatchString(strings.Join(args, " ")) {
+				return GuardMatch{Kind: RuleGuard, Rule: rule.pattern}, true
+			}
+		}
+	}
+	return GuardMatch{}, false
+}
+
+// rootTargets are rm operands that wipe a whole filesystem or home directory.
+var rootTargets = map[string]bool{
+	"/": true, "/*": true, "/.": true,
+	"~": true, "~/": true, "~/*": true,
+	"$HOME": true, "$HOME/": true, "$HOME/*": true, "${HOME}": true, "${HOME}/": true, "${HOME}/*": true,
+}
+
+func isRecursiveRootRemove(args []string) bool {
+	if shellwords.Program(args[0]) != "rm" {
+		return false
+	}
+	recursive, root := false, false
+	for _, arg := range args[1:] {
+		switch {
+		case arg == "--recursive":
+			recursive = true
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--"):
+			recursive = recursive || strings.ContainsAny(arg, "rR")
+		case rootTargets[arg]:
+			root = true
+		}
+	}
+	return recursive && root
+}
+
+// kubectlValueFlags are kubectl flags whose value is the next argument and
+// may name a namespace, context or file rather than a resource.
+var kubectlValueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--cluster": true, "--user": true,
+	"--kubeconfig": true, "-s": true, "--server": true, "-f": true, "--filename": true,
+	"-l": true, "--selector": true, "-o": true, "--output": true,
+}
+
+func isKubectlDeleteNamespace(args []string) bool {
+	if shellwords.Program(args[0]) != "kubectl" {
+		return false
+	}
+	deleting := false
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if kubectlValueFlags[arg] {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if !deleting {
+			deleting = strings.EqualFold(arg, "delete")
+			continue
+		}
+		kind, _, _ := strings.Cut(strings.ToLower(arg), "/")
+		return kind == "ns" || kind == "namespace" || kind == "namespaces"
+	}
+	return false
+}
+
+func isTerraformDestroy(args []string) bool {
+	if program := shellwords.Program(args[0]); program != "terraform" && program != "tofu" {
+		return false
+	}
+	apply := false
+	for _, arg := range args[1:] {
+		switch arg {
+		case "destroy":
+			return true
+		case "apply":
+			apply = true
+		case "-destroy", "--destroy":
+			if apply {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var sqlDropDatabase = regexp.MustCompile(`(?i)\bdrop\s+(database|schema)\b`)
+
+func isSQLDropDatabase(args []string) bool {
+	return sqlDropDatabase.MatchString(strings.Join(args, " "))
+}
+
+// helmValueFlags are the global helm flags whose value may precede the
+// subcommand.
+var helmValueFlags = map[string]bool{"-n": true, "--namespace": true, "--kube-context": true, "--kubeconfig": true}
+
+func isHelmUninstall(args []string) bool {
+	if shellwords.Program(args[0]) != "helm" {
+		return false
+	}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if helmValueFlags[arg] {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		switch strings.ToLower(arg) {
+		case "uninstall", "delete", "del", "un":
+			return true
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/fixi2/Commandry/internal/shellwords"
+)
+
+func TestDangerousMatchesBuiltinRules(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tests := []struct {
+		line string
+		rule string
+	}{
+		{line: "rm -rf /", rule: "rm_recursive_root"},
+		{line: "sudo rm -r -f /*", rule: "rm_recursive_root"},
+		{line: "rm --recursive --force ~", rule: "rm_recursive_root"},
+		{line: "kubectl --context prod delete ns payments", rule: "kubectl_delete_namespace"},
+		{line: "kubectl delete namespace/payments", rule: "kubectl_delete_namespace"},
+		{line: "kubectl delete -n kube-system ns foo", rule: "kubectl_delete_namespace"},
+		{line: "kubectl --as admin delete --context prod namespace foo", rule: "kubectl_delete_namespace"},
+		{line: "terraform -chdir=infra destroy", rule: "terraform_destroy"},
+		{line: "tofu apply -destroy -auto-approve", rule: "terraform_destroy"},
+		{line: `psql -c "DROP DATABASE orders"`, rule: "sql_drop_database"},
+		{line: "echo 'drop schema app cascade' | mysql", rule: "sql_drop_database"},
+		{line: "helm -n web uninstall api", rule: "helm_uninstall"},
+		{line: "cd infra && terraform destroy", rule: "terraform_destroy"},
+	}
+	for _, tt := range tests {
+		got, ok := p.DangerousLine(tt.line, shellwords.POSIX)
+		if !ok || got.Kind != RuleBuiltin || got.Rule != tt.rule {
+			t.Errorf("DangerousLine(%q) = %+v, %v; want %s", tt.line, got, ok, tt.rule)
+		}
+	}
+
+	for _, line := range []string{
+		"rm -rf ./build",
+		"rm -f /tmp/x",
+		"kubectl delete pod api-0",
+		"kubectl delete -n ns pod api-0",
+		"kubectl -n delete get pods",
+		"kubectl get ns",
+		"terraform plan -destroy",
+		"psql -c 'select 1'",
+		"helm upgrade api ./chart",
+		"echo terraform destroy",
+	} {
+		if got, ok := p.DangerousLine(line, shellwords.POSIX); ok {
+			t.Errorf("DangerousLine(%q) matched %+v", line, got)
+		}
+	}
+}
+
+func TestDangerousPatternsAndDisabledRules(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Options{
+		GuardPatterns:  []string{"aws s3 rb *"},
+		DisabledGuards: []string{"helm_uninstall"},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	got, ok := p.Dangerous("aws s3 rb s3://logs --force", []string{"aws", "s3", "rb", "s3://logs", "--force"})
+	if !ok || got.Kind != RuleGuard || got.Rule != "aws s3 rb *" {
+		t.Fatalf("guard pattern did not match: %+v, %v", got, ok)
+	}
+	if got, ok := p.Dangerous("helm uninstall api", nil); ok {
+		t.Fatalf("disabled rule still matched: %+v", got)
+	}
+}
@@ -0,0 +1,320 @@
+package policy
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/fixi2/Commandry/internal/config"
+)
+
+// Policy layers, lowest precedence first.
+const (
+	LayerBuiltin = "builtin"
+	LayerSystem  = "system"
+	LayerUser    = "user"
+	LayerProject = "project"
+)
+
+// Layer is one policy source. Layers are merged in the order given; a layer
+// that sets a key replaces the value from the layers below it unless one of
+// them locked the key. Locked lists can still be extended. The project layer
+// comes from whichever repository the command runs in, so it is treated as if
+// every key were locked: it can add rules and turn checks on, never remove
+// or turn them off.
+type Layer struct {
+	Name   string
+	Path   string
+	Config config.Config
+}
+
+// Origin tells which layer a merged value came from.
+type Origin struct {
+	Layer string `json:"layer"`
+	Path  string `json:"path,omitempty"`
+	// Locked is set when a layer at or above this one locked the key.
+	Locked bool `json:"locked,omitempty"`
+
+	rank int
+}
+
+type Entry struct {
+	Value string `json:"value"`
+	Origin
+}
+
+type RuleEntry struct {
+	config.RedactionRule
+	Origin
+}
+
+// ToggleEntry is a built-in detector or guard rule switched on or off.
+type ToggleEntry struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Origin
+}
+
+type ProfileEntry struct {
+	Name string `json:"name"`
+	Origin
+}
+
+// LayerSource describes a layer that took part in a merge.
+type LayerSource struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"`
+}
+
+// Merged is the effective policy section built from all layers, with the
+// origin of every value.
+type Merged struct {
+	Layers            []LayerSource  `json:"layers"`
+	EnforceDenylist   bool           `json:"enforce_denylist"`
+	EnforceOrigin     Origin         `json:"enforce_denylist_origin"`
+	Denylist          []Entry        `json:"denylist"`
+	RedactionKeywords []Entry        `json:"redaction_keywords"`
+	RedactionRules    []RuleEntry    `json:"redaction_rules"`
+	Detectors         []ToggleEntry  `json:"detectors"`
+	GuardPatterns     []Entry        `json:"guard_patterns"`
+	GuardRules        []ToggleEntry  `json:"guard_rules"`
+	Profiles          []ProfileEntry `json:"profiles"`
+	// Locks maps each locked key to the layer that locked it.
+	Locks map[string]Origin `json:"locks"`
+
+	profiles map[string]config.PolicyProfile
+}
+
+// MergeLayers combines the built-in defaults with layers, given lowest
+// precedence first.
+func MergeLayers(layers []Layer) Merged {
+	builtin := Origin{Layer: LayerBuiltin}
+	m := Merged{
+		Layers:            []LayerSource{{Name: LayerBuiltin}},
+		EnforceOrigin:     builtin,
+		Denylist:          entries(defaultDenylistPatterns, builtin),
+		RedactionKeywords: entries(defaultRedactionKeywords, builtin),
+		RedactionRules:    []RuleEntry{},
+		Detectors:         []ToggleEntry{},
+		GuardPatterns:     []Entry{},
+		GuardRules:        []ToggleEntry{},
+		Profiles:          []ProfileEntry{},
+		Locks:             make(map[string]Origin),
+		profiles:          make(map[string]config.PolicyProfile),
+	}
+
+	for i, layer := range layers {
+		origin := Origin{Layer: layer.Name, Path: layer.Path, rank: i + 1}
+		pc := layer.Config.Policy
+		// The project policy comes with the repository, not from the user.
+		untrusted := layer.Name == LayerProject
+		m.Layers = append(m.Layers, LayerSource{Name: layer.Name, Path: layer.Path})
+
+		if len(pc.Denylist) > 0 {
+			m.Denylist = mergeEntries(m.Denylist, entries(pc.Denylist, origin), m.extendOnly("denylist", untrusted))
+		}
+		if len(pc.RedactionKeywords) > 0 {
+			m.RedactionKeywords = mergeEntries(m.RedactionKeywords, entries(pc.RedactionKeywords, origin), m.extendOnly("redaction_keywords", untrusted))
+		}
+		if len(pc.RedactionRules) > 0 {
+			rules := make([]RuleEntry, 0, len(pc.RedactionRules))
+			for _, rule := range pc.RedactionRules {
+				rules = append(rules, RuleEntry{RedactionRule: rule, Origin: origin})
+			}
+			if m.extendOnly("redaction_rules", untrusted) {
+				m.RedactionRules = append(m.RedactionRules, rules...)
+			} else {
+				m.RedactionRules = rules
+			}
+		}
+		if _, locked := m.Locks["detectors"]; !locked {
+			for _, name := range sortedNames(pc.Detectors) {
+				if enabled := pc.Detectors[name]; enabled || !untrusted {
+					m.Detectors = setToggle(m.Detectors, name, enabled, origin)
+				}
+			}
+		}
+		if len(pc.Guard.Patterns) > 0 {
+			m.GuardPatterns = mergeEntries(m.GuardPatterns, entries(pc.Guard.Patterns, origin), m.extendOnly("guard", untrusted))
+		}
+		if _, locked := m.Locks["guard"]; !locked {
+			for _, name := range sortedNames(pc.Guard.Rules) {
+				if enabled := pc.Guard.Rules[name]; enabled || !untrusted {
+					m.GuardRules = setToggle(m.GuardRules, name, enabled, origin)
+				}
+			}
+		}
+		if _, locked := m.Locks["enforce_denylist"]; !locked && layer.Config.Line("policy.enforce_denylist") > 0 && (pc.EnforceDenylist || !untrusted) {
+			m.EnforceDenylist = pc.EnforceDenylist
+			m.EnforceOrigin = origin
+		}
+		for _, name := range sortedNames(pc.Profiles) {
+			profile := pc.Profiles[name]
+			if untrusted && profile.EnforceDenylist != nil && !*profile.EnforceDenylist {
+				profile.EnforceDenylist = nil
+			}
+			m.setProfile(name, profile, origin, m.extendOnly("profiles", untrusted))
+		}
+
+		for _, key := range config.LockableKeys {
+			if _, locked := m.Locks[key]; !locked && pc.Locks(key) {
+				m.Locks[key] = origin
+			}
+		}
+	}
+
+	m.markLocked()
+	return m
+}
+
+func entries(values []string, origin Origin) []Entry {
+	out := make([]Entry, 0, len(values))
+	for _, value := range values {
+		out = append(out, Entry{Value: value, Origin: origin})
+	}
+	return out
+}
+
+// extendOnly reports whether a layer may only add to key: because a layer
+// below locked it, or because the layer is untrusted.
+func (m *Merged) extendOnly(key string, untrusted bool) bool {
+	_, locked := m.Locks[key]
+	return locked || untrusted
+}
+
+// mergeEntries replaces current with next, or appends the new values of next
+// when extend is set.
+func mergeEntries(current, next []Entry, extend bool) []Entry {
+	if !extend {
+		return next
+	}
+	seen := make(map[string]bool, len(current))
+	for _, entry := range current {
+		seen[entry.Value] = true
+	}
+	for _, entry := range next {
+		if !seen[entry.Value] {
+			seen[entry.Value] = true
+			current = append(current, entry)
+		}
+	}
+	return current
+}
+
+func setToggle(toggles []ToggleEntry, name string, enabled bool, origin Origin) []ToggleEntry {
+	for i := range toggles {
+		if toggles[i].Name == name {
+			toggles[i] = ToggleEntry{Name: name, Enabled: enabled, Origin: origin}
+			return toggles
+		}
+	}
+	return append(toggles, ToggleEntry{Name: name, Enabled: enabled, Origin: origin})
+}
+
+// setProfile defines or replaces a profile. With extend, an existing profile
+// can only be extended: the layer may add denylist and redaction entries or
+// turn protected on, and its other settings stay. Names match
+// case-insensitively, as environment labels do.
+func (m *Merged) setProfile(name string, profile config.PolicyProfile, origin Origin, extend bool) {
+	if extend {
+		for existing, current := range m.profiles {
+			if !strings.EqualFold(existing, name) {
+				continue
+			}
+			current.Denylist = appendNew(current.Denylist, profile.Denylist)
+			current.RedactionKeywords = appendNew(current.RedactionKeywords, profile.RedactionKeywords)
+			current.Protected = current.Protected || profile.Protected
+			m.profiles[existing] = current
+			return
+		}
+	}
+	m.profiles[name] = profile
+	for i := range m.Profiles {
+		if m.Profiles[i].Name == name {
+			m.Profiles[i].Origin = origin
+			return
+		}
+	}
+	m.Profiles = append(m.Profiles, ProfileEntry{Name: name, Origin: origin})
+}
+
+// markLocked flags values that come from a layer at or below the one that
+// locked their key, since no layer above can remove them.
+func (m *Merged) markLocked() {
+	locked := func(key string, origin Origin) bool {
+		lock, ok := m.Locks[key]
+		return ok && origin.rank <= lock.rank
+	}
+	for i := range m.Denylist {
+		m.Denylist[i].Locked = locked("denylist", m.Denylist[i].Origin)
+	}
+	for i := range m.RedactionKeywords {
+		m.RedactionKeywords[i].Locked = locked("redaction_keywords", m.RedactionKeywords[i].Origin)
+	}
+	for i := range m.RedactionRules {
+		m.RedactionRules[i].Locked = locked("redaction_rules", m.RedactionRules[i].Origin)
+	}
+	for i := range m.Detectors {
+		m.Detectors[i].Locked = locked("detectors", m.Detectors[i].Origin)
+	}
+	for i := range m.GuardPatterns {
+		m.GuardPatterns[i].Locked = locked("guard", m.GuardPatterns[i].Origin)
+	}
+	for i := range m.GuardRules {
+		m.GuardRules[i].Locked = locked("guard", m.GuardRules[i].Origin)
+	}
+	for i := range m.Profiles {
+		m.Profiles[i].Locked = locked("profiles", m.Profiles[i].Origin)
+	}
+	m.EnforceOrigin.Locked = locked("enforce_denylist", m.EnforceOrigin)
+}
+
+// Config flattens the merged values into a policy section. Locked keys are
+// listed in Lock so profiles cannot override them either.
+func (m Merged) Config() config.PolicyConfig {
+	pc := config.PolicyConfig{EnforceDenylist: m.EnforceDenylist}
+	for _, entry := range m.Denylist {
+		pc.Denylist = append(pc.Denylist, entry.Value)
+	}
+	for _, entry := range m.RedactionKeywords {
+		pc.RedactionKeywords = append(pc.RedactionKeywords, entry.Value)
+	}
+	for _, entry := range m.RedactionRules {
+		pc.RedactionRules = append(pc.RedactionRules, entry.RedactionRule)
+	}
+	if len(m.Detectors) > 0 {
+		pc.Detectors = make(map[string]bool, len(m.Detectors))
+		for _, entry := range m.Detectors {
+			pc.Detectors[entry.Name] = entry.Enabled
+		}
+	}
+	for _, entry := range m.GuardPatterns {
+		pc.Guard.Patterns = append(pc.Guard.Patterns, entry.Value)
+	}
+	if len(m.GuardRules) > 0 {
+		pc.Guard.Rules = make(map[string]bool, len(m.GuardRules))
+		for _, entry := range m.GuardRules {
+			pc.Guard.Rules[entry.Name] = entry.Enabled
+		}
+	}
+	if len(m.profiles) > 0 {
+		pc.Profiles = make(map[string]config.PolicyProfile, len(m.profiles))
+		for name, profile := range m.profiles {
+			pc.Profiles[name] = profile
+		}
+	}
+	for _, key := range config.LockableKeys {
+		if _, ok := m.Locks[key]; ok {
+			pc.Lock = append(pc.Lock, key)
+		}
+	}
+	return pc
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,234 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fixi2/Commandry/internal/config"
+	"github.com/fixi2/Commandry/internal/shellwords"
+)
+
+func mustLayer(t *testing.T, name string, lines ...string) Layer {
+	t.Helper()
+	cfg, _, err := config.Parse([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatalf("parse %s layer: %v", name, err)
+	}
+	return Layer{Name: name, Path: name + ".yaml", Config: cfg}
+}
+
+func TestMergeLayersPrecedenceAndLocks(t *testing.T) {
+	t.Parallel()
+
+	system := mustLayer(t, LayerSystem,
+		"policy:",
+		"  denylist: [\"terraform destroy*\"]",
+		"  enforce_denylist: true",
+		"  detectors: {high_entropy: true}",
+		"  lock: [denylist, enforce_denylist, detectors]",
+	)
+	user := mustLayer(t, LayerUser,
+		"policy:",
+		"  denylist: [printenv]",
+		"  redaction_keywords: [db_pass]",
+		"  enforce_denylist: false",
+		"  detectors: {high_entropy: false}",
+		"  profiles:",
+		"    prod:",
+		"      enforce_denylist: false",
+	)
+	project := mustLayer(t, LayerProject,
+		"policy:",
+		"  redaction_keywords: [api_key]",
+	)
+
+	m := MergeLayers([]Layer{system, user, project})
+	if !m.EnforceDenylist || m.EnforceOrigin.Layer != LayerSystem || !m.EnforceOrigin.Locked {
+		t.Fatalf("locked enforce_denylist was overridden: %v %+v", m.EnforceDenylist, m.EnforceOrigin)
+	}
+	if len(m.Denylist) != 2 || m.Denylist[0].Value != "terraform destroy*" || !m.Denylist[0].Locked || m.Denylist[1].Value != "printenv" || m.Denylist[1].Locked {
+		t.Fatalf("locked denylist should be extended, not replaced: %+v", m.Denylist)
+	}
+	if len(m.RedactionKeywords) != 2 || m.RedactionKeywords[0].Value != "db_pass" || m.RedactionKeywords[1].Value != "api_key" || m.RedactionKeywords[1].Layer != LayerProject {
+		t.Fatalf("project keywords should extend user keywords: %+v", m.RedactionKeywords)
+	}
+	if len(m.Detectors) != 1 || !m.Detectors[0].Enabled || m.Detectors[0].Layer != LayerSystem {
+		t.Fatalf("locked detectors were changed: %+v", m.Detectors)
+	}
+	if m.Locks["denylist"].Layer != LayerSystem || len(m.Layers) != 4 {
+		t.Fatalf("unexpected locks or layers: %+v %+v", m.Locks, m.Layers)
+	}
+
+	prod, err := ResolveProfile(config.Config{Policy: m.Config()}, "prod")
+	if err != nil {
+		t.Fatalf("ResolveProfile failed: %v", err)
+	}
+	if !prod.Policy.EnforceDenylist() {
+		t.Fatalf("a profile must not turn off a locked enforce_denylist")
+	}
+	if got := prod.Policy.ApplyLine("terraform destroy -auto-approve", shellwords.POSIX); !got.Denied {
+		t.Fatalf("system deny entry was lost: %+v", got)
+	}
+}
+
+func TestMergeLayersWithoutFilesUsesDefaults(t *testing.T) {
+	t.Parallel()
+
+	m := MergeLayers([]Layer{mustLayer(t, LayerUser, "capture:", "  record_cwd: true")})
+	if len(m.Denylist) != len(defaultDenylistPatterns) || m.Denylist[0].Layer != LayerBuiltin || m.EnforceDenylist {
+		t.Fatalf("expected built-in defaults: %+v", m)
+	}
+	pc := m.Config()
+	if len(pc.Lock) != 0 || len(pc.RedactionKeywords) != len(defaultRedactionKeywords) {
+		t.Fatalf("unexpected flattened config: %+v", pc)
+	}
+}
+
+func TestMergeLayersLockedGuardCanOnlyBeExtended(t *testing.T) {
+	t.Parallel()
+
+	system := mustLayer(t, LayerSystem,
+		"policy:",
+		"  guard:",
+		"    patterns: [\"aws s3 rb *\"]",
+		"    rules: {helm_uninstall: true}",
+		"  lock: [guard]",
+	)
+	user := mustLayer(t, LayerUser,
+		"policy:",
+		"  guard:",
+		"    patterns: [\"gcloud projects delete *\"]",
+		"    rules: {helm_uninstall: false}",
+	)
+
+	m := MergeLayers([]Layer{system, user})
+	if len(m.GuardPatterns) != 2 || !m.GuardPatterns[0].Locked || m.GuardPatterns[1].Layer != LayerUser {
+		t.Fatalf("locked guard patterns should be extended: %+v", m.GuardPatterns)
+	}
+	if len(m.GuardRules) != 1 || !m.GuardRules[0].Enabled || !m.GuardRules[0].Locked {
+		t.Fatalf("locked guard rules were changed: %+v", m.GuardRules)
+	}
+
+	p, err := NewFromConfig(m.Config())
+	if err != nil {
+		t.Fatalf("NewFromConfig failed: %v", err)
+	}
+	for _, line := range []string{"helm uninstall api", "aws s3 rb s3://logs", "gcloud projects delete demo"} {
+		if _, ok := p.DangerousLine(line, shellwords.POSIX); !ok {
+			t.Fatalf("merged guard missed %q", line)
+		}
+	}
+}
+
+func TestMergeLayersLockedProfilesCanOnlyBeExtended(t *testing.T) {
+	t.Parallel()
+
+	system := mustLayer(t, LayerSystem,
+		"policy:",
+		"  profiles:",
+		"    prod:",
+		"      protected: true",
+		"      enforce_denylist: true",
+		"      denylist: [\"kubectl delete *\"]",
+		"  lock: [profiles]",
+	)
+	user := mustLayer(t, LayerUser,
+		"policy:",
+		"  profiles:",
+		"    prod: {}",
+		"    PROD:",
+		"      enforce_denylist: false",
+		"      denylist: [\"helm rollback *\"]",
+		"    dev:",
+		"      record_cwd: false",
+	)
+
+	m := MergeLayers([]Layer{system, user})
+	if len(m.Profiles) != 2 || m.Profiles[0].Name != "prod" || m.Profiles[0].Layer != LayerSystem || !m.Profiles[0].Locked || m.Profiles[1].Name != "dev" || m.Profiles[1].Locked {
+		t.Fatalf("unexpected profiles: %+v", m.Profiles)
+	}
+
+	cfg := config.Config{Policy: m.Config()}
+	for _, env := range []string{"prod", "PROD"} {
+		prod, err := ResolveProfile(cfg, env)
+		if err != nil {
+			t.Fatalf("ResolveProfile(%s) failed: %v", env, err)
+		}
+		if !prod.Protected || !prod.Policy.EnforceDenylist() {
+			t.Fatalf("user layer weakened the locked prod profile for %s: %+v", env, prod)
+		}
+		for _, line := range []string{"kubectl delete pod api", "helm rollback api 3"} {
+			if got := prod.Policy.ApplyLine(line, shellwords.POSIX); !got.Denied {
+				t.Fatalf("%s: expected %q to be denied: %+v", env, line, got)
+			}
+		}
+	}
+}
+
+func TestMergeLayersProjectCanOnlyTightenThePolicy(t *testing.T) {
+	t.Parallel()
+
+	user := mustLayer(t, LayerUser,
+		"policy:",
+		"  denylist: [printenv]",
+		"  redaction_keywords: [db_pass]",
+		"  redaction_rules:",
+		"    - name: acme",
+		"      pattern: 'acme_[a-z0-9]{8}'",
+		"  enforce_denylist: true",
+		"  guard:",
+		"    rules: {helm_uninstall: true}",
+		"  profiles:",
+		"    prod:",
+		"      protected: true",
+		"      denylist: [\"kubectl delete *\"]",
+	)
+	hostile := mustLayer(t, LayerProject,
+		"policy:",
+		"  denylist: [\"harmless*\"]",
+		"  redaction_keywords: [nothing]",
+		"  redaction_rules:",
+		"    - name: ticket",
+		"      pattern: 'TCK-[0-9]+'",
+		"  enforce_denylist: false",
+		"  detectors: {high_entropy: false, jwt: true}",
+		"  guard:",
+		"    rules: {helm_uninstall: false, terraform_destroy: true}",
+		"  profiles:",
+		"    prod: {protected: false, enforce_denylist: false, denylist: []}",
+		"    staging: {enforce_denylist: false}",
+	)
+
+	m := MergeLayers([]Layer{user, hostile})
+	if !m.EnforceDenylist || m.EnforceOrigin.Layer != LayerUser {
+		t.Fatalf("project turned off enforce_denylist: %+v", m.EnforceOrigin)
+	}
+	if len(m.Denylist) != 2 || m.Denylist[0].Value != "printenv" || len(m.RedactionKeywords) != 2 || m.RedactionKeywords[0].Value != "db_pass" {
+		t.Fatalf("project replaced user lists: %+v %+v", m.Denylist, m.RedactionKeywords)
+	}
+	if len(m.RedactionRules) != 2 || m.RedactionRules[0].Name != "acme" {
+		t.Fatalf("project replaced user redaction rules: %+v", m.RedactionRules)
+	}
+	if len(m.Detectors) != 1 || m.Detectors[0].Name != "jwt" || !m.Detectors[0].Enabled {
+		t.Fatalf("project may only turn detectors on: %+v", m.Detectors)
+	}
+	if len(m.GuardRules) != 2 || !m.GuardRules[0].Enabled || m.GuardRules[0].Layer != LayerUser || !m.GuardRules[1].Enabled {
+		t.Fatalf("project may only turn guard rules on: %+v", m.GuardRules)
+	}
+
+	cfg := config.Config{Policy: m.Config()}
+	prod, err := ResolveProfile(cfg, "prod")
+	if err != nil {
+		t.Fatalf("ResolveProfile failed: %v", err)
+	}
+	if !prod.Protected || !prod.Policy.EnforceDenylist() || !prod.Policy.ApplyLine("kubectl delete ns app", shellwords.POSIX).Denied {
+		t.Fatalf("project weakened the prod profile: %+v", prod)
+	}
+	staging, err := ResolveProfile(cfg, "staging")
+	if err != nil {
+		t.Fatalf("ResolveProfile failed: %v", err)
+	}
+	if staging.Name != "staging" || !staging.Policy.EnforceDenylist() {
+		t.Fatalf("a project profile must not turn off enforce_denylist: %+v", staging)
+	}
+}
@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lint severities.
+const (
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Lint checks.
+const (
+	CheckBroadPattern = "broad_pattern"
+	CheckDuplicate    = "duplicate"
+	CheckShadowed     = "shadowed"
+	CheckNoisyKeyword = "noisy_keyword"
+)
+
+// lintExampleLimit caps the example commands attached to a finding.
+const lintExampleLimit = 3
+
+// keywordRedactorNames are the built-in redactors generated from
+// redaction_keywords; the others do not depend on configuration.
+var keywordRedactorNames = map[string]bool{
+	"keyword_flag_equals": true,
+	"keyword_flag_value":  true,
+	"keyword_assignment":  true,
+}
+
+// Finding is one problem reported by Lint. Subject names the config entry,
+// e.g. `policy.denylist "env"`.
+type Finding struct {
+	Severity string   `json:"severity"`
+	Check    string   `json:"check"`
+	Subject  string   `json:"subject"`
+	Message  string   `json:"message"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+// LintReport is the result of Lint.
+type LintReport struct {
+	CorpusSize  int       `json:"corpus_size"`
+	HistorySize int       `json:"history_size"`
+	Findings    []Finding `json:"findings"`
+}
+
+// Warnings counts the findings with SeverityWarning.
+func (r LintReport) Warnings() int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == SeverityWarning {
+			n++
+		}
+	}
+	return n
+}
+
+// Lint evaluates the denylist and redaction keywords of cfg against a
+// built-in corpus of harmless DevOps commands and the caller's recorded
+// history. Denied history entries should be left out by the caller since
+// they were already replaced by DeniedPlaceholder.
+func Lint(cfg Config, history []string) LintReport {
+	report := LintReport{
+		CorpusSize:  len(lintCorpus),
+		HistorySize: len(history),
+		Findings:    []Finding{},
+	}
+	report.Findings = append(report.Findings, lintDenylist(cfg.Denylist, history)...)
+	report.Findings = append(report.Findings, lintKeywords(cfg.RedactionKeywords, history)...)
+	return report
+}
+
+func lintDenylist(patterns []string, history []string) []Finding {
+	var findings []Finding
+	rules := make([]denyRule, 0, len(patterns))
+	seen := make(map[string]bool, len(patterns))
+	for _, raw := range patterns {
+		pattern := strings.TrimSpace(raw)
+		if pattern == "" {
+			continue
+		}
+		subject := fmt.Sprintf("policy.denylist %q", pattern)
+		key := strings.ToLower(pattern)
+		if seen[key] {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Check:    CheckDuplicate,
+				Subject:  subject,
+				Message:  "listed more than once",
+			})
+			continue
+		}
+		seen[key] = true
+		re, err := compileDenyPattern(pattern)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, denyRule{pattern: pattern, re: re})
+	}
+
+	for i, rule := range rules {
+		subject := fmt.Sprintf("policy.denylist %q", rule.pattern)
+		if shadow := shadowingRule(rules, i); shadow != "" {
+			findings = append(findings, Finding{
+				Severity: SeverityInfo,
+				Check:    CheckShadowed,
+				Subject:  subject,
+				Message:  "already denied by " + shadow + "; the entry is redundant",
+			})
+		}
+
+		corpusHits := filterCommands(lintCorpus, rule.re.MatchString)
+		historyHits := filterCommands(history, rule.re.MatchString)
+		if len(corpusHits) == 0 && len(historyHits) == 0 {
+			continue
+		}
+		finding := Finding{
+			Severity: SeverityWarning,
+			Check:    CheckBroadPattern,
+			Subject:  subject,
+			Examples: exampleCommands(corpusHits, historyHits),
+		}
+		switch {
+		case len(corpusHits) == 0:
+			finding.Severity = SeverityInfo
+			finding.Message = fmt.Sprintf("would deny %d recorded command(s)", len(historyHits))
+		case len(historyHits) == 0:
+			finding.Message = fmt.Sprintf("matches %d harmless command(s) anywhere in the command line", len(corpusHits))
+		default:
+			finding.Message = fmt.Sprintf("matches %d harmless command(s) and %d recorded command(s) anywhere in the command line", len(corpusHits), len(historyHits))
+		}
+		if 2*len(corpusHits) > len(lintCorpus) {
+			finding.Message += "; it denies almost everything"
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// shadowingRule returns the built-in rule or earlier denylist entry that
+// already denies the literal form of rules[i], or "".
+func shadowingRule(rules []denyRule, i int) string {
+	probe := strings.TrimSpace(strings.ReplaceAll(rules[i].pattern, "*", " "))
+	args := strings.Fields(probe)
+	if len(args) > 0 {
+		for _, builtin := range builtinDenyRules {
+			if builtin.match(args) {
+				return "built-in rule " + builtin.name
+			}
+		}
+	}
+	for j, other := range rules {
+		if j == i || other.pattern == rules[i].pattern {
+			continue
+		}
+		// Entries that match each other are reported on the later one only.
+		if other.re.MatchString(probe) && (j < i || !rules[i].re.MatchString(strings.ReplaceAll(other.pattern, "*", " "))) {
+			return fmt.Sprintf("denylist entry %q", other.pattern)
+		}
+	}
+	return ""
+}
+
+func lintKeywords(keywords []string, history []string) []Finding {
+	var findings []Finding
+	seen := make(map[string]bool, len(keywords))
+	for _, raw := range keywords {
+		keyword := strings.ToLower(strings.TrimSpace(raw))
+		if keyword == "" {
+			continue
+		}
+		subject := fmt.Sprintf("policy.redaction_keywords %q", keyword)
+		if seen[keyword] {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Check:    CheckDuplicate,
+				Subject:  subject,
+				Message:  "listed more than once",
+			})
+			continue
+		}
+		seen[keyword] = true
+
+		var redactors []redactor
+		for _, r := range buildRedactors([]string{keyword}) {
+			if !keywordRedactorNames[r.name] {
+				continue
+			}
+			// authorization and bearer are excluded from key=value matching,
+			// which leaves that redactor with the fallback keyword.
+			if r.name == "keyword_assignment" && (keyword == "authorization" || keyword == "bearer") {
+				continue
+			}
+			redactors = append(redactors, r)
+		}
+		changes := func(command string) bool {
+			for _, r := range redactors {
+				if r.re.MatchString(command) {
+					return true
+				}
+			}
+			return false
+		}
+
+		corpusHits := filterCommands(lintCorpus, changes)
+		if len(corpusHits) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Check:    CheckNoisyKeyword,
+			Subject:  subject,
+			Message:  fmt.Sprintf("redacts values in %d harmless command(s)", len(corpusHits)),
+			Examples: exampleCommands(corpusHits, filterCommands(history, changes)),
+		})
+	}
+	return findings
+}
+
+func filterCommands(commands []string, match func(string) bool) []string {
+	var out []string
+	for _, command := range commands {
+		if command != DeniedPlaceholder && match(command) {
+			out = append(out, command)
+		}
+	}
+	return out
+}
+
+func exampleCommands(groups ...[]string) []string {
+	var out []string
+	for _, group := range groups {
+		for _, command := range group {
+			if len(out) == lintExampleLimit {
+				return out
+			}
+			out = append(out, command)
+		}
+	}
+	return out
+}
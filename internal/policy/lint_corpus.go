@@ -0,0 +1,68 @@
+package policy
+
+// lintCorpus is a set of everyday DevOps commands that carry no secrets. A
+// denylist entry or keyword that touches one of them is probably too broad.
+var lintCorpus = []string{
+	"git status",
+	"git log --oneline -n 20",
+	"git checkout -b feature/environment-config",
+	"git push origin main",
+	"git rebase -i HEAD~3",
+	"ls -la",
+	"cd infra/environments/staging",
+	"cat README.md",
+	"grep -rn TODO src",
+	"tail -f /var/log/nginx/access.log",
+	"python3 -m venv .venv",
+	"pip install -r requirements.txt",
+	"envsubst < deploy.tmpl.yaml > deploy.yaml",
+	"make build",
+	"go test ./...",
+	"npm ci",
+	"npm run build",
+	"docker build -t app:latest .",
+	"docker run -d -p 8080:80 nginx:1.25",
+	"docker compose up -d",
+	"docker ps -a",
+	"docker logs -f api",
+	"docker image prune -f",
+	"kubectl get pods -n payments",
+	"kubectl get deployments -n prod --show-labels",
+	"kubectl describe pod api-7f9c6d5b4-x2k8q",
+	"kubectl logs deploy/api -n prod --since 1h",
+	"kubectl rollout status deployment/api",
+	"kubectl rollout restart deployment/api -n prod",
+	"kubectl apply -f k8s/",
+	"kubectl label nodes worker-1 disktype=ssd",
+	"kubectl taint nodes worker-1 key=value:NoSchedule",
+	"kubectl get secrets -n prod",
+	"kubectl describe secret db-credentials",
+	"kubectl set image deployment/api api=ghcr.io/acme/api:1.4.2",
+	"kubectl config use-context staging",
+	"kubectl scale deployment/api --replicas 3",
+	"helm repo update",
+	"helm upgrade --install api ./charts/api --set image.tag=1.4.2",
+	"helm list -A",
+	"terraform init",
+	"terraform plan -out tfplan",
+	"terraform apply tfplan",
+	"terraform state list",
+	"terraform workspace select staging",
+	"ansible-playbook -i inventory/prod site.yml --check",
+	"aws s3 ls s3://acme-artifacts",
+	"aws ecs update-service --cluster prod --service api --force-new-deployment",
+	"aws sts get-caller-identity",
+	"gcloud config set project acme-staging",
+	"az account show",
+	"ssh-keyscan github.com",
+	"ssh deploy@bastion.example.com",
+	"scp build.tar.gz deploy@web-1:/tmp/",
+	"curl -fsS https://api.example.com/healthz",
+	"curl -X POST https://hooks.example.com/deploy -d env=staging",
+	"systemctl restart nginx",
+	"journalctl -u api --since today",
+	"openssl x509 -in server.crt -noout -dates",
+	"vault status",
+	"psql -h db.internal -U app -c 'select 1'",
+	"redis-cli -h cache.internal ping",
+}
@@ -0,0 +1,86 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func findFinding(findings []Finding, check, subject string) *Finding {
+	for i := range findings {
+		if findings[i].Check == check && findings[i].Subject == subject {
+			return &findings[i]
+		}
+	}
+	return nil
+}
+
+func TestLintDenylist(t *testing.T) {
+	t.Parallel()
+
+	report := Lint(Config{
+		Denylist: []string{"env", "vault read*", "kubectl get secret -o yaml", "terraform output*", "terraform output -json", "Vault Read*"},
+	}, []string{"vault read -field=password kv/db", "ls"})
+
+	env := findFinding(report.Findings, CheckBroadPattern, `policy.denylist "env"`)
+	if env == nil || env.Severity != SeverityWarning || len(env.Examples) == 0 {
+		t.Fatalf("expected env to be flagged as broad: %+v", report.Findings)
+	}
+	for _, example := range env.Examples {
+		if !strings.Contains(strings.ToLower(example), "env") {
+			t.Fatalf("example %q does not contain the pattern", example)
+		}
+	}
+	if f := findFinding(report.Findings, CheckShadowed, `policy.denylist "env"`); f == nil || !strings.Contains(f.Message, "isEnvDumpDenied") {
+		t.Fatalf("expected env to be shadowed by the built-in rule: %+v", report.Findings)
+	}
+	if f := findFinding(report.Findings, CheckShadowed, `policy.denylist "kubectl get secret -o yaml"`); f == nil || !strings.Contains(f.Message, "isKubectlSecretOutputDenied") {
+		t.Fatalf("expected kubectl entry to be shadowed: %+v", report.Findings)
+	}
+	if f := findFinding(report.Findings, CheckShadowed, `policy.denylist "terraform output -json"`); f == nil || !strings.Contains(f.Message, `"terraform output*"`) {
+		t.Fatalf("expected narrower entry to be shadowed by the broader one: %+v", report.Findings)
+	}
+	if f := findFinding(report.Findings, CheckShadowed, `policy.denylist "terraform output*"`); f != nil {
+		t.Fatalf("broader entry must not be reported as shadowed: %+v", f)
+	}
+	if f := findFinding(report.Findings, CheckDuplicate, `policy.denylist "Vault Read*"`); f == nil {
+		t.Fatalf("expected case-insensitive duplicate: %+v", report.Findings)
+	}
+	history := findFinding(report.Findings, CheckBroadPattern, `policy.denylist "vault read*"`)
+	if history == nil || history.Severity != SeverityInfo || history.Message != "would deny 1 recorded command(s)" {
+		t.Fatalf("expected history match: %+v", report.Findings)
+	}
+	if report.CorpusSize != len(lintCorpus) || report.HistorySize != 2 {
+		t.Fatalf("unexpected sizes: %+v", report)
+	}
+}
+
+func TestLintKeywords(t *testing.T) {
+	t.Parallel()
+
+	report := Lint(Config{RedactionKeywords: []string{"token", "key", "token"}}, nil)
+	if f := findFinding(report.Findings, CheckNoisyKeyword, `policy.redaction_keywords "key"`); f == nil || len(f.Examples) == 0 {
+		t.Fatalf("expected key to be noisy: %+v", report.Findings)
+	}
+	if f := findFinding(report.Findings, CheckNoisyKeyword, `policy.redaction_keywords "token"`); f != nil {
+		t.Fatalf("token should be quiet on the corpus: %+v", f)
+	}
+	if f := findFinding(report.Findings, CheckDuplicate, `policy.redaction_keywords "token"`); f == nil {
+		t.Fatalf("expected duplicate keyword: %+v", report.Findings)
+	}
+}
+
+func TestLintCorpusPassesDefaultRedaction(t *testing.T) {
+	t.Parallel()
+
+	// Every corpus hit is reported as a false positive, so the corpus itself
+	// must not contain anything the built-in rules treat as a secret.
+	p, err := New(Options{DenylistPatterns: []string{"printenv"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for _, command := range lintCorpus {
+		if e := p.Explain(command, strings.Fields(command)); e.Denied || len(e.Detected()) > 0 {
+			t.Fatalf("corpus command %q is treated as sensitive: %+v", command, e)
+		}
+	}
+}
@@ -3,10 +3,11 @@ package policy
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/fixi2/Commandry/internal/shellwords"
 )
 
 const (
@@ -17,23 +18,45 @@ const (
 type Result struct {
 	Command string
 	Denied  bool
+	// Detected lists the built-in secret detectors that redacted something.
+	Detected []string
 }
 
 type redactor struct {
+	name string
 	re   *regexp.Regexp
 	repl string
 }
 
+type denyRule struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
 type Policy struct {
-	denylist        []*regexp.Regexp
+	denylist        []denyRule
 	redact          []redactor
+	custom          []customRedactor
+	detectors       []detector
 	enforceDenylist bool
+	guard           []denyRule
+	guardRules      []guardRule
+	fingerprint     string
 }
 
 type Options struct {
 	DenylistPatterns  []string
 	RedactionKeywords []string
+	// RedactionRules run before the built-in redactors, in order.
+	RedactionRules []RedactionRule
+	// DisabledDetectors turns off built-in secret detectors by name.
+	DisabledDetectors []string
 	EnforceDenylist   bool
+	// GuardPatterns are denylist-style globs for dangerous commands, checked
+	// after the built-in guard rules.
+	GuardPatterns []string
+	// DisabledGuards turns off built-in guard rules by name.
+	DisabledGuards []string
 }
 
 var credentialInImageRef = regexp.MustCompile(`^[^/\s:@]+:[^/\s@]+@`)
@@ -83,7 +106,7 @@ func New(opts Options) (*Policy, error) {
 		redactionKeywords = defaultRedactionKeywords
 	}
 
-	denylist := make([]*regexp.Regexp, 0, len(denyPatterns))
+	denylist := make([]denyRule, 0, len(denyPatterns))
 	for _, pattern := range denyPatterns {
 		if strings.TrimSpace(pattern) == "" {
 			continue
@@ -92,14 +115,37 @@ func New(opts Options) (*Policy, error) {
 		if err != nil {
 			return nil, err
 		}
-		denylist = append(denylist, re)
+		denylist = append(denylist, denyRule{pattern: strings.TrimSpace(pattern), re: re})
+	}
+
+	guard := make([]denyRule, 0, len(opts.GuardPatterns))
+	for _, pattern := range opts.GuardPatterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		re, err := compileDenyPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		guard = append(guard, denyRule{pattern: strings.TrimSpace(pattern), re: re})
+	}
+
+	custom, err := compileRedactionRules(opts.RedactionRules)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Policy{
+	p := &Policy{
 		denylist:        denylist,
 		redact:          buildRedactors(redactionKeywords),
+		custom:          custom,
+		detectors:       enabledDetectors(opts.DisabledDetectors),
 		enforceDenylist: opts.EnforceDenylist,
-	}, nil
+		guard:           guard,
+		guardRules:      enabledGuardRules(opts.DisabledGuards),
+	}
+	p.fingerprint = p.computeFingerprint(redactionKeywords, opts.RedactionRules)
+	return p, nil
 }
 
 func buildRedactors(keywords []string) []redactor {
@@ -110,38 +156,47 @@ func buildRedactors(keywords []string) []redactor {
 	}))
 	return []redactor{
 		{
+			name: "authorization_bearer",
 			re:   regexp.MustCompile(`(?i)(authorization\s*:\s*bearer\s+)([^\s"']+)`),
 			repl: `${1}` + RedactedValue,
 		},
 		{
+			name: "uri_userinfo",
 			re:   uriUserinfo,
 			repl: `${1}` + RedactedValue + `:` + RedactedValue + `@`,
 		},
 		{
+			name: "keyword_flag_equals",
 			re:   regexp.MustCompile(`(?i)(--(?:` + keyPattern + `)=)([^\s]+)`),
 			repl: `${1}` + RedactedValue,
 		},
 		{
+			name: "keyword_flag_value",
 			re:   regexp.MustCompile(`(?i)(--(?:` + keyPattern + `)\s+)([^\s]+)`),
 			repl: `${1}` + RedactedValue,
 		},
 		{
+			name: "short_password_flag",
 			re:   regexp.MustCompile(`(?i)(-p\s+)([^\s]+)`),
 			repl: `${1}` + RedactedValue,
 		},
 		{
+			name: "keyword_assignment",
 			re:   regexp.MustCompile(`(?i)(\b(?:` + keyValuePattern + `)\b\s*[:=]\s*)([^\s]+)`),
 			repl: `${1}` + RedactedValue,
 		},
 		{
+			name: "assignment_double_quoted",
 			re:   regexp.MustCompile(`(?i)(\b[A-Za-z_][A-Za-z0-9_]*=)"[^"]*"`),
 			repl: `${1}"` + RedactedValue + `"`,
 		},
 		{
+			name: "assignment_single_quoted",
 			re:   regexp.MustCompile(`(?i)(\b[A-Za-z_][A-Za-z0-9_]*=)'[^']*'`),
 			repl: `${1}'` + RedactedValue + `'`,
 		},
 		{
+			name: "assignment_value",
 			re:   regexp.MustCompile(`(?i)(\b[A-Za-z_][A-Za-z0-9_]*=)([^\s"']+)`),
 			repl: `${1}` + RedactedValue,
 		},
@@ -201,11 +256,7 @@ func LoadFromConfig(path string) (*Policy, error) {
 	if err != nil {
 		return nil, err
 	}
-	return New(Options{
-		DenylistPatterns:  cfg.Denylist,
-		RedactionKeywords: cfg.RedactionKeywords,
-		EnforceDenylist:   cfg.EnforceDenylist,
-	})
+	return cfg.build()
 }
 
 func LoadFromConfigOrDefault(path string) (*Policy, error) {
@@ -223,49 +274,40 @@ func (p *Policy) EnforceDenylist() bool {
 	return p.enforceDenylist
 }
 
+// Apply sanitizes a command. args is the exact argument vector when the
+// caller has one (cmdry run); when nil, rawCommand is parsed as a POSIX shell
+// line.
 func (p *Policy) Apply(rawCommand string, args []string) Result {
-	if p.isDenied(rawCommand, args) {
-		return Result{
-			Command: DeniedPlaceholder,
-			Denied:  true,
-		}
-	}
-
-	sanitized, preserved := preserveKubectlSetImageAssignments(rawCommand, args)
-	for _, rule := range p.redact {
-		sanitized = rule.re.ReplaceAllString(sanitized, rule.repl)
-	}
-	for placeholder, original := range preserved {
-		sanitized = strings.ReplaceAll(sanitized, placeholder, original)
-	}
-
-	return Result{
-		Command: sanitized,
-		Denied:  false,
-	}
+	return p.Explain(rawCommand, args).Result()
 }
 
-func preserveKubectlSetImageAssignments(rawCommand string, args []string) (string, map[string]string) {
-	if !isKubectlSetImage(args) {
-		return rawCommand, nil
-	}
+// ApplyLine sanitizes a command line typed into a shell of the given dialect.
+func (p *Policy) ApplyLine(line string, dialect shellwords.Dialect) Result {
+	return p.ExplainLine(line, dialect).Result()
+}
 
+func preserveKubectlSetImageAssignments(rawCommand string, commands [][]string) (string, map[string]string) {
 	sanitized := rawCommand
 	preserved := make(map[string]string)
 	index := 0
 
-	for _, arg := range args {
-		if strings.HasPrefix(arg, "-") || !strings.Contains(arg, "=") {
+	for _, args := range commands {
+		if !isKubectlSetImage(args) {
 			continue
 		}
-		if !isSafeImageAssignment(arg) {
-			continue
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "-") || !strings.Contains(arg, "=") {
+				continue
+			}
+			if !isSafeImageAssignment(arg) {
+				continue
+			}
+
+			placeholder := "__COMMANDRY_IMG_ASSIGN_" + strconv.Itoa(index) + "__"
+			index++
+			sanitized = strings.ReplaceAll(sanitized, arg, placeholder)
+			preserved[placeholder] = arg
 		}
-
-		placeholder := "__COMMANDRY_IMG_ASSIGN_" + strconv.Itoa(index) + "__"
-		index++
-		sanitized = strings.ReplaceAll(sanitized, arg, placeholder)
-		preserved[placeholder] = arg
 	}
 
 	return sanitized, preserved
@@ -276,8 +318,7 @@ func isKubectlSetImage(args []string) bool {
 		return false
 	}
 
-	binary := strings.ToLower(filepath.Base(args[0]))
-	if binary != "kubectl" && binary != "kubectl.exe" {
+	if shellwords.Program(args[0]) != "kubectl" {
 		return false
 	}
 
@@ -315,32 +356,52 @@ func containsSensitiveKeyword(v string) bool {
 	return false
 }
 
-func (p *Policy) isDenied(rawCommand string, args []string) bool {
-	if len(args) > 0 {
-		binary := strings.ToLower(filepath.Base(args[0]))
-		if binary == "env" || binary == "printenv" {
-			return true
-		}
-		if isKubectlSecretOutputDenied(args) {
-			return true
+// builtinDenyRules are checked before the configurable denylist and cannot be
+// turned off.
+var builtinDenyRules = []struct {
+	name  string
+	match func(args []string) bool
+}{
+	{name: "isEnvDumpDenied", match: isEnvDumpDenied},
+	{name: "isKubectlSecretOutputDenied", match: isKubectlSecretOutputDenied},
+}
+
+// denyMatch returns the rule that denies the command, if any. Built-in rules
+// look at each simple command; denylist entries match the whole line or any
+// simple command with its wrappers removed.
+func (p *Policy) denyMatch(rawCommand string, commands [][]string) (DenyMatch, bool) {
+	for _, args := range commands {
+		for _, rule := range builtinDenyRules {
+			if rule.match(args) {
+				return DenyMatch{Kind: RuleBuiltin, Rule: rule.name}, true
+			}
 		}
 	}
 
 	for _, rule := range p.denylist {
-		if rule.MatchString(rawCommand) {
-			return true
+		if rule.re.MatchString(rawCommand) {
+			return DenyMatch{Kind: RuleDenylist, Rule: rule.pattern}, true
+		}
+		for _, args := range commands {
+			if rule.re.MatchString(strings.Join(args, " ")) {
+				return DenyMatch{Kind: RuleDenylist, Rule: rule.pattern}, true
+			}
 		}
 	}
 
-	return false
+	return DenyMatch{}, false
+}
+
+func isEnvDumpDenied(args []string) bool {
+	binary := shellwords.Program(args[0])
+	return binary == "env" || binary == "printenv"
 }
 
 func isKubectlSecretOutputDenied(args []string) bool {
 	if len(args) < 5 {
 		return false
 	}
-	binary := strings.ToLower(filepath.Base(args[0]))
-	if binary != "kubectl" && binary != "kubectl.exe" {
+	if shellwords.Program(args[0]) != "kubectl" {
 		return false
 	}
 	if !strings.EqualFold(args[1], "get") || !strings.EqualFold(args[2], "secret") {
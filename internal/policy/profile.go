@@ -0,0 +1,65 @@
+package policy
+
+import "github.com/fixi2/Commandry/internal/config"
+
+// Profile is the recording policy in effect for one session environment.
+type Profile struct {
+	// Name is the config profile that matched, or "" for the base policy.
+	Name   string
+	Policy *Policy
+	// RecordCWD reports whether steps keep the working directory.
+	RecordCWD bool
+	// Protected reports whether dangerous commands need confirmation.
+	Protected bool
+}
+
+// ResolveProfile builds the policy for a session labelled env: the policy
+// section of cfg with the matching profile, if any, layered on top. A locked
+// enforce_denylist cannot be changed by a profile.
+func ResolveProfile(cfg config.Config, env string) (Profile, error) {
+	resolved := FromConfig(cfg.Policy)
+	profile := Profile{RecordCWD: cfg.Capture.RecordCWD}
+	if name, pp, ok := cfg.Policy.Profile(env); ok {
+		profile.Name = name
+		if cfg.Policy.Locks("enforce_denylist") {
+			pp.EnforceDenylist = nil
+		}
+		resolved = resolved.withProfile(pp)
+		if pp.RecordCWD != nil {
+			profile.RecordCWD = *pp.RecordCWD
+		}
+		profile.Protected = pp.Protected
+	}
+
+	p, err := resolved.build()
+	if err != nil {
+		return Profile{}, err
+	}
+	profile.Policy = p
+	return profile, nil
+}
+
+func (c Config) withProfile(pp config.PolicyProfile) Config {
+	c.Denylist = appendNew(c.Denylist, pp.Denylist)
+	c.RedactionKeywords = appendNew(c.RedactionKeywords, pp.RedactionKeywords)
+	if pp.EnforceDenylist != nil {
+		c.EnforceDenylist = *pp.EnforceDenylist
+	}
+	return c
+}
+
+// appendNew returns base followed by the entries of extra it lacks.
+func appendNew(base, extra []string) []string {
+	out := append([]string(nil), base...)
+	seen := make(map[string]bool, len(base)+len(extra))
+	for _, entry := range base {
+		seen[entry] = true
+	}
+	for _, entry := range extra {
+		if !seen[entry] {
+			seen[entry] = true
+			out = append(out, entry)
+		}
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fixi2/Commandry/internal/config"
+	"github.com/fixi2/Commandry/internal/shellwords"
+)
+
+func TestResolveProfileLayersOnBasePolicy(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, err := config.Parse([]byte(strings.Join([]string{
+		"policy:",
+		"  profiles:",
+		"    prod:",
+		"      denylist: [\"terraform destroy*\"]",
+		"      redaction_keywords: [db_pass]",
+		"      enforce_denylist: true",
+		"      record_cwd: false",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	dev, err := ResolveProfile(cfg, "dev")
+	if err != nil {
+		t.Fatalf("ResolveProfile(dev) failed: %v", err)
+	}
+	if dev.Name != "" || !dev.RecordCWD || dev.Policy.EnforceDenylist() {
+		t.Fatalf("unexpected dev profile: %+v", dev)
+	}
+	if got := dev.Policy.ApplyLine("terraform destroy -auto-approve", shellwords.POSIX); got.Denied {
+		t.Fatalf("base policy denied terraform destroy: %+v", got)
+	}
+
+	prod, err := ResolveProfile(cfg, "Prod")
+	if err != nil {
+		t.Fatalf("ResolveProfile(prod) failed: %v", err)
+	}
+	if prod.Name != "prod" || prod.RecordCWD || !prod.Policy.EnforceDenylist() {
+		t.Fatalf("unexpected prod profile: %+v", prod)
+	}
+	for _, line := range []string{"terraform destroy -auto-approve", "printenv"} {
+		if got := prod.Policy.ApplyLine(line, shellwords.POSIX); !got.Denied {
+			t.Fatalf("prod profile did not deny %q: %+v", line, got)
+		}
+	}
+	if got := prod.Policy.ApplyLine("psql --db_pass=hunter2 --password=x", shellwords.POSIX); strings.Contains(got.Command, "hunter2") || strings.Contains(got.Command, "=x") {
+		t.Fatalf("prod profile kept a secret: %q", got.Command)
+	}
+}
@@ -0,0 +1,166 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Targets a redaction rule can apply to.
+const (
+	TargetCommand = "command"
+	TargetOutput  = "output"
+	TargetCWD     = "cwd"
+)
+
+// SecretGroup is the capture group a rule without a replacement redacts; when
+// the pattern has no such group the whole match is redacted.
+const SecretGroup = "secret"
+
+// RedactionRule is a user-defined regular expression redaction.
+type RedactionRule struct {
+	Name        string
+	Pattern     string
+	Replacement string
+	AppliesTo   string
+}
+
+// RuleError reports an invalid field of the redaction rule at Index.
+type RuleError struct {
+	Index int
+	Name  string
+	Field string
+	Err   error
+}
+
+func (e *RuleError) Error() string {
+	label := e.Name
+	if label == "" {
+		label = "#" + strconv.Itoa(e.Index+1)
+	}
+	return fmt.Sprintf("redaction rule %s: invalid %s: %v", label, e.Field, e.Err)
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+var replacementRef = regexp.MustCompile(`\$(?:\{([A-Za-z0-9_]+)\}|([0-9]+))`)
+
+type customRedactor struct {
+	name   string
+	target string
+	re     *regexp.Regexp
+	repl   string
+	// secret is the index of the `secret` group used when repl is empty.
+	secret int
+}
+
+func compileRedactionRules(rules []RedactionRule) ([]customRedactor, error) {
+	compiled := make([]customRedactor, 0, len(rules))
+	for i, rule := range rules {
+		fail := func(field string, err error) error {
+			return &RuleError{Index: i, Name: rule.Name, Field: field, Err: err}
+		}
+
+		target := strings.ToLower(strings.TrimSpace(rule.AppliesTo))
+		switch target {
+		case "":
+			target = TargetCommand
+		case TargetCommand, TargetOutput, TargetCWD:
+		default:
+			return nil, fail("applies_to", fmt.Errorf("%q is not one of command, output, cwd", rule.AppliesTo))
+		}
+
+		if strings.TrimSpace(rule.Pattern) == "" {
+			return nil, fail("pattern", fmt.Errorf("pattern cannot be empty"))
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fail("pattern", err)
+		}
+		if re.MatchString("") {
+			return nil, fail("pattern", fmt.Errorf("pattern matches the empty string"))
+		}
+		if err := checkReplacementRefs(re, rule.Replacement); err != nil {
+			return nil, fail("replacement", err)
+		}
+
+		compiled = append(compiled, customRedactor{
+			name:   rule.Name,
+			target: target,
+			re:     re,
+			repl:   rule.Replacement,
+			secret: re.SubexpIndex(SecretGroup),
+		})
+	}
+	return compiled, nil
+}
+
+// checkReplacementRefs rejects $N / ${name} references to groups the pattern
+// does not define, which regexp would otherwise expand to nothing.
+func checkReplacementRefs(re *regexp.Regexp, repl string) error {
+	for _, m := range replacementRef.FindAllStringSubmatch(repl, -1) {
+		ref := m[1]
+		if ref == "" {
+			ref = m[2]
+		}
+		if n, err := strconv.Atoi(ref); err == nil {
+			if n > re.NumSubexp() {
+				return fmt.Errorf("$%s refers to a missing capture group (pattern has %d)", ref, re.NumSubexp())
+			}
+			continue
+		}
+		if re.SubexpIndex(ref) < 0 {
+			return fmt.Errorf("${%s} refers to a missing capture group", ref)
+		}
+	}
+	return nil
+}
+
+func (r customRedactor) apply(s string) string {
+	if r.repl != "" {
+		return r.re.ReplaceAllString(s, r.repl)
+	}
+	if r.secret < 0 {
+		return r.re.ReplaceAllLiteralString(s, RedactedValue)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range r.re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := loc[2*r.secret], loc[2*r.secret+1]
+		if start < 0 {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(RedactedValue)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (p *Policy) applyCustom(target, s string) string {
+	for _, rule := range p.custom {
+		if rule.target == target {
+			s = rule.apply(s)
+		}
+	}
+	return s
+}
+
+// RedactCWD applies the rules targeting working directories.
+func (p *Policy) RedactCWD(cwd string) string {
+	if cwd == "" {
+		return cwd
+	}
+	return p.applyCustom(TargetCWD, cwd)
+}
+
+// RedactOutput applies the rules targeting command output. The CLI never
+// stores output; this is for SDK callers that capture it themselves.
+func (p *Policy) RedactOutput(output string) string {
+	return p.applyCustom(TargetOutput, output)
+}
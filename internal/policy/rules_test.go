@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRedactionRulesApplyToCommands(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Options{RedactionRules: []RedactionRule{
+		{Name: "acme-live-key", Pattern: `acme_live_[A-Za-z0-9]{32}`},
+		{Name: "customer-id", Pattern: `\b(cust)-(\d{4})\d{4}\b`, Replacement: "${1}-${2}****"},
+		{Name: "tenant", Pattern: `tenant/(?P<secret>[a-z]+)/`, AppliesTo: "command"},
+	}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	cases := map[string]string{
+		"deploy acme_live_0123456789abcdefABCDEF0123456789 --dry-run": "deploy [REDACTED] --dry-run",
+		"billing refund cust-12345678 cust-87654321":                  "billing refund cust-1234**** cust-8765****",
+		"curl https://api.example.com/tenant/globex/status":           "curl https://api.example.com/tenant/[REDACTED]/status",
+		"deploy acme_live_short":                                      "deploy acme_live_short",
+	}
+	for input, want := range cases {
+		got := p.Apply(input, strings.Fields(input))
+		if got.Denied || got.Command != want {
+			t.Fatalf("Apply(%q) = %+v, want %q", input, got, want)
+		}
+	}
+
+	if got := p.RedactCWD("/srv/cust-12345678"); got != "/srv/cust-12345678" {
+		t.Fatalf("command rules must not touch cwd, got %q", got)
+	}
+}
+
+func TestRedactionRulesTargets(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Options{RedactionRules: []RedactionRule{
+		{Name: "home", Pattern: `^/home/[^/]+`, Replacement: "~", AppliesTo: "CWD"},
+		{Name: "email", Pattern: `[\w.]+@example\.com`, AppliesTo: "output"},
+	}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got := p.RedactCWD("/home/alice/src/app"); got != "~/src/app" {
+		t.Fatalf("RedactCWD = %q", got)
+	}
+	if got := p.RedactOutput("owner: bob@example.com"); got != "owner: [REDACTED]" {
+		t.Fatalf("RedactOutput = %q", got)
+	}
+	if got := p.Apply("cd /home/alice", []string{"cd", "/home/alice"}); got.Command != "cd /home/alice" {
+		t.Fatalf("cwd rule leaked into command redaction: %q", got.Command)
+	}
+}
+
+func TestRedactionRulesValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		rule  RedactionRule
+		field string
+	}{
+		{RedactionRule{Name: "empty"}, "pattern"},
+		{RedactionRule{Name: "broken", Pattern: `acme_(`}, "pattern"},
+		{RedactionRule{Name: "everything", Pattern: `x*`}, "pattern"},
+		{RedactionRule{Name: "target", Pattern: `x`, AppliesTo: "stdout"}, "applies_to"},
+		{RedactionRule{Name: "group", Pattern: `(a)b`, Replacement: "$2"}, "replacement"},
+		{RedactionRule{Name: "named", Pattern: `(?P<id>a)b`, Replacement: "${key}"}, "replacement"},
+	}
+	for _, tc := range cases {
+		_, err := New(Options{RedactionRules: []RedactionRule{{Pattern: `ok`}, tc.rule}})
+		var ruleErr *RuleError
+		if !errors.As(err, &ruleErr) {
+			t.Fatalf("rule %q: expected RuleError, got %v", tc.rule.Name, err)
+		}
+		if ruleErr.Index != 1 || ruleErr.Field != tc.field {
+			t.Fatalf("rule %q: got index %d field %q, want 1 %q", tc.rule.Name, ruleErr.Index, ruleErr.Field, tc.field)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package policy
+
+import (
+	"strconv"
+
+	"github.com/fixi2/Commandry/internal/shellwords"
+)
+
+// SecretMatch names a redactor that would still change a piece of text.
+type SecretMatch struct {
+	Kind string `json:"kind"`
+	Rule string `json:"rule"`
+}
+
+// FindSecrets lists the redaction rules and secret detectors that would
+// change text if it were recorded now, in the order they run. Text that has
+// already been through the policy yields no matches. text is read as a POSIX
+// command line, so values the policy deliberately keeps, such as the images
+// of `kubectl set image`, are not reported.
+func (p *Policy) FindSecrets(text string) []SecretMatch {
+	var matches []SecretMatch
+	p.redactPreserving(text, splitCommandLine(text, shellwords.POSIX, 0), func(kind, name, _, _ string) {
+		matches = append(matches, SecretMatch{Kind: kind, Rule: name})
+	})
+	return matches
+}
+
+// FindCWDSecrets is FindSecrets for a working directory, checked against the
+// rules with applies_to: cwd.
+func (p *Policy) FindCWDSecrets(cwd string) []SecretMatch {
+	var matches []SecretMatch
+	for i, rule := range p.custom {
+		if rule.target != TargetCWD {
+			continue
+		}
+		if after := rule.apply(cwd); after != cwd {
+			name := rule.name
+			if name == "" {
+				name = "#" + strconv.Itoa(i+1)
+			}
+			matches = append(matches, SecretMatch{Kind: RuleCustom, Rule: name})
+			cwd = after
+		}
+	}
+	return matches
+}
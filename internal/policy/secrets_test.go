@@ -0,0 +1,41 @@
+package policy
+
+import "testing"
+
+func TestFindSecretsNamesRulesAndIgnoresRedactedText(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Options{RedactionRules: []RedactionRule{
+		{Name: "acme", Pattern: `acme_live_[A-Za-z0-9]{8}`},
+		{Name: "home", Pattern: `^/home/(?P<secret>[^/]+)`, AppliesTo: TargetCWD},
+	}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	got := p.FindSecrets("deploy --key acme_live_ABCD1234 --password=hunter2")
+	if len(got) != 2 || got[0] != (SecretMatch{Kind: RuleCustom, Rule: "acme"}) || got[1].Kind != RuleBuiltin {
+		t.Fatalf("unexpected matches: %+v", got)
+	}
+	if got := p.FindSecrets(p.Apply("deploy --key acme_live_ABCD1234 --password=hunter2", nil).Command); len(got) != 0 {
+		t.Fatalf("redacted text should be clean: %+v", got)
+	}
+	if got := p.FindCWDSecrets("/home/alice/src"); len(got) != 1 || got[0].Rule != "home" {
+		t.Fatalf("unexpected cwd matches: %+v", got)
+	}
+	if got := p.FindCWDSecrets(p.RedactCWD("/home/alice/src")); len(got) != 0 {
+		t.Fatalf("redacted cwd should be clean: %+v", got)
+	}
+}
+
+func TestFindSecretsKeepsKubectlSetImageAssignments(t *testing.T) {
+	t.Parallel()
+
+	p := NewDefault()
+	if got := p.FindSecrets("kubectl set image deployment/app app=nginx:1.2"); len(got) != 0 {
+		t.Fatalf("set image assignments are not secrets: %+v", got)
+	}
+	if got := p.FindSecrets("kubectl set image deployment/app app=nginx:1.2 && export TOKEN=abc123"); len(got) != 1 {
+		t.Fatalf("expected only the exported token, got %+v", got)
+	}
+}
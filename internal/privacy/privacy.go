@@ -0,0 +1,218 @@
+// Package privacy rewrites personal and internal names in recorded steps:
+// the home directory, configured hostnames and usernames, and working
+// directories inside a repository. Unlike the policy it does not hide
+// secrets; it makes runbooks safe to share outside the team.
+package privacy
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fixi2/Commandry/internal/config"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+// Default placeholders for names configured without one.
+const (
+	HostPlaceholder = "<host>"
+	UserPlaceholder = "<user>"
+)
+
+// placeholderPattern matches a name in angle brackets, the form of the
+// default placeholders.
+var placeholderPattern = regexp.MustCompile(`<[A-Za-z][A-Za-z0-9_.-]*>`)
+
+// HasPlaceholder reports whether text holds a placeholder in angle brackets,
+// such as <host> or <user>. A scrubbed command with one cannot run as it is:
+// shells read the brackets as redirections.
+func HasPlaceholder(text string) bool {
+	return placeholderPattern.MatchString(text)
+}
+
+type replacement struct {
+	name        string
+	placeholder string
+	foldCase    bool
+}
+
+// Scrubber applies a privacy config. The zero value changes nothing.
+type Scrubber struct {
+	home        string
+	names       []replacement
+	relativeCWD bool
+	// repoRoot finds the repository containing a directory; tests replace it.
+	repoRoot func(dir string) (string, bool)
+}
+
+// New builds a scrubber for cfg, using the current user's home directory.
+func New(cfg config.PrivacyConfig) *Scrubber {
+	s := &Scrubber{relativeCWD: cfg.RelativeCWD, repoRoot: findRepoRoot}
+	if cfg.Home {
+		if home, err := os.UserHomeDir(); err == nil {
+			s.home = strings.TrimRight(home, `/\`)
+		}
+	}
+	s.names = append(s.names, replacements(cfg.Hosts, HostPlaceholder, true)...)
+	s.names = append(s.names, replacements(cfg.Users, UserPlaceholder, false)...)
+	// Longer names first, so "db01.corp" does not eat into "db01.corp.example".
+	sort.SliceStable(s.names, func(i, j int) bool { return len(s.names[i].name) > len(s.names[j].name) })
+	return s
+}
+
+func replacements(m map[string]string, fallback string, foldCase bool) []replacement {
+	out := make([]replacement, 0, len(m))
+	for name, placeholder := range m {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if placeholder = strings.TrimSpace(placeholder); placeholder == "" {
+			placeholder = fallback
+		}
+		out = append(out, replacement{name: name, placeholder: placeholder, foldCase: foldCase})
+	}
+	return out
+}
+
+// Text scrubs a command line or any other free text.
+func (s *Scrubber) Text(text string) string {
+	if s == nil {
+		return text
+	}
+	if s.home != "" {
+		text = replaceHome(text, s.home)
+	}
+	for _, r := range s.names {
+		text = replaceName(text, r)
+	}
+	return text
+}
+
+// CWD scrubs a working directory. With relative_cwd, a directory inside a
+// repository becomes relative to its root ("." for the root itself).
+func (s *Scrubber) CWD(cwd string) string {
+	if s == nil || cwd == "" {
+		return cwd
+	}
+	if s.relativeCWD && s.repoRoot != nil {
+		if root, ok := s.repoRoot(cwd); ok {
+			if rel, err := filepath.Rel(root, cwd); err == nil {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return s.Text(cwd)
+}
+
+// Step scrubs the command and working directory of step.
+func (s *Scrubber) Step(step *store.Step) {
+	step.Command = s.Text(step.Command)
+	step.CWD = s.CWD(step.CWD)
+}
+
+// Session returns a scrubbed copy of session: its title, metadata values and
+// steps.
+func (s *Scrubber) Session(session *store.Session) *store.Session {
+	out := *session
+	out.Title = s.Text(session.Title)
+	if session.Meta != nil {
+		out.Meta = make(map[string]string, len(session.Meta))
+		for key, value := range session.Meta {
+			out.Meta[key] = s.Text(value)
+		}
+	}
+	out.Steps = make([]store.Step, len(session.Steps))
+	for i, step := range session.Steps {
+		s.Step(&step)
+		out.Steps[i] = step
+	}
+	return &out
+}
+
+// replaceHome rewrites home to ~ where it is a whole path prefix.
+func replaceHome(text, home string) string {
+	fold := runtime.GOOS == "windows"
+	var b strings.Builder
+	for {
+		i := index(text, home, fold)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		end := i + len(home)
+		if (i == 0 || !isPathByte(text[i-1])) && (end == len(text) || !isPathByte(text[end]) || text[end] == '/' || text[end] == '\\') {
+			b.WriteString(text[:i])
+			b.WriteString("~")
+		} else {
+			b.WriteString(text[:end])
+		}
+		text = text[end:]
+	}
+}
+
+// replaceName rewrites r.name where it stands as a whole host or user name:
+// not preceded or followed by a name character. A trailing dot ends a name
+// unless another label follows it.
+func replaceName(text string, r replacement) string {
+	var b strings.Builder
+	for {
+		i := index(text, r.name, r.foldCase)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		end := i + len(r.name)
+		before := i == 0 || !isNameByte(text[i-1]) && text[i-1] != '.'
+		after := end == len(text) || !isNameByte(text[end]) && (text[end] != '.' || end+1 == len(text) || !isNameByte(text[end+1]))
+		if before && after {
+			b.WriteString(text[:i])
+			b.WriteString(r.placeholder)
+		} else {
+			b.WriteString(text[:end])
+		}
+		text = text[end:]
+	}
+}
+
+// index finds substr in s, case-insensitively when fold is set. Windows of s
+// are compared as they are, since lowercasing can change the byte length of
+// a rune and shift every offset after it.
+func index(s, substr string, fold bool) int {
+	if !fold {
+		return strings.Index(s, substr)
+	}
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if utf8.RuneStart(s[i]) && strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+func isPathByte(c byte) bool {
+	return isNameByte(c) || c == '.'
+}
+
+// findRepoRoot walks up from dir to the nearest directory holding .git.
+func findRepoRoot(dir string) (string, bool) {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
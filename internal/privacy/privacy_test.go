@@ -0,0 +1,103 @@
+package privacy
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fixi2/Commandry/internal/config"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestTextReplacesHomeHostsAndUsers(t *testing.T) {
+	t.Parallel()
+
+	s := New(config.PrivacyConfig{
+		Hosts: map[string]string{"db01.corp.example": "db-primary", "db01": ""},
+		Users: map[string]string{"alice": "deployer"},
+	})
+	s.home = "/home/alice"
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"cat /home/alice/.kube/config", "cat ~/.kube/config"},
+		{"cd /home/alice", "cd ~"},
+		{"ls /home/alicex /data/home/alice", "ls /home/alicex /data/home/deployer"},
+		{"psql -h DB01.corp.example -U alice", "psql -h db-primary -U deployer"},
+		{"ssh alice@db01 uptime", "ssh deployer@<host> uptime"},
+		{"ping db01.", "ping <host>."},
+		{"ping db01.staging.example db01x", "ping db01.staging.example db01x"},
+		{"grep Alice users.txt", "grep Alice users.txt"},
+		// Ⱥ grows from two to three bytes when lowercased.
+		{"echo Ⱥ db01", "echo Ⱥ <host>"},
+		{"echo ȺȺȺ DB01x db01", "echo ȺȺȺ DB01x <host>"},
+	}
+	for _, tt := range tests {
+		if got := s.Text(tt.in); got != tt.want {
+			t.Errorf("Text(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHasPlaceholder(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"ssh <user>@<host> uptime": true,
+		"psql -h <db.primary>":     true,
+		"ssh deployer@db-primary":  false,
+		"sort <in.txt >out.txt":    false,
+		"diff <(ls a) <(ls b)":     false,
+	}
+	for in, want := range tests {
+		if got := HasPlaceholder(in); got != want {
+			t.Errorf("HasPlaceholder(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestCWDRelativeToRepoRoot(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.FromSlash("/src/app")
+	s := New(config.PrivacyConfig{RelativeCWD: true})
+	s.home = filepath.FromSlash("/home/alice")
+	s.repoRoot = func(dir string) (string, bool) {
+		return root, dir == root || strings.HasPrefix(dir, root+string(filepath.Separator))
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{root, "."},
+		{filepath.Join(root, "deploy", "k8s"), "deploy/k8s"},
+		{filepath.FromSlash("/home/alice/tmp"), filepath.FromSlash("~/tmp")},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := s.CWD(tt.in); got != tt.want {
+			t.Errorf("CWD(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSessionReturnsScrubbedCopy(t *testing.T) {
+	t.Parallel()
+
+	s := New(config.PrivacyConfig{Hosts: map[string]string{"db01": "db"}})
+	session := &store.Session{
+		Title: "Restart db01",
+		Meta:  map[string]string{"host": "db01"},
+		Steps: []store.Step{{Command: "ssh db01 sudo systemctl restart postgres"}},
+	}
+	got := s.Session(session)
+	if got.Title != "Restart db" || got.Meta["host"] != "db" || got.Steps[0].Command != "ssh db sudo systemctl restart postgres" {
+		t.Fatalf("unexpected scrubbed session: %+v", got)
+	}
+	if session.Title != "Restart db01" || session.Meta["host"] != "db01" || session.Steps[0].Command != "ssh db01 sudo systemctl restart postgres" {
+		t.Fatalf("original session was modified: %+v", session)
+	}
+}
@@ -0,0 +1,396 @@
+// Package shellwords splits interactive command lines into the simple
+// commands they run. It understands enough POSIX shell and PowerShell syntax
+// (quoting, escapes, pipelines, && / || / ; chains, subshells, command
+// substitution and redirections) for policy checks; it is not a full parser
+// and never evaluates anything.
+package shellwords
+
+import (
+	"strings"
+)
+
+// Dialect selects the quoting and escaping rules of a shell.
+type Dialect string
+
+const (
+	POSIX      Dialect = "posix"
+	PowerShell Dialect = "powershell"
+)
+
+// ParseDialect maps a shell name (bash, zsh, sh, pwsh, powershell, ...) to a
+// dialect. Unknown and empty names fall back to POSIX.
+func ParseDialect(shell string) Dialect {
+	switch strings.ToLower(strings.TrimSuffix(strings.TrimSpace(shell), ".exe")) {
+	case "powershell", "pwsh", "ps":
+		return PowerShell
+	default:
+		return POSIX
+	}
+}
+
+// maxDepth bounds nested substitutions so hostile input cannot recurse
+// without limit; deeper text is kept as a literal word.
+const maxDepth = 16
+
+// reservedWords open or close POSIX compound commands. They are dropped
+// from the front of a simple command so `if printenv; then ...` still
+// reports printenv.
+var reservedWords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"do": true, "done": true, "while": true, "until": true, "!": true,
+}
+
+// loopWords start commands whose words are not a program invocation.
+var loopWords = map[string]bool{"for": true, "case": true, "select": true, "esac": true}
+
+// Commands returns the simple commands in line, each as its argument list
+// with quotes removed. Commands inside $(...), `...`, <(...) and subshells
+// are returned as well, before the command that contains them.
+func Commands(line string, d Dialect) [][]string {
+	p := &parser{src: []rune(line), dialect: d}
+	p.parseList(0)
+	return p.out
+}
+
+type parser struct {
+	src     []rune
+	pos     int
+	dialect Dialect
+	depth   int
+	out     [][]string
+}
+
+func (p *parser) peek(offset int) rune {
+	if i := p.pos + offset; i < len(p.src) {
+		return p.src[i]
+	}
+	return 0
+}
+
+// parseList consumes simple commands until closer (or the end of input)
+// and appends them to p.out.
+func (p *parser) parseList(closer rune) {
+	var (
+		args     []string
+		word     strings.Builder
+		inWord   bool
+		skipNext bool
+	)
+	flushWord := func() {
+		if !inWord {
+			return
+		}
+		if skipNext {
+			skipNext = false
+		} else {
+			args = append(args, word.String())
+		}
+		word.Reset()
+		inWord = false
+	}
+	endCommand := func() {
+		flushWord()
+		skipNext = false
+		if args = trimReserved(args, p.dialect); len(args) > 0 {
+			p.out = append(p.out, args)
+		}
+		args = nil
+	}
+	posix := p.dialect != PowerShell
+
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case closer != 0 && r == closer:
+			p.pos++
+			endCommand()
+			return
+		case r == ' ' || r == '\t' || r == '\r':
+			flushWord()
+			p.pos++
+		case r == '\n' || r == ';':
+			endCommand()
+			p.pos++
+		case r == '|':
+			endCommand()
+			p.pos++
+			if next := p.peek(0); next == '|' || next == '&' {
+				p.pos++
+			}
+		case r == '&':
+			switch next := p.peek(1); {
+			case next == '&':
+				endCommand()
+				p.pos += 2
+			case next == '>' && posix:
+				p.redirect(&word, &inWord, &skipNext, flushWord)
+			case !posix && !inWord && len(args) == 0:
+				// PowerShell call operator: & 'C:\tool.exe' args
+				p.pos++
+			default:
+				endCommand()
+				p.pos++
+			}
+		case r == '#' && !inWord:
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case r == '(' && !inWord:
+			endCommand()
+			p.pos++
+			p.nested(func() { p.parseList(')') })
+		case r == ')':
+			// Unbalanced closer: treat as a separator.
+			endCommand()
+			p.pos++
+		case r == '{' && !inWord && (!posix || isSpace(p.peek(1))):
+			endCommand()
+			p.pos++
+		case r == '}' && !inWord:
+			endCommand()
+			p.pos++
+		case !posix && r == '<' && p.peek(1) == '#':
+			p.skipBlockComment()
+		case posix && (r == '<' || r == '>') && p.peek(1) == '(' && !inWord:
+			start := p.pos
+			p.pos += 2
+			p.nested(func() { p.parseList(')') })
+			word.WriteString(string(p.src[start:p.pos]))
+			inWord = true
+		case r == '<' || r == '>':
+			p.redirect(&word, &inWord, &skipNext, flushWord)
+		case r == '\'':
+			inWord = true
+			p.singleQuoted(&word)
+		case r == '"':
+			inWord = true
+			p.doubleQuoted(&word)
+		case posix && r == '\\':
+			p.pos++
+			if p.pos < len(p.src) {
+				if p.src[p.pos] != '\n' {
+					word.WriteRune(p.src[p.pos])
+					inWord = true
+				}
+				p.pos++
+			}
+		case !posix && r == '`':
+			p.pos++
+			if p.pos < len(p.src) {
+				if p.src[p.pos] != '\n' {
+					word.WriteRune(escapedPowerShellRune(p.src[p.pos]))
+					inWord = true
+				}
+				p.pos++
+			}
+		case (r == '$' || (!posix && r == '@')) && p.peek(1) == '(':
+			p.substitution(&word)
+			inWord = true
+		case posix && r == '`':
+			p.backtick(&word)
+			inWord = true
+		default:
+			word.WriteRune(r)
+			inWord = true
+			p.pos++
+		}
+	}
+	endCommand()
+}
+
+// nested runs fn one level deeper, or skips the rest of the group as a
+// literal when the depth limit is reached.
+func (p *parser) nested(fn func()) {
+	if p.depth >= maxDepth {
+		p.skipBalanced('(', ')')
+		return
+	}
+	p.depth++
+	fn()
+	p.depth--
+}
+
+// skipBalanced advances past the closer matching an already consumed opener.
+func (p *parser) skipBalanced(open, closer rune) {
+	for level := 1; p.pos < len(p.src) && level > 0; p.pos++ {
+		switch p.src[p.pos] {
+		case open:
+			level++
+		case closer:
+			level--
+		}
+	}
+}
+
+// redirect consumes a redirection operator and marks its target word to be
+// dropped. A file descriptor number written before the operator (2>) is
+// dropped too; descriptor duplication (2>&1) has no target.
+func (p *parser) redirect(word *strings.Builder, inWord, skipNext *bool, flushWord func()) {
+	if *inWord && isDescriptor(word.String()) {
+		word.Reset()
+		*inWord = false
+	} else {
+		flushWord()
+	}
+	for p.pos < len(p.src) && strings.ContainsRune("<>&|", p.src[p.pos]) {
+		dup := p.src[p.pos] == '&' && p.pos > 0 && (p.src[p.pos-1] == '>' || p.src[p.pos-1] == '<')
+		p.pos++
+		if dup && p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '-') {
+			for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '-') {
+				p.pos++
+			}
+			return
+		}
+	}
+	*skipNext = true
+}
+
+func (p *parser) singleQuoted(word *strings.Builder) {
+	p.pos++
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		p.pos++
+		if r == '\'' {
+			if p.dialect == PowerShell && p.peek(0) == '\'' {
+				word.WriteRune('\'')
+				p.pos++
+				continue
+			}
+			return
+		}
+		word.WriteRune(r)
+	}
+}
+
+func (p *parser) doubleQuoted(word *strings.Builder) {
+	p.pos++
+	posix := p.dialect != PowerShell
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case r == '"':
+			p.pos++
+			if !posix && p.peek(0) == '"' {
+				word.WriteRune('"')
+				p.pos++
+				continue
+			}
+			return
+		case posix && r == '\\' && strings.ContainsRune("$`\"\\\n", p.peek(1)):
+			if p.peek(1) != '\n' {
+				word.WriteRune(p.peek(1))
+			}
+			p.pos += 2
+		case !posix && r == '`' && p.pos+1 < len(p.src):
+			word.WriteRune(escapedPowerShellRune(p.peek(1)))
+			p.pos += 2
+		case r == '$' && p.peek(1) == '(':
+			p.substitution(word)
+		case posix && r == '`':
+			p.backtick(word)
+		default:
+			word.WriteRune(r)
+			p.pos++
+		}
+	}
+}
+
+// substitution parses $(...) (and PowerShell @(...)), collecting the
+// commands inside and keeping the original text in the current word.
+// Arithmetic $((...)) is kept as text.
+func (p *parser) substitution(word *strings.Builder) {
+	start := p.pos
+	p.pos += 2
+	if p.dialect != PowerShell && p.peek(0) == '(' {
+		p.pos++
+		p.skipBalanced('(', ')')
+		if p.peek(0) == ')' {
+			p.pos++
+		}
+	} else {
+		p.nested(func() { p.parseList(')') })
+	}
+	word.WriteString(string(p.src[start:min(p.pos, len(p.src))]))
+}
+
+// backtick parses a POSIX `...` command substitution.
+func (p *parser) backtick(word *strings.Builder) {
+	start := p.pos
+	p.pos++
+	var inner strings.Builder
+	for p.pos < len(p.src) && p.src[p.pos] != '`' {
+		if p.src[p.pos] == '\\' && p.peek(1) == '`' {
+			p.pos++
+		}
+		inner.WriteRune(p.src[p.pos])
+		p.pos++
+	}
+	if p.pos < len(p.src) {
+		p.pos++
+	}
+	word.WriteString(string(p.src[start:p.pos]))
+	if p.depth >= maxDepth {
+		return
+	}
+	sub := &parser{src: []rune(inner.String()), dialect: p.dialect, depth: p.depth + 1}
+	sub.parseList(0)
+	p.out = append(p.out, sub.out...)
+}
+
+func (p *parser) skipBlockComment() {
+	p.pos += 2
+	for p.pos < len(p.src) {
+		if p.src[p.pos] == '#' && p.peek(1) == '>' {
+			p.pos += 2
+			return
+		}
+		p.pos++
+	}
+}
+
+func trimReserved(args []string, d Dialect) []string {
+	if d == PowerShell {
+		return args
+	}
+	for len(args) > 0 && reservedWords[args[0]] {
+		args = args[1:]
+	}
+	if len(args) > 0 && loopWords[args[0]] {
+		return nil
+	}
+	return args
+}
+
+func escapedPowerShellRune(r rune) rune {
+	switch r {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	default:
+		return r
+	}
+}
+
+func isDescriptor(s string) bool {
+	if s == "*" {
+		return true
+	}
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !isDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == 0
+}
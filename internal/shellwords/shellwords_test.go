@@ -0,0 +1,122 @@
+package shellwords
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandsPOSIX(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line string
+		want [][]string
+	}{
+		{`ls -la`, [][]string{{"ls", "-la"}}},
+		{`echo "a b" 'c d' e\ f`, [][]string{{"echo", "a b", "c d", "e f"}}},
+		{`echo "" x`, [][]string{{"echo", "", "x"}}},
+		{`echo hi | env`, [][]string{{"echo", "hi"}, {"env"}}},
+		{`make && make test || echo failed; true & wait`, [][]string{{"make"}, {"make", "test"}, {"echo", "failed"}, {"true"}, {"wait"}}},
+		{`grep "a|b" file`, [][]string{{"grep", "a|b", "file"}}},
+		{`echo "$(printenv HOME)" done`, [][]string{{"printenv", "HOME"}, {"echo", "$(printenv HOME)", "done"}}},
+		{"echo `whoami`", [][]string{{"whoami"}, {"echo", "`whoami`"}}},
+		{`(cd app; make) && { go test ./...; }`, [][]string{{"cd", "app"}, {"make"}, {"go", "test", "./..."}}},
+		{`diff <(sort a) b`, [][]string{{"sort", "a"}, {"diff", "<(sort a)", "b"}}},
+		{`kubectl get pods > pods.txt 2>&1`, [][]string{{"kubectl", "get", "pods"}}},
+		{`cat <<EOF >out`, [][]string{{"cat"}}},
+		{`echo $((1 + 2))`, [][]string{{"echo", "$((1 + 2))"}}},
+		{`if grep -q x f; then printenv; fi`, [][]string{{"grep", "-q", "x", "f"}, {"printenv"}}},
+		{`for f in a b; do rm "$f"; done`, [][]string{{"rm", "$f"}}},
+		{"ls # list files\npwd", [][]string{{"ls"}, {"pwd"}}},
+		{"terraform plan \\\n  -out tfplan", [][]string{{"terraform", "plan", "-out", "tfplan"}}},
+		{`echo "unterminated`, [][]string{{"echo", "unterminated"}}},
+	}
+	for _, tt := range tests {
+		if got := Commands(tt.line, POSIX); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Commands(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCommandsPowerShell(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line string
+		want [][]string
+	}{
+		{`Get-ChildItem C:\Users\ops | Select-Object Name`, [][]string{{"Get-ChildItem", `C:\Users\ops`}, {"Select-Object", "Name"}}},
+		{`& 'C:\Program Files\tool.exe' --flag 'it''s'`, [][]string{{`C:\Program Files\tool.exe`, "--flag", "it's"}}},
+		{"Write-Host \"a`\"b\" \"c\"\"d\"", [][]string{{"Write-Host", `a"b`, `c"d`}}},
+		{`Write-Output "$(Get-Date)"; kubectl get pods 2>$null`, [][]string{{"Get-Date"}, {"Write-Output", "$(Get-Date)"}, {"kubectl", "get", "pods"}}},
+		{`git pull && dotnet build <# inline #> -c Release`, [][]string{{"git", "pull"}, {"dotnet", "build", "-c", "Release"}}},
+		{`1..3 | ForEach-Object { Write-Host $_ }`, [][]string{{"1..3"}, {"ForEach-Object"}, {"Write-Host", "$_"}}},
+	}
+	for _, tt := range tests {
+		if got := Commands(tt.line, PowerShell); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Commands(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCommandsDepthLimit(t *testing.T) {
+	t.Parallel()
+
+	line := "echo "
+	for i := 0; i < 100; i++ {
+		line += "$("
+	}
+	line += "printenv"
+	// Must terminate without unbounded recursion.
+	_ = Commands(line, POSIX)
+}
+
+func TestStripWrappers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"sudo", "printenv"}, []string{"printenv"}},
+		{[]string{"sudo", "-u", "deploy", "-E", "--", "kubectl", "get", "pods"}, []string{"kubectl", "get", "pods"}},
+		{[]string{"env", "-i", "FOO=1", "BAR=2", "make"}, []string{"make"}},
+		{[]string{"FOO=1", "make", "build"}, []string{"make", "build"}},
+		{[]string{"time", "-p", "nice", "-n", "10", "nohup", "./backup.sh"}, []string{"./backup.sh"}},
+		{[]string{"timeout", "-s", "KILL", "30s", "curl", "x"}, []string{"curl", "x"}},
+		{[]string{"nice", "-10", "tar", "czf", "a.tgz", "."}, []string{"tar", "czf", "a.tgz", "."}},
+		{[]string{"/usr/bin/sudo", "env"}, []string{"env"}},
+		{[]string{"env"}, []string{"env"}},
+		{[]string{"env", "FOO=1"}, []string{"env", "FOO=1"}},
+		{[]string{"sudo", "-l"}, []string{"sudo", "-l"}},
+		{[]string{"kubectl", "get", "pods"}, []string{"kubectl", "get", "pods"}},
+	}
+	for _, tt := range tests {
+		if got := StripWrappers(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("StripWrappers(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestInlineScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		script  string
+		dialect Dialect
+		ok      bool
+	}{
+		{[]string{"bash", "-c", "echo hi | env"}, "echo hi | env", POSIX, true},
+		{[]string{"/bin/sh", "-ec", "printenv"}, "printenv", POSIX, true},
+		{[]string{"pwsh", "-NoProfile", "-Command", "Get-ChildItem", "Env:"}, "Get-ChildItem Env:", PowerShell, true},
+		{[]string{"bash", "script.sh", "-c"}, "", "", false},
+		{[]string{"python3", "-c", "print(1)"}, "", "", false},
+	}
+	for _, tt := range tests {
+		script, dialect, ok := InlineScript(tt.args)
+		if script != tt.script || dialect != tt.dialect || ok != tt.ok {
+			t.Errorf("InlineScript(%q) = %q, %q, %v", tt.args, script, dialect, ok)
+		}
+	}
+}
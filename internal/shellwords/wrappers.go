@@ -0,0 +1,155 @@
+package shellwords
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// wrapper describes a command that runs another command given after its own
+// options, like sudo or nice.
+type wrapper struct {
+	// valueOpts are short or long options that take a separate argument.
+	valueOpts map[string]bool
+	// positional is the number of operands before the command (timeout's
+	// duration).
+	positional int
+	// assignments allows NAME=value operands before the command (env).
+	assignments bool
+}
+
+func opts(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+var wrappers = map[string]wrapper{
+	"sudo":    {valueOpts: opts("-u", "-g", "-h", "-p", "-C", "-U", "-r", "-t", "-D", "-T", "--user", "--group", "--host", "--prompt", "--close-from", "--other-user", "--role", "--type", "--chdir", "--command-timeout")},
+	"doas":    {valueOpts: opts("-u", "-C")},
+	"env":     {valueOpts: opts("-u", "-C", "-S", "--unset", "--chdir", "--split-string"), assignments: true},
+	"time":    {valueOpts: opts("-f", "-o", "--format", "--output")},
+	"nice":    {valueOpts: opts("-n", "--adjustment")},
+	"ionice":  {valueOpts: opts("-c", "-n", "-p", "-P", "-u", "--class", "--classdata")},
+	"nohup":   {},
+	"command": {},
+	"builtin": {},
+	"exec":    {valueOpts: opts("-a")},
+	"stdbuf":  {valueOpts: opts("-i", "-o", "-e")},
+	"timeout": {valueOpts: opts("-s", "-k", "--signal", "--kill-after"), positional: 1},
+	"xargs":   {valueOpts: opts("-I", "-n", "-P", "-d", "-L", "-E", "-s", "-a", "--max-args", "--max-procs", "--delimiter", "--arg-file")},
+}
+
+// Program returns the lower-case base name of a program without a Windows
+// executable extension.
+func Program(arg string) string {
+	name := strings.ToLower(filepath.Base(strings.ReplaceAll(arg, `\`, "/")))
+	for _, ext := range []string{".exe", ".cmd", ".bat", ".ps1"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// StripWrappers removes leading NAME=value assignments and wrappers such as
+// sudo, env, time, nice, nohup and timeout, with their options, and returns
+// the command they run. A wrapper without a command (a bare `env` or
+// `sudo -l`) is returned unchanged so callers still see it.
+func StripWrappers(args []string) []string {
+	for {
+		rest := args
+		for len(rest) > 0 && isAssignment(rest[0]) {
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return args
+		}
+		w, ok := wrappers[Program(rest[0])]
+		if !ok {
+			return rest
+		}
+		inner := skipWrapperArgs(rest[1:], w)
+		if len(inner) == 0 {
+			return rest
+		}
+		args = inner
+	}
+}
+
+func skipWrapperArgs(args []string, w wrapper) []string {
+	positional := w.positional
+	for len(args) > 0 {
+		arg := args[0]
+		switch {
+		case arg == "--":
+			args = args[1:]
+			for ; positional > 0 && len(args) > 0; positional-- {
+				args = args[1:]
+			}
+			return args
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			args = args[1:]
+			name, _, hasValue := strings.Cut(arg, "=")
+			if !hasValue && w.valueOpts[name] && len(args) > 0 {
+				args = args[1:]
+			}
+		case w.assignments && isAssignment(arg):
+			args = args[1:]
+		case positional > 0:
+			args = args[1:]
+			positional--
+		default:
+			return args
+		}
+	}
+	return nil
+}
+
+func isAssignment(arg string) bool {
+	name, _, ok := strings.Cut(arg, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// shells maps interpreters to the dialect of the script they take with -c
+// (or -Command).
+var shells = map[string]Dialect{
+	"sh": POSIX, "bash": POSIX, "zsh": POSIX, "dash": POSIX, "ksh": POSIX, "ash": POSIX,
+	"pwsh": PowerShell, "powershell": PowerShell,
+}
+
+// InlineScript returns the script passed to a shell with -c (bash -c '...',
+// pwsh -Command ...) and its dialect.
+func InlineScript(args []string) (string, Dialect, bool) {
+	if len(args) < 2 {
+		return "", "", false
+	}
+	dialect, ok := shells[Program(args[0])]
+	if !ok {
+		return "", "", false
+	}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if dialect == PowerShell {
+			if lower := strings.ToLower(arg); lower == "-c" || lower == "-command" {
+				return strings.Join(args[i+1:], " "), dialect, i+1 < len(args)
+			}
+			continue
+		}
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			return "", "", false
+		}
+		// Short options may be combined: bash -ec '...'.
+		if !strings.HasPrefix(arg, "--") && strings.Contains(arg, "c") && i+1 < len(args) {
+			return args[i+1], dialect, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	archivePrefix = "sessions-"
+	archiveSuffix = ".jsonl.gz"
+	archiveMonth  = "2006-01"
+)
+
+var archiveNamePattern = regexp.MustCompile(`^sessions-\d{4}-\d{2}\.jsonl\.gz$`)
+
+// CompactResult reports what Compact moved out of the live sessions file.
+type CompactResult struct {
+	Archived int
+	Archives []string
+}
+
+// SetAutoCompact controls whether StopSession rotates sessions from earlier
+// months into archives.
+func (s *JSONStore) SetAutoCompact(enabled bool) {
+	s.autoCompact = enabled
+}
+
+// Compact moves completed sessions that ended before the month of now from
+// sessions.jsonl into compressed monthly archives.
+func (s *JSONStore) Compact(_ context.Context, now time.Time) (CompactResult, error) {
+	if err := s.requireInitialized(); err != nil {
+		return CompactResult{}, err
+	}
+
+	var result CompactResult
+	err := s.withActiveStateLock(func() error {
+		var err error
+		result, err = s.compactLocked(now)
+		return err
+	})
+	return result, err
+}
+
+func (s *JSONStore) compactLocked(now time.Time) (CompactResult, error) {
+	sessions, err := readSessionsFile(s.sessionsPath)
+	if err != nil {
+		if errors.Is(err, ErrNoSessions) {
+			return CompactResult{}, nil
+		}
+		return CompactResult{}, err
+	}
+
+	current := now.UTC().Format(archiveMonth)
+	byArchive := make(map[string][]Session)
+	kept := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		month := sessionMonth(session)
+		if month >= current {
+			kept = append(kept, session)
+			continue
+		}
+		name := archivePrefix + month + archiveSuffix
+		byArchive[name] = append(byArchive[name], session)
+	}
+	if len(byArchive) == 0 {
+		return CompactResult{}, nil
+	}
+
+	names := make([]string, 0, len(byArchive))
+	for name := range byArchive {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Archives are written before the live file is trimmed, so an interruption
+	// leaves duplicates (which readers drop) rather than losing sessions.
+	result := CompactResult{Archives: names}
+	for _, name := range names {
+		path := filepath.Join(s.rootPath, name)
+		existing, err := readSessionsFile(path)
+		if err != nil && !errors.Is(err, ErrNoSessions) {
+			return CompactResult{}, err
+		}
+		moved := byArchive[name]
+		incoming := make(map[string]bool, len(moved))
+		for _, session := range moved {
+			incoming[session.ID] = true
+		}
+		merged := make([]Session, 0, len(existing)+len(moved))
+		for _, session := range existing {
+			if !incoming[session.ID] {
+				merged = append(merged, session)
+			}
+		}
+		merged = append(merged, moved...)
+		if err := writeSessionsFileAtomic(path, merged); err != nil {
+			return CompactResult{}, fmt.Errorf("write archive %s: %w", name, err)
+		}
+		result.Archived += len(moved)
+	}
+
+	if err := writeSessionsFileAtomic(s.sessionsPath, kept); err != nil {
+		return CompactResult{}, fmt.Errorf("rewrite sessions file: %w", err)
+	}
+	return result, nil
+}
+
+// rotateLocked compacts the live file when its oldest session belongs to an
+// earlier month than now. Only the first record is decoded to keep
+// StopSession cheap.
+func (s *JSONStore) rotateLocked(now time.Time) error {
+	first, err := readFirstSession(s.sessionsPath)
+	if err != nil || first == nil {
+		return err
+	}
+	if sessionMonth(*first) >= now.UTC().Format(archiveMonth) {
+		return nil
+	}
+	_, err = s.compactLocked(now)
+	return err
+}
+
+// segments lists archive files oldest first, followed by sessions.jsonl.
+func (s *JSONStore) segments() ([]string, error) {
+	entries, err := os.ReadDir(s.rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("read store directory: %w", err)
+	}
+	segments := make([]string, 0, len(entries)+1)
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && archiveNamePattern.MatchString(entry.Name()) {
+			segments = append(segments, filepath.Join(s.rootPath, entry.Name()))
+		}
+	}
+	sort.Strings(segments)
+	return append(segments, s.sessionsPath), nil
+}
+
+func (s *JSONStore) lastArchivedSession() (*Session, error) {
+	segments, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(segments) - 2; i >= 0; i-- {
+		sessions, err := readSessionsFile(segments[i])
+		if err != nil {
+			if errors.Is(err, ErrNoSessions) {
+				continue
+			}
+			return nil, err
+		}
+		if len(sessions) > 0 {
+			session := sessions[len(sessions)-1]
+			return &session, nil
+		}
+	}
+	return nil, ErrNoSessions
+}
+
+func readFirstSession(path string) (*Session, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open sessions file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSessionRecordBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal([]byte(line), &session); err != nil {
+			return nil, fmt.Errorf("decode session: %w", err)
+		}
+		return &session, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan sessions file: %w", err)
+	}
+	return nil, nil
+}
+
+func sessionMonth(session Session) string {
+	if session.EndedAt != nil {
+		return session.EndedAt.UTC().Format(archiveMonth)
+	}
+	return session.StartedAt.UTC().Format(archiveMonth)
+}
+
+func isArchivePath(path string) bool {
+	return strings.HasSuffix(path, archiveSuffix)
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJSONStoreCompactArchivesOldMonths(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	root := newRetryTempDir(t)
+	s := NewJSONStore(root)
+	if err := s.Init(ctx); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	// Sessions are added directly so that StopSession does not rotate them.
+	var added []Session
+	for _, started := range []time.Time{
+		time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 20, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 5, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+	} {
+		end := started.Add(time.Hour)
+		added = append(added, Session{ID: NewSessionID(started), Title: started.Format("Jan 2"), StartedAt: started, EndedAt: &end})
+	}
+	if err := s.ReplaceSessions(ctx, nil, added); err != nil {
+		t.Fatalf("seed sessions failed: %v", err)
+	}
+
+	result, err := s.Compact(ctx, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if result.Archived != 3 || !equalStrings(result.Archives, []string{"sessions-2026-01.jsonl.gz", "sessions-2026-02.jsonl.gz"}) {
+		t.Fatalf("unexpected compact result: %+v", result)
+	}
+	for _, name := range result.Archives {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Fatalf("expected archive %s: %v", name, err)
+		}
+	}
+	live, err := readSessionsFile(filepath.Join(root, "sessions.jsonl"))
+	if err != nil || len(live) != 1 || live[0].ID != added[3].ID {
+		t.Fatalf("expected only the March session to stay live, got %+v (%v)", live, err)
+	}
+
+	sessions, err := s.ListSessions(ctx, 0)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(sessions) != 4 || sessions[0].ID != added[3].ID || sessions[3].ID != added[0].ID {
+		t.Fatalf("expected archived sessions to be listed newest first, got %+v", sessions)
+	}
+	if _, err := s.SessionByID(ctx, added[1].ID); err != nil {
+		t.Fatalf("expected archived session lookup to succeed: %v", err)
+	}
+
+	updated, err := s.UpdateSession(ctx, added[0].ID, func(session *Session) error {
+		session.Tags = []string{"archived"}
+		return nil
+	})
+	if err != nil || !updated.HasTag("archived") {
+		t.Fatalf("update archived session failed: %+v (%v)", updated, err)
+	}
+	got, err := s.SessionByID(ctx, added[0].ID)
+	if err != nil || !got.HasTag("archived") {
+		t.Fatalf("expected archived update to persist, got %+v (%v)", got, err)
+	}
+
+	if err := s.ReplaceSessions(ctx, []string{added[2].ID}, nil); err != nil {
+		t.Fatalf("remove archived session failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "sessions-2026-02.jsonl.gz")); !os.IsNotExist(err) {
+		t.Fatalf("expected empty archive to be removed, got %v", err)
+	}
+
+	result, err = s.Compact(ctx, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil || result.Archived != 0 {
+		t.Fatalf("expected second compact to be a no-op, got %+v (%v)", result, err)
+	}
+}
+
+func TestJSONStoreStopSessionRotatesPreviousMonth(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	root := newRetryTempDir(t)
+	s := NewJSONStore(root)
+	if err := s.Init(ctx); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	january := time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC)
+	old, err := s.StartSession(ctx, "january", "", january, nil)
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if _, err := s.StopSession(ctx, january.Add(30*time.Minute)); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	february := time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)
+	if _, err := s.StartSession(ctx, "february", "", february, nil); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	current, err := s.StopSession(ctx, february.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	archived, err := readSessionsFile(filepath.Join(root, "sessions-2026-01.jsonl.gz"))
+	if err != nil || len(archived) != 1 || archived[0].ID != old.ID {
+		t.Fatalf("expected January session in archive, got %+v (%v)", archived, err)
+	}
+	last, err := s.LastSession(ctx)
+	if err != nil || last.ID != current.ID {
+		t.Fatalf("expected last session %s, got %+v (%v)", current.ID, last, err)
+	}
+
+	if err := s.ReplaceSessions(ctx, []string{current.ID}, nil); err != nil {
+		t.Fatalf("remove live session failed: %v", err)
+	}
+	last, err = s.LastSession(ctx)
+	if err != nil || last.ID != old.ID {
+		t.Fatalf("expected LastSession to fall back to archives, got %+v (%v)", last, err)
+	}
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ProjectDirName is the directory that marks a project-local store.
+	ProjectDirName = ".commandry"
+	// HomeEnvVar overrides the store directory for every command.
+	HomeEnvVar = "CMDRY_HOME"
+)
+
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceProject = "project"
+	SourceUser    = "user"
+)
+
+// Location describes which store directory is in effect and why.
+type Location struct {
+	Dir    string
+	Source string
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s (%s)", l.Dir, l.Source)
+}
+
+// ResolveLocation picks the store directory using this precedence:
+// explicit override, CMDRY_HOME, the nearest .commandry directory above
+// workingDir, and finally the user config directory.
+func ResolveLocation(override, workingDir string) (Location, error) {
+	if override = strings.TrimSpace(override); override != "" {
+		dir, err := filepath.Abs(override)
+		if err != nil {
+			return Location{}, fmt.Errorf("resolve store path: %w", err)
+		}
+		return Location{Dir: dir, Source: SourceFlag}, nil
+	}
+	if env := strings.TrimSpace(os.Getenv(HomeEnvVar)); env != "" {
+		dir, err := filepath.Abs(env)
+		if err != nil {
+			return Location{}, fmt.Errorf("resolve %s: %w", HomeEnvVar, err)
+		}
+		return Location{Dir: dir, Source: SourceEnv}, nil
+	}
+	if workingDir != "" {
+		if dir, ok := FindProjectStore(workingDir); ok {
+			return Location{Dir: dir, Source: SourceProject}, nil
+		}
+	}
+	dir, err := DefaultRootDir()
+	if err != nil {
+		return Location{}, err
+	}
+	return Location{Dir: dir, Source: SourceUser}, nil
+}
+
+// FindProjectStore walks up from start looking for a .commandry directory.
+func FindProjectStore(start string) (string, bool) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", false
+	}
+	for {
+		candidate := filepath.Join(dir, ProjectDirName)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
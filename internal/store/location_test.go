@@ -0,0 +1,57 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveLocationPrecedence(t *testing.T) {
+	base := t.TempDir()
+	project := filepath.Join(base, "repo")
+	nested := filepath.Join(project, "services", "api")
+	if err := os.MkdirAll(filepath.Join(project, ProjectDirName), 0o755); err != nil {
+		t.Fatalf("mkdir project store: %v", err)
+	}
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("mkdir nested: %v", err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("APPDATA", filepath.Join(base, "config"))
+	t.Setenv(HomeEnvVar, "")
+
+	loc, err := ResolveLocation("", nested)
+	if err != nil {
+		t.Fatalf("ResolveLocation failed: %v", err)
+	}
+	if loc.Source != SourceProject || loc.Dir != filepath.Join(project, ProjectDirName) {
+		t.Fatalf("expected project store, got %+v", loc)
+	}
+
+	loc, err = ResolveLocation("", base)
+	if err != nil {
+		t.Fatalf("ResolveLocation failed: %v", err)
+	}
+	if loc.Source != SourceUser {
+		t.Fatalf("expected user store outside project, got %+v", loc)
+	}
+
+	envDir := filepath.Join(base, "env-store")
+	t.Setenv(HomeEnvVar, envDir)
+	loc, err = ResolveLocation("", nested)
+	if err != nil {
+		t.Fatalf("ResolveLocation failed: %v", err)
+	}
+	if loc.Source != SourceEnv || loc.Dir != envDir {
+		t.Fatalf("expected env store, got %+v", loc)
+	}
+
+	flagDir := filepath.Join(base, "flag-store")
+	loc, err = ResolveLocation(flagDir, nested)
+	if err != nil {
+		t.Fatalf("ResolveLocation failed: %v", err)
+	}
+	if loc.Source != SourceFlag || loc.Dir != flagDir {
+		t.Fatalf("expected flag store, got %+v", loc)
+	}
+}
@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a SessionStore that keeps sessions in process memory. It is
+// always initialized and is safe for concurrent use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	active    *Session
+	completed []Session
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Init(context.Context) error {
+	return nil
+}
+
+func (s *MemoryStore) IsInitialized(context.Context) (bool, error) {
+	return true, nil
+}
+
+// RootDir is empty because nothing is persisted.
+func (s *MemoryStore) RootDir() string {
+	return ""
+}
+
+func (s *MemoryStore) StartSession(_ context.Context, title, env string, startedAt time.Time, setup func(*Session) error) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active != nil {
+		return nil, ErrActiveSessionExists
+	}
+	session := &Session{
+		ID:        NewSessionID(startedAt),
+		Title:     strings.TrimSpace(title),
+		Env:       strings.TrimSpace(env),
+		StartedAt: startedAt.UTC(),
+		Steps:     []Step{},
+	}
+	if setup != nil {
+		if err := setup(session); err != nil {
+			return nil, err
+		}
+	}
+	s.active = session
+	return cloneSession(s.active), nil
+}
+
+func (s *MemoryStore) GetActiveSession(context.Context) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return nil, ErrNoActiveSession
+	}
+	return cloneSession(s.active), nil
+}
+
+func (s *MemoryStore) AddStep(_ context.Context, step Step) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return ErrNoActiveSession
+	}
+	s.active.appendStep(step)
+	return nil
+}
+
+func (s *MemoryStore) StopSession(_ context.Context, endedAt time.Time) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return nil, ErrNoActiveSession
+	}
+	end := endedAt.UTC()
+	s.active.EndedAt = &end
+	s.completed = append(s.completed, *s.active)
+	stopped := s.active
+	s.active = nil
+	return cloneSession(stopped), nil
+}
+
+func (s *MemoryStore) LastSession(context.Context) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.completed) == 0 {
+		return nil, ErrNoSessions
+	}
+	return cloneSession(&s.completed[len(s.completed)-1]), nil
+}
+
+func (s *MemoryStore) ListSessions(_ context.Context, limit int) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.completed) == 0 {
+		return nil, ErrNoSessions
+	}
+	if limit <= 0 || limit > len(s.completed) {
+		limit = len(s.completed)
+	}
+	result := make([]Session, 0, limit)
+	for i := len(s.completed) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, *cloneSession(&s.completed[i]))
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) SessionByID(_ context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.completed) == 0 {
+		return nil, ErrNoSessions
+	}
+	for i := len(s.completed) - 1; i >= 0; i-- {
+		if s.completed[i].ID == id {
+			return cloneSession(&s.completed[i]), nil
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+func (s *MemoryStore) UpdateSession(_ context.Context, id string, fn func(*Session) error) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.active
+	if target == nil || target.ID != id {
+		target = nil
+		for i := len(s.completed) - 1; i >= 0; i-- {
+			if s.completed[i].ID == id {
+				target = &s.completed[i]
+				break
+			}
+		}
+	}
+	if target == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	updated := cloneSession(target)
+	if err := fn(updated); err != nil {
+		return nil, err
+	}
+	*target = *cloneSession(updated)
+	return updated, nil
+}
+
+func (s *MemoryStore) ReplaceSessions(_ context.Context, removeIDs []string, add []Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remove := make(map[string]bool, len(removeIDs))
+	for _, id := range removeIDs {
+		remove[id] = true
+	}
+	found := make(map[string]bool, len(removeIDs))
+	existing := make(map[string]bool, len(s.completed))
+	kept := make([]Session, 0, len(s.completed)+len(add))
+	for _, session := range s.completed {
+		if remove[session.ID] {
+			found[session.ID] = true
+			continue
+		}
+		existing[session.ID] = true
+		kept = append(kept, session)
+	}
+	for _, id := range removeIDs {
+		if !found[id] {
+			return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+		}
+	}
+	for i := range add {
+		if existing[add[i].ID] {
+			return fmt.Errorf("%w: %s", ErrDuplicateSessionID, add[i].ID)
+		}
+		existing[add[i].ID] = true
+		kept = append(kept, *cloneSession(&add[i]))
+	}
+	s.completed = kept
+	return nil
+}
+
+// cloneSession copies the slices and maps of a session so callers cannot
+// mutate stored state.
+func cloneSession(session *Session) *Session {
+	clone := cloneSessionHeader(*session)
+	clone.ID = session.ID
+	if session.EndedAt != nil {
+		end := *session.EndedAt
+		clone.EndedAt = &end
+	}
+	clone.Steps = append([]Step{}, session.Steps...)
+	return &clone
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreLifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := NewMemoryStore()
+	base := time.Date(2026, 4, 2, 9, 0, 0, 0, time.UTC)
+
+	if _, err := s.LastSession(ctx); !errors.Is(err, ErrNoSessions) {
+		t.Fatalf("expected ErrNoSessions, got %v", err)
+	}
+	started, err := s.StartSession(ctx, " deploy ", "prod", base, nil)
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if _, err := s.StartSession(ctx, "again", "", base, nil); !errors.Is(err, ErrActiveSessionExists) {
+		t.Fatalf("expected ErrActiveSessionExists, got %v", err)
+	}
+	if err := s.AddStep(ctx, Step{Timestamp: base, Command: "make deploy"}); err != nil {
+		t.Fatalf("add step failed: %v", err)
+	}
+
+	active, err := s.GetActiveSession(ctx)
+	if err != nil {
+		t.Fatalf("get active failed: %v", err)
+	}
+	active.Steps[0].Command = "mutated"
+
+	stopped, err := s.StopSession(ctx, base.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if stopped.ID != started.ID || stopped.Title != "deploy" || len(stopped.Steps) != 1 || stopped.Steps[0].Command != "make deploy" {
+		t.Fatalf("unexpected stopped session: %+v", stopped)
+	}
+
+	if _, err := s.UpdateSession(ctx, started.ID, func(session *Session) error {
+		session.Tags = []string{"release"}
+		return nil
+	}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	got, err := s.SessionByID(ctx, started.ID)
+	if err != nil || !got.HasTag("release") {
+		t.Fatalf("expected updated session, got %+v (%v)", got, err)
+	}
+
+	end := base.Add(time.Hour)
+	if err := s.ReplaceSessions(ctx, []string{started.ID}, []Session{{ID: "new", Title: "new", StartedAt: base, EndedAt: &end}}); err != nil {
+		t.Fatalf("replace failed: %v", err)
+	}
+	sessions, err := s.ListSessions(ctx, 0)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != "new" {
+		t.Fatalf("unexpected sessions after replace: %+v (%v)", sessions, err)
+	}
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NewSessionID returns a session id in the same format StartSession uses.
+func NewSessionID(t time.Time) string {
+	return fmt.Sprintf("%d", t.UnixNano())
+}
+
+// MergeSessions combines completed sessions into a new session whose steps are
+// interleaved by timestamp. Steps with equal timestamps keep input order.
+func MergeSessions(id, title string, sessions []Session) (Session, error) {
+	if len(sessions) < 2 {
+		return Session{}, errors.New("merge needs at least two sessions")
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return Session{}, errors.New("merged session title cannot be empty")
+	}
+
+	merged := Session{
+		ID:                id,
+		Title:             title,
+		Env:               sessions[0].Env,
+		StartedAt:         sessions[0].StartedAt,
+		PolicyFingerprint: sessions[0].PolicyFingerprint,
+	}
+	var endedAt time.Time
+	var steps []Step
+
+	for _, session := range sessions {
+		if session.EndedAt == nil {
+			return Session{}, fmt.Errorf("session %s is still recording", session.ID)
+		}
+		if session.Env != merged.Env {
+			merged.Env = ""
+		}
+		if session.PolicyFingerprint != merged.PolicyFingerprint {
+			// The first step says which policy the merged session started with.
+			merged.PolicyFingerprint = ""
+		}
+		if session.StartedAt.Before(merged.StartedAt) {
+			merged.StartedAt = session.StartedAt
+		}
+		if session.EndedAt.After(endedAt) {
+			endedAt = *session.EndedAt
+		}
+		for _, tag := range session.Tags {
+			if !merged.HasTag(tag) {
+				merged.Tags = append(merged.Tags, tag)
+			}
+		}
+		for key, value := range session.Meta {
+			if merged.Meta == nil {
+				merged.Meta = make(map[string]string, len(session.Meta))
+			}
+			if _, ok := merged.Meta[key]; !ok {
+				merged.Meta[key] = value
+			}
+		}
+		steps = append(steps, resolvedSteps(session, 0, len(session.Steps))...)
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Timestamp.Before(steps[j].Timestamp)
+	})
+	merged.Steps = make([]Step, 0, len(steps))
+	for _, step := range steps {
+		merged.appendStep(step)
+	}
+	end := endedAt.UTC()
+	merged.EndedAt = &end
+	return merged, nil
+}
+
+// SplitSession cuts a completed session into two sessions; the second one
+// starts with step atStep (1-based).
+func SplitSession(session Session, atStep int, firstID, secondID string) (Session, Session, error) {
+	if session.EndedAt == nil {
+		return Session{}, Session{}, fmt.Errorf("session %s is still recording", session.ID)
+	}
+	if atStep < 2 || atStep > len(session.Steps) {
+		return Session{}, Session{}, fmt.Errorf("split step must be between 2 and %d", len(session.Steps))
+	}
+
+	cut := atStep - 1
+	boundary := session.Steps[cut].Timestamp.UTC()
+
+	first := cloneSessionHeader(session)
+	first.ID = firstID
+	first.Title = session.Title + " (1/2)"
+	first.EndedAt = &boundary
+	for _, step := range resolvedSteps(session, 0, cut) {
+		first.appendStep(step)
+	}
+
+	second := cloneSessionHeader(session)
+	second.ID = secondID
+	second.Title = session.Title + " (2/2)"
+	second.StartedAt = boundary
+	end := session.EndedAt.UTC()
+	second.EndedAt = &end
+	second.PolicyFingerprint = session.PolicyFingerprintAt(cut)
+	for _, step := range resolvedSteps(session, cut, len(session.Steps)) {
+		second.appendStep(step)
+	}
+
+	return first, second, nil
+}
+
+// resolvedSteps copies steps [from, to) of session with the policy
+// fingerprint in effect spelled out on each, ready to be appended to another
+// session.
+func resolvedSteps(session Session, from, to int) []Step {
+	steps := make([]Step, 0, to-from)
+	for i := from; i < to; i++ {
+		step := session.Steps[i]
+		step.PolicyFingerprint = session.PolicyFingerprintAt(i)
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func cloneSessionHeader(session Session) Session {
+	clone := Session{
+		Title:             session.Title,
+		Env:               session.Env,
+		StartedAt:         session.StartedAt,
+		PolicyFingerprint: session.PolicyFingerprint,
+	}
+	if len(session.Tags) > 0 {
+		clone.Tags = append([]string(nil), session.Tags...)
+	}
+	if len(session.Meta) > 0 {
+		clone.Meta = make(map[string]string, len(session.Meta))
+		for key, value := range session.Meta {
+			clone.Meta[key] = value
+		}
+	}
+	return clone
+}
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMergeSessionsInterleavesSteps(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	endA := base.Add(10 * time.Minute)
+	endB := base.Add(20 * time.Minute)
+	a := Session{
+		ID: "a", Title: "a", Env: "prod", Tags: []string{"incident"}, Meta: map[string]string{"ticket": "INC-1"},
+		StartedAt: base.Add(time.Minute), EndedAt: &endA,
+		Steps: []Step{
+			{Timestamp: base.Add(2 * time.Minute), Command: "a1"},
+			{Timestamp: base.Add(5 * time.Minute), Command: "a2"},
+		},
+	}
+	b := Session{
+		ID: "b", Title: "b", Env: "prod", Tags: []string{"db", "incident"}, Meta: map[string]string{"ticket": "INC-2", "owner": "sre"},
+		StartedAt: base, EndedAt: &endB,
+		Steps: []Step{
+			{Timestamp: base.Add(3 * time.Minute), Command: "b1"},
+			{Timestamp: base.Add(5 * time.Minute), Command: "b2"},
+		},
+	}
+
+	merged, err := MergeSessions("m", "Incident", []Session{a, b})
+	if err != nil {
+		t.Fatalf("MergeSessions failed: %v", err)
+	}
+	var order []string
+	for _, step := range merged.Steps {
+		order = append(order, step.Command)
+	}
+	if got, want := order, []string{"a1", "b1", "a2", "b2"}; !equalStrings(got, want) {
+		t.Fatalf("step order = %v, want %v", got, want)
+	}
+	if !merged.StartedAt.Equal(base) || merged.EndedAt == nil || !merged.EndedAt.Equal(endB) {
+		t.Fatalf("unexpected merged bounds: %v - %v", merged.StartedAt, merged.EndedAt)
+	}
+	if merged.Env != "prod" || !equalStrings(merged.Tags, []string{"incident", "db"}) {
+		t.Fatalf("unexpected merged labels: env=%q tags=%v", merged.Env, merged.Tags)
+	}
+	if merged.Meta["ticket"] != "INC-1" || merged.Meta["owner"] != "sre" {
+		t.Fatalf("unexpected merged meta: %v", merged.Meta)
+	}
+
+	b.Env = "staging"
+	merged, err = MergeSessions("m", "Incident", []Session{a, b})
+	if err != nil {
+		t.Fatalf("MergeSessions failed: %v", err)
+	}
+	if merged.Env != "" {
+		t.Fatalf("expected env to be cleared for mixed envs, got %q", merged.Env)
+	}
+
+	a.EndedAt = nil
+	if _, err := MergeSessions("m", "Incident", []Session{a, b}); err == nil {
+		t.Fatalf("expected error for active session")
+	}
+}
+
+func TestSplitSession(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	end := base.Add(time.Hour)
+	session := Session{
+		ID: "s", Title: "Big", StartedAt: base, EndedAt: &end, Tags: []string{"x"},
+		Steps: []Step{
+			{Timestamp: base.Add(time.Minute), Command: "one"},
+			{Timestamp: base.Add(2 * time.Minute), Command: "two"},
+			{Timestamp: base.Add(3 * time.Minute), Command: "three"},
+		},
+	}
+
+	first, second, err := SplitSession(session, 3, "f", "g")
+	if err != nil {
+		t.Fatalf("SplitSession failed: %v", err)
+	}
+	if len(first.Steps) != 2 || len(second.Steps) != 1 || second.Steps[0].Command != "three" {
+		t.Fatalf("unexpected split: %d/%d", len(first.Steps), len(second.Steps))
+	}
+	if first.Title != "Big (1/2)" || second.Title != "Big (2/2)" {
+		t.Fatalf("unexpected titles: %q / %q", first.Title, second.Title)
+	}
+	if !first.EndedAt.Equal(base.Add(3*time.Minute)) || !second.StartedAt.Equal(base.Add(3*time.Minute)) || !second.EndedAt.Equal(end) {
+		t.Fatalf("unexpected split bounds")
+	}
+	first.Tags[0] = "mutated"
+	if session.Tags[0] != "x" {
+		t.Fatalf("split must not share tag storage with the original")
+	}
+
+	for _, at := range []int{0, 1, 4} {
+		if _, _, err := SplitSession(session, at, "f", "g"); err == nil {
+			t.Fatalf("expected error for at-step %d", at)
+		}
+	}
+}
+
+func TestMergeAndSplitKeepPolicyFingerprints(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	end := base.Add(time.Hour)
+	// Fingerprints are stored only where the policy changed.
+	a := Session{
+		ID: "a", Title: "a", StartedAt: base, EndedAt: &end, PolicyFingerprint: "aaaa",
+		Steps: []Step{
+			{Timestamp: base.Add(1 * time.Minute), Command: "a1"},
+			{Timestamp: base.Add(4 * time.Minute), Command: "a2", PolicyFingerprint: "cccc"},
+			{Timestamp: base.Add(5 * time.Minute), Command: "a3"},
+		},
+	}
+	b := Session{
+		ID: "b", Title: "b", StartedAt: base, EndedAt: &end, PolicyFingerprint: "bbbb",
+		Steps: []Step{
+			{Timestamp: base.Add(2 * time.Minute), Command: "b1"},
+			{Timestamp: base.Add(3 * time.Minute), Command: "b2"},
+		},
+	}
+	fingerprints := func(s Session) []string {
+		var out []string
+		for i, step := range s.Steps {
+			out = append(out, step.Command+"="+s.PolicyFingerprintAt(i))
+		}
+		return out
+	}
+
+	merged, err := MergeSessions("m", "Merged", []Session{a, b})
+	if err != nil {
+		t.Fatalf("MergeSessions failed: %v", err)
+	}
+	want := "[a1=aaaa b1=bbbb b2=bbbb a2=cccc a3=cccc]"
+	if got := fmt.Sprint(fingerprints(merged)); got != want || merged.PolicyFingerprint != "aaaa" {
+		t.Fatalf("merged fingerprints = %s (session %q), want %s", got, merged.PolicyFingerprint, want)
+	}
+	if merged.Steps[2].PolicyFingerprint != "" || merged.Steps[4].PolicyFingerprint != "" {
+		t.Fatalf("unchanged fingerprints should not be repeated on steps: %+v", merged.Steps)
+	}
+
+	first, second, err := SplitSession(a, 3, "f", "g")
+	if err != nil {
+		t.Fatalf("SplitSession failed: %v", err)
+	}
+	if got := fmt.Sprint(fingerprints(first)); got != "[a1=aaaa a2=cccc]" || first.PolicyFingerprint != "aaaa" {
+		t.Fatalf("first half fingerprints = %s (session %q)", got, first.PolicyFingerprint)
+	}
+	if got := fmt.Sprint(fingerprints(second)); got != "[a3=cccc]" || second.PolicyFingerprint != "cccc" || second.Steps[0].PolicyFingerprint != "" {
+		t.Fatalf("second half fingerprints = %s (session %q, step %q)", got, second.PolicyFingerprint, second.Steps[0].PolicyFingerprint)
+	}
+}
+
+func TestJSONStoreReplaceSessions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	root := newRetryTempDir(t)
+	s := NewJSONStore(root)
+	if err := s.Init(ctx); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	base := time.Date(2026, 3, 3, 8, 0, 0, 0, time.UTC)
+	var ids []string
+	for i := 0; i < 2; i++ {
+		session, err := s.StartSession(ctx, "s", "", base.Add(time.Duration(i)*time.Minute), nil)
+		if err != nil {
+			t.Fatalf("start failed: %v", err)
+		}
+		if _, err := s.StopSession(ctx, base.Add(time.Duration(i)*time.Minute+time.Second)); err != nil {
+			t.Fatalf("stop failed: %v", err)
+		}
+		ids = append(ids, session.ID)
+	}
+
+	end := base.Add(time.Hour)
+	added := Session{ID: "new", Title: "new", StartedAt: base, EndedAt: &end}
+	if err := s.ReplaceSessions(ctx, []string{ids[0]}, []Session{added}); err != nil {
+		t.Fatalf("ReplaceSessions failed: %v", err)
+	}
+
+	sessions, err := s.ListSessions(ctx, 0)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "new" || sessions[1].ID != ids[1] {
+		t.Fatalf("unexpected sessions after replace: %+v", sessions)
+	}
+
+	if err := s.ReplaceSessions(ctx, []string{"missing"}, nil); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+	if err := s.ReplaceSessions(ctx, nil, []Session{added}); !errors.Is(err, ErrDuplicateSessionID) {
+		t.Fatalf("expected ErrDuplicateSessionID, got %v", err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
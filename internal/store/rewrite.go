@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// BackupsDirName holds copies of store files taken before bulk rewrites.
+const BackupsDirName = "backups"
+
+// RewriteResult reports what RewriteSessions changed.
+type RewriteResult struct {
+	// Sessions is the number of sessions fn changed.
+	Sessions int
+	// BackupDir holds the original copies of the rewritten files; it is empty
+	// when nothing was written.
+	BackupDir string
+}
+
+// RewriteSessions calls fn for every stored session, the active one included,
+// and persists the sessions for which fn returns true. The files about to be
+// replaced are first copied to backups/<name>-<timestamp>/, then each file is
+// replaced atomically.
+func (s *JSONStore) RewriteSessions(_ context.Context, name string, now time.Time, fn func(*Session) bool) (RewriteResult, error) {
+	if err := s.requireInitialized(); err != nil {
+		return RewriteResult{}, err
+	}
+
+	var result RewriteResult
+	err := s.withActiveStateLock(func() error {
+		segments, err := s.segments()
+		if err != nil {
+			return err
+		}
+
+		type rewrite struct {
+			path     string
+			sessions []Session
+			active   *Session
+		}
+		var pending []rewrite
+		for _, path := range segments {
+			sessions, err := readSessionsFile(path)
+			if err != nil {
+				if errors.Is(err, ErrNoSessions) {
+					continue
+				}
+				return err
+			}
+			changed := 0
+			for i := range sessions {
+				if fn(&sessions[i]) {
+					changed++
+				}
+			}
+			if changed > 0 {
+				result.Sessions += changed
+				pending = append(pending, rewrite{path: path, sessions: sessions})
+			}
+		}
+
+		active, err := s.readActive()
+		if err != nil && !errors.Is(err, ErrNoActiveSession) {
+			return err
+		}
+		if active != nil && fn(active) {
+			result.Sessions++
+			pending = append(pending, rewrite{path: s.activeStatePath, active: active})
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		backupDir := filepath.Join(s.rootPath, BackupsDirName, name+"-"+now.UTC().Format("20060102T150405Z"))
+		if err := os.MkdirAll(backupDir, 0o700); err != nil {
+			return fmt.Errorf("create backup directory: %w", err)
+		}
+		for _, item := range pending {
+			if err := copyFile(item.path, filepath.Join(backupDir, filepath.Base(item.path))); err != nil {
+				return fmt.Errorf("back up %s: %w", filepath.Base(item.path), err)
+			}
+		}
+		result.BackupDir = backupDir
+
+		for _, item := range pending {
+			if item.active != nil {
+				err = s.writeJSONAtomic(item.path, item.active)
+			} else {
+				err = writeSessionsFileAtomic(item.path, item.sessions)
+			}
+			if err != nil {
+				return fmt.Errorf("rewrite %s: %w", filepath.Base(item.path), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return RewriteResult{}, err
+	}
+	return result, nil
+}
+
+func copyFile(src, dst string) error {
+	payload, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, payload, 0o600)
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJSONStoreRewriteSessionsBacksUpChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	root := newRetryTempDir(t)
+	s := NewJSONStore(root)
+	if err := s.Init(ctx); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	var added []Session
+	for _, started := range []time.Time{
+		time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+	} {
+		end := started.Add(time.Hour)
+		added = append(added, Session{ID: NewSessionID(started), Title: "secret", StartedAt: started, EndedAt: &end})
+	}
+	if err := s.ReplaceSessions(ctx, nil, added); err != nil {
+		t.Fatalf("seed sessions failed: %v", err)
+	}
+	if _, err := s.Compact(ctx, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if _, err := s.StartSession(ctx, "secret", "", time.Now().UTC(), nil); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	result, err := s.RewriteSessions(ctx, "test", now, func(*Session) bool { return false })
+	if err != nil || result.Sessions != 0 || result.BackupDir != "" {
+		t.Fatalf("no-op rewrite = %+v (%v)", result, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, BackupsDirName)); !os.IsNotExist(err) {
+		t.Fatalf("no-op rewrite must not create backups: %v", err)
+	}
+
+	result, err = s.RewriteSessions(ctx, "test", now, func(session *Session) bool {
+		if session.ID == added[1].ID {
+			return false
+		}
+		session.Title = "renamed"
+		return true
+	})
+	if err != nil {
+		t.Fatalf("RewriteSessions failed: %v", err)
+	}
+	wantBackup := filepath.Join(root, BackupsDirName, "test-20260320T120000Z")
+	if result.Sessions != 2 || result.BackupDir != wantBackup {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	for _, name := range []string{"sessions-2026-01.jsonl.gz", "active_session.json"} {
+		if _, err := os.Stat(filepath.Join(wantBackup, name)); err != nil {
+			t.Fatalf("expected backup of %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(wantBackup, "sessions.jsonl")); !os.IsNotExist(err) {
+		t.Fatalf("unchanged sessions.jsonl must not be backed up: %v", err)
+	}
+
+	archived, err := s.SessionByID(ctx, added[0].ID)
+	if err != nil || archived.Title != "renamed" {
+		t.Fatalf("archived session not rewritten: %+v (%v)", archived, err)
+	}
+	live, err := s.SessionByID(ctx, added[1].ID)
+	if err != nil || live.Title != "secret" {
+		t.Fatalf("live session changed: %+v (%v)", live, err)
+	}
+	active, err := s.GetActiveSession(ctx)
+	if err != nil || active.Title != "renamed" {
+		t.Fatalf("active session not rewritten: %+v (%v)", active, err)
+	}
+	if original, err := readSessionsFile(filepath.Join(wantBackup, "sessions-2026-01.jsonl.gz")); err != nil || original[0].Title != "secret" {
+		t.Fatalf("backup should hold the original archive: %+v (%v)", original, err)
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// SessionSource tells where a stored session was read from.
+type SessionSource struct {
+	Path string `json:"path"`
+	// Line is the 1-based line of the session in Path, counted after
+	// decompression for archives. It is 0 for the active session, which is a
+	// single JSON document.
+	Line int `json:"line,omitempty"`
+}
+
+// EachSession calls fn for every stored session: archives oldest first, then
+// sessions.jsonl, then the active session. It stops at the first error fn
+// returns.
+func (s *JSONStore) EachSession(_ context.Context, fn func(src SessionSource, session *Session) error) error {
+	if err := s.requireInitialized(); err != nil {
+		return err
+	}
+
+	segments, err := s.segments()
+	if err != nil {
+		return err
+	}
+	for _, path := range segments {
+		err := eachSessionInFile(path, func(line int, session Session) error {
+			return fn(SessionSource{Path: path, Line: line}, &session)
+		})
+		if err != nil && !errors.Is(err, ErrNoSessions) {
+			return err
+		}
+	}
+
+	active, err := s.readActive()
+	if err != nil {
+		if errors.Is(err, ErrNoActiveSession) {
+			return nil
+		}
+		return err
+	}
+	return fn(SessionSource{Path: s.activeStatePath}, active)
+}
@@ -2,10 +2,13 @@ package store
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -21,6 +24,7 @@ var (
 	ErrNoActiveSession     = errors.New("no active session")
 	ErrNoSessions          = errors.New("no completed sessions")
 	ErrSessionNotFound     = errors.New("session not found")
+	ErrDuplicateSessionID  = errors.New("session id already exists")
 )
 
 const maxSessionRecordBytes = 32 * 1024 * 1024
@@ -29,13 +33,17 @@ type SessionStore interface {
 	Init(ctx context.Context) error
 	IsInitialized(ctx context.Context) (bool, error)
 	RootDir() string
-	StartSession(ctx context.Context, title, env string, startedAt time.Time) (*Session, error)
+	// StartSession begins the active session. setup, if not nil, fills in the
+	// new session before it is written; when it fails no session is started.
+	StartSession(ctx context.Context, title, env string, startedAt time.Time, setup func(*Session) error) (*Session, error)
 	GetActiveSession(ctx context.Context) (*Session, error)
 	AddStep(ctx context.Context, step Step) error
 	StopSession(ctx context.Context, endedAt time.Time) (*Session, error)
 	LastSession(ctx context.Context) (*Session, error)
 	ListSessions(ctx context.Context, limit int) ([]Session, error)
 	SessionByID(ctx context.Context, id string) (*Session, error)
+	UpdateSession(ctx context.Context, id string, fn func(*Session) error) (*Session, error)
+	ReplaceSessions(ctx context.Context, removeIDs []string, add []Session) error
 }
 
 type JSONStore struct {
@@ -43,6 +51,7 @@ type JSONStore struct {
 	configPath      string
 	sessionsPath    string
 	activeStatePath string
+	autoCompact     bool
 }
 
 func DefaultRootDir() (string, error) {
@@ -59,6 +68,7 @@ func NewJSONStore(rootPath string) *JSONStore {
 		configPath:      filepath.Join(rootPath, "config.yaml"),
 		sessionsPath:    filepath.Join(rootPath, "sessions.jsonl"),
 		activeStatePath: filepath.Join(rootPath, "active_session.json"),
+		autoCompact:     true,
 	}
 }
 
@@ -66,6 +76,11 @@ func (s *JSONStore) RootDir() string {
 	return s.rootPath
 }
 
+// SetRootDir points the store at a different root directory.
+func (s *JSONStore) SetRootDir(rootPath string) {
+	*s = *NewJSONStore(rootPath)
+}
+
 func (s *JSONStore) Init(_ context.Context) error {
 	if err := os.MkdirAll(s.rootPath, 0o700); err != nil {
 		return fmt.Errorf("create root directory: %w", err)
@@ -94,7 +109,7 @@ func (s *JSONStore) IsInitialized(_ context.Context) (bool, error) {
 	return !info.IsDir(), nil
 }
 
-func (s *JSONStore) StartSession(_ context.Context, title, env string, startedAt time.Time) (*Session, error) {
+func (s *JSONStore) StartSession(_ context.Context, title, env string, startedAt time.Time, setup func(*Session) error) (*Session, error) {
 	if err := s.requireInitialized(); err != nil {
 		return nil, err
 	}
@@ -110,12 +125,17 @@ func (s *JSONStore) StartSession(_ context.Context, title, env string, startedAt
 		}
 
 		session := &Session{
-			ID:        fmt.Sprintf("%d", startedAt.UnixNano()),
+			ID:        NewSessionID(startedAt),
 			Title:     strings.TrimSpace(title),
 			Env:       strings.TrimSpace(env),
 			StartedAt: startedAt.UTC(),
 			Steps:     make([]Step, 0, 8),
 		}
+		if setup != nil {
+			if err := setup(session); err != nil {
+				return err
+			}
+		}
 
 		if err := s.writeJSONAtomic(s.activeStatePath, session); err != nil {
 			return fmt.Errorf("write active session: %w", err)
@@ -152,7 +172,7 @@ func (s *JSONStore) AddStep(_ context.Context, step Step) error {
 			return err
 		}
 
-		session.Steps = append(session.Steps, step)
+		session.appendStep(step)
 		if err := s.writeJSONAtomic(s.activeStatePath, session); err != nil {
 			return fmt.Errorf("persist active session: %w", err)
 		}
@@ -184,6 +204,12 @@ func (s *JSONStore) StopSession(_ context.Context, endedAt time.Time) (*Session,
 			return fmt.Errorf("remove active state: %w", err)
 		}
 
+		// The session is already saved; a failed rotation is retried on the
+		// next stop or by `cmdry store compact`.
+		if s.autoCompact {
+			_ = s.rotateLocked(end)
+		}
+
 		stopped = session
 		return nil
 	}); err != nil {
@@ -201,7 +227,7 @@ func (s *JSONStore) LastSession(_ context.Context) (*Session, error) {
 	file, err := os.Open(s.sessionsPath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil, ErrNoSessions
+			return s.lastArchivedSession()
 		}
 		return nil, fmt.Errorf("open sessions file: %w", err)
 	}
@@ -220,7 +246,7 @@ func (s *JSONStore) LastSession(_ context.Context) (*Session, error) {
 		return nil, fmt.Errorf("scan sessions file: %w", err)
 	}
 	if lastLine == "" {
-		return nil, ErrNoSessions
+		return s.lastArchivedSession()
 	}
 
 	var session Session
@@ -272,6 +298,130 @@ func (s *JSONStore) SessionByID(_ context.Context, id string) (*Session, error)
 	return nil, ErrSessionNotFound
 }
 
+// UpdateSession applies fn to the active or completed session with the given id
+// and persists the result. Completed sessions are rewritten in place.
+func (s *JSONStore) UpdateSession(_ context.Context, id string, fn func(*Session) error) (*Session, error) {
+	if err := s.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	var updated *Session
+	if err := s.withActiveStateLock(func() error {
+		active, err := s.readActive()
+		if err != nil && !errors.Is(err, ErrNoActiveSession) {
+			return err
+		}
+		if active != nil && active.ID == id {
+			if err := fn(active); err != nil {
+				return err
+			}
+			if err := s.writeJSONAtomic(s.activeStatePath, active); err != nil {
+				return fmt.Errorf("persist active session: %w", err)
+			}
+			updated = active
+			return nil
+		}
+
+		segments, err := s.segments()
+		if err != nil {
+			return err
+		}
+		for i := len(segments) - 1; i >= 0; i-- {
+			sessions, err := readSessionsFile(segments[i])
+			if err != nil {
+				if errors.Is(err, ErrNoSessions) {
+					continue
+				}
+				return err
+			}
+			for j := len(sessions) - 1; j >= 0; j-- {
+				if sessions[j].ID != id {
+					continue
+				}
+				if err := fn(&sessions[j]); err != nil {
+					return err
+				}
+				if err := writeSessionsFileAtomic(segments[i], sessions); err != nil {
+					return fmt.Errorf("rewrite sessions file: %w", err)
+				}
+				session := sessions[j]
+				updated = &session
+				return nil
+			}
+		}
+		return ErrSessionNotFound
+	}); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// ReplaceSessions atomically removes the completed sessions listed in removeIDs
+// and appends add to the completed sessions file.
+func (s *JSONStore) ReplaceSessions(_ context.Context, removeIDs []string, add []Session) error {
+	if err := s.requireInitialized(); err != nil {
+		return err
+	}
+
+	return s.withActiveStateLock(func() error {
+		segments, err := s.segments()
+		if err != nil {
+			return err
+		}
+
+		remove := make(map[string]bool, len(removeIDs))
+		for _, id := range removeIDs {
+			remove[id] = true
+		}
+		found := make(map[string]bool, len(removeIDs))
+		existing := make(map[string]bool)
+		rewritten := make([][]Session, len(segments))
+		changed := make([]bool, len(segments))
+		for i, path := range segments {
+			sessions, err := readSessionsFile(path)
+			if err != nil && !errors.Is(err, ErrNoSessions) {
+				return err
+			}
+			kept := make([]Session, 0, len(sessions))
+			for _, session := range sessions {
+				if remove[session.ID] {
+					found[session.ID] = true
+					changed[i] = true
+					continue
+				}
+				existing[session.ID] = true
+				kept = append(kept, session)
+			}
+			rewritten[i] = kept
+		}
+		for _, id := range removeIDs {
+			if !found[id] {
+				return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+			}
+		}
+		live := len(segments) - 1
+		for _, session := range add {
+			if existing[session.ID] {
+				return fmt.Errorf("%w: %s", ErrDuplicateSessionID, session.ID)
+			}
+			existing[session.ID] = true
+			rewritten[live] = append(rewritten[live], session)
+			changed[live] = true
+		}
+
+		for i, path := range segments {
+			if !changed[i] {
+				continue
+			}
+			if err := writeSessionsFileAtomic(path, rewritten[i]); err != nil {
+				return fmt.Errorf("rewrite sessions file: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
 func (s *JSONStore) ensureConfigFile() error {
 	_, err := os.Stat(s.configPath)
 	if err == nil {
@@ -306,6 +456,13 @@ func (s *JSONStore) ensureConfigFile() error {
 capture:
   include_stdout: false
   include_stderr: false
+  record_cwd: true
+export:
+  output_dir: runbooks
+hooks:
+  ignore: []
+retention:
+  auto_compact: true
 `
 	return os.WriteFile(s.configPath, []byte(defaultConfig), 0o600)
 }
@@ -365,34 +522,153 @@ func (s *JSONStore) appendCompleted(session *Session) error {
 	return nil
 }
 
+// readAllSessions returns completed sessions from the archive segments (oldest
+// first) followed by the live sessions file.
 func (s *JSONStore) readAllSessions() ([]Session, error) {
-	file, err := os.Open(s.sessionsPath)
+	segments, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	var all []Session
+	found := false
+	for _, path := range segments {
+		sessions, err := readSessionsFile(path)
+		if err != nil {
+			if errors.Is(err, ErrNoSessions) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		all = append(all, sessions...)
+	}
+	if !found {
+		return nil, ErrNoSessions
+	}
+	return dedupeSessions(all), nil
+}
+
+// dedupeSessions keeps only the last copy of each session id. Copies only
+// appear if compaction stopped between writing an archive and the live file.
+func dedupeSessions(sessions []Session) []Session {
+	seen := make(map[string]bool, len(sessions))
+	keep := make([]bool, len(sessions))
+	dupes := 0
+	for i := len(sessions) - 1; i >= 0; i-- {
+		if seen[sessions[i].ID] {
+			dupes++
+			continue
+		}
+		seen[sessions[i].ID] = true
+		keep[i] = true
+	}
+	if dupes == 0 {
+		return sessions
+	}
+	out := make([]Session, 0, len(sessions)-dupes)
+	for i := range sessions {
+		if keep[i] {
+			out = append(out, sessions[i])
+		}
+	}
+	return out
+}
+
+// readSessionsFile decodes one JSONL segment; archives are gzip-compressed.
+func readSessionsFile(path string) ([]Session, error) {
+	sessions := make([]Session, 0, 32)
+	err := eachSessionInFile(path, func(_ int, session Session) error {
+		sessions = append(sessions, session)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// eachSessionInFile calls fn for every session of a segment. A missing
+// segment yields ErrNoSessions.
+func eachSessionInFile(path string, fn func(line int, session Session) error) error {
+	file, err := os.Open(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil, ErrNoSessions
+			return ErrNoSessions
 		}
-		return nil, fmt.Errorf("open sessions file: %w", err)
+		return fmt.Errorf("open sessions file: %w", err)
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	var reader io.Reader = file
+	if isArchivePath(path) {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("open archive %s: %w", filepath.Base(path), err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	return scanSessions(reader, fn)
+}
+
+// scanSessions decodes one session per line of r and passes it to fn with its
+// 1-based line number.
+func scanSessions(r io.Reader, fn func(line int, session Session) error) error {
+	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, 64*1024), maxSessionRecordBytes)
-	sessions := make([]Session, 0, 32)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
 			continue
 		}
 		var session Session
-		if err := json.Unmarshal([]byte(line), &session); err != nil {
-			return nil, fmt.Errorf("decode session: %w", err)
+		if err := json.Unmarshal([]byte(text), &session); err != nil {
+			return fmt.Errorf("decode session: %w", err)
+		}
+		if err := fn(line, session); err != nil {
+			return err
 		}
-		sessions = append(sessions, session)
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("scan sessions file: %w", err)
+		return fmt.Errorf("scan sessions file: %w", err)
 	}
-	return sessions, nil
+	return nil
+}
+
+// writeSessionsFileAtomic replaces a segment with sessions. Archives are
+// compressed, and an archive left without sessions is removed.
+func writeSessionsFileAtomic(path string, sessions []Session) error {
+	archive := isArchivePath(path)
+	if archive && len(sessions) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove empty archive: %w", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if archive {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	for i := range sessions {
+		payload, err := json.Marshal(&sessions[i])
+		if err != nil {
+			return fmt.Errorf("marshal session: %w", err)
+		}
+		if _, err := w.Write(append(payload, '\n')); err != nil {
+			return fmt.Errorf("encode session: %w", err)
+		}
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("compress archive: %w", err)
+		}
+	}
+	return writeFileAtomic(path, buf.Bytes())
 }
 
 func (s *JSONStore) writeJSONAtomic(path string, value any) error {
@@ -400,7 +676,10 @@ func (s *JSONStore) writeJSONAtomic(path string, value any) error {
 	if err != nil {
 		return fmt.Errorf("marshal json: %w", err)
 	}
+	return writeFileAtomic(path, payload)
+}
 
+func writeFileAtomic(path string, payload []byte) error {
 	dir := filepath.Dir(path)
 	base := filepath.Base(path)
 
@@ -18,7 +18,7 @@ func TestJSONStoreLifecycle(t *testing.T) {
 	root := newRetryTempDir(t)
 	s := NewJSONStore(root)
 
-	_, err := s.StartSession(ctx, "deploy", "", time.Now().UTC())
+	_, err := s.StartSession(ctx, "deploy", "", time.Now().UTC(), nil)
 	if !errors.Is(err, ErrNotInitialized) {
 		t.Fatalf("expected ErrNotInitialized, got %v", err)
 	}
@@ -36,7 +36,7 @@ func TestJSONStoreLifecycle(t *testing.T) {
 	}
 
 	startedAt := time.Date(2026, 2, 3, 12, 0, 0, 0, time.UTC)
-	session, err := s.StartSession(ctx, "Deploy to staging", "staging", startedAt)
+	session, err := s.StartSession(ctx, "Deploy to staging", "staging", startedAt, nil)
 	if err != nil {
 		t.Fatalf("start session failed: %v", err)
 	}
@@ -105,6 +105,37 @@ func TestJSONStoreLifecycle(t *testing.T) {
 	}
 }
 
+func TestStartSessionSetupIsAllOrNothing(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	jsonStore := NewJSONStore(newRetryTempDir(t))
+	if err := jsonStore.Init(ctx); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	startedAt := time.Date(2026, 2, 3, 12, 0, 0, 0, time.UTC)
+	for name, s := range map[string]SessionStore{"json": jsonStore, "memory": NewMemoryStore()} {
+		failed := errors.New("setup failed")
+		if _, err := s.StartSession(ctx, "Deploy", "prod", startedAt, func(*Session) error { return failed }); !errors.Is(err, failed) {
+			t.Fatalf("%s: expected the setup error, got %v", name, err)
+		}
+		if _, err := s.GetActiveSession(ctx); !errors.Is(err, ErrNoActiveSession) {
+			t.Fatalf("%s: a failed setup must not leave an active session: %v", name, err)
+		}
+
+		if _, err := s.StartSession(ctx, "Deploy", "prod", startedAt, func(started *Session) error {
+			started.Tags = []string{"release"}
+			started.PolicyFingerprint = "3f2a91c0be44"
+			return nil
+		}); err != nil {
+			t.Fatalf("%s: start failed: %v", name, err)
+		}
+		active, err := s.GetActiveSession(ctx)
+		if err != nil || len(active.Tags) != 1 || active.PolicyFingerprint != "3f2a91c0be44" {
+			t.Fatalf("%s: setup was not stored with the session: %+v (%v)", name, active, err)
+		}
+	}
+}
 func TestJSONStoreListSessionsAndByID(t *testing.T) {
 	t.Parallel()
 
@@ -119,7 +150,7 @@ func TestJSONStoreListSessionsAndByID(t *testing.T) {
 	for i := 0; i < 3; i++ {
 		start := base.Add(time.Duration(i) * time.Minute)
 		title := "Session " + string(rune('A'+i))
-		session, err := s.StartSession(ctx, title, "", start)
+		session, err := s.StartSession(ctx, title, "", start, nil)
 		if err != nil {
 			t.Fatalf("start session %d failed: %v", i, err)
 		}
@@ -173,7 +204,7 @@ func TestJSONStoreLastSessionLargeRecord(t *testing.T) {
 	}
 
 	start := time.Date(2026, 2, 7, 12, 0, 0, 0, time.UTC)
-	if _, err := s.StartSession(ctx, "Large session", "", start); err != nil {
+	if _, err := s.StartSession(ctx, "Large session", "", start, nil); err != nil {
 		t.Fatalf("start session failed: %v", err)
 	}
 
@@ -217,7 +248,7 @@ func TestJSONStoreAddStepConcurrentNoLostUpdates(t *testing.T) {
 	}
 
 	start := time.Date(2026, 2, 20, 12, 0, 0, 0, time.UTC)
-	if _, err := s.StartSession(ctx, "Concurrent steps", "", start); err != nil {
+	if _, err := s.StartSession(ctx, "Concurrent steps", "", start, nil); err != nil {
 		t.Fatalf("start session failed: %v", err)
 	}
 
@@ -251,6 +282,67 @@ func TestJSONStoreAddStepConcurrentNoLostUpdates(t *testing.T) {
 	}
 }
 
+func TestJSONStoreUpdateSession(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	root := newRetryTempDir(t)
+	s := NewJSONStore(root)
+	if err := s.Init(ctx); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	first, err := s.StartSession(ctx, "first", "", base, nil)
+	if err != nil {
+		t.Fatalf("start first failed: %v", err)
+	}
+	if _, err := s.StopSession(ctx, base.Add(time.Minute)); err != nil {
+		t.Fatalf("stop first failed: %v", err)
+	}
+	second, err := s.StartSession(ctx, "second", "", base.Add(2*time.Minute), nil)
+	if err != nil {
+		t.Fatalf("start second failed: %v", err)
+	}
+
+	active, err := s.UpdateSession(ctx, second.ID, func(session *Session) error {
+		session.Tags = append(session.Tags, "incident")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("update active failed: %v", err)
+	}
+	if !active.HasTag("INCIDENT") {
+		t.Fatalf("expected active session tag, got %+v", active.Tags)
+	}
+
+	if _, err := s.UpdateSession(ctx, first.ID, func(session *Session) error {
+		session.Meta = map[string]string{"ticket": "CHG-1"}
+		return nil
+	}); err != nil {
+		t.Fatalf("update completed failed: %v", err)
+	}
+	got, err := s.SessionByID(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("session by id failed: %v", err)
+	}
+	if got.Meta["ticket"] != "CHG-1" || got.EndedAt == nil {
+		t.Fatalf("unexpected completed session after update: %+v", got)
+	}
+
+	stopped, err := s.StopSession(ctx, base.Add(3*time.Minute))
+	if err != nil {
+		t.Fatalf("stop second failed: %v", err)
+	}
+	if !stopped.HasTag("incident") {
+		t.Fatalf("expected tag to survive stop, got %+v", stopped.Tags)
+	}
+
+	if _, err := s.UpdateSession(ctx, "missing", func(*Session) error { return nil }); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+}
+
 func intPtr(v int) *int {
 	return &v
 }
@@ -278,3 +370,43 @@ func newRetryTempDir(t *testing.T) string {
 
 	return dir
 }
+
+func TestJSONStoreKeepsPolicyFingerprintOnlyWhenItChanges(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := NewJSONStore(t.TempDir())
+	if err := s.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	base := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	if _, err := s.StartSession(ctx, "fingerprints", "", base, nil); err != nil {
+		t.Fatalf("StartSession failed: %v", err)
+	}
+	for i, fp := range []string{"aaaa", "aaaa", "bbbb", "bbbb", "aaaa", ""} {
+		if err := s.AddStep(ctx, Step{Timestamp: base.Add(time.Duration(i) * time.Second), Command: "true", PolicyFingerprint: fp}); err != nil {
+			t.Fatalf("AddStep failed: %v", err)
+		}
+	}
+	session, err := s.StopSession(ctx, base.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("StopSession failed: %v", err)
+	}
+
+	if session.PolicyFingerprint != "aaaa" {
+		t.Fatalf("first step should set the session fingerprint: %+v", session)
+	}
+	var stored []string
+	for _, step := range session.Steps {
+		stored = append(stored, step.PolicyFingerprint)
+	}
+	if strings.Join(stored, ",") != ",,bbbb,,aaaa," {
+		t.Fatalf("unexpected step fingerprints: %q", stored)
+	}
+	if got := session.PolicyFingerprintAt(3); got != "bbbb" {
+		t.Fatalf("PolicyFingerprintAt(3) = %q", got)
+	}
+	if got := session.PolicyFingerprintAt(5); got != "aaaa" {
+		t.Fatalf("PolicyFingerprintAt(5) = %q", got)
+	}
+}
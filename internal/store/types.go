@@ -1,22 +1,89 @@
 package store
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Step struct {
 	Timestamp  time.Time `json:"timestamp"`
 	Command    string    `json:"command"`
 	Status     string    `json:"status,omitempty"` // OK, FAILED, REDACTED
-	Reason     string    `json:"reason,omitempty"` // nonzero_exit, command_not_found, start_failed, policy_redacted, policy_blocked, unknown
+	Reason     string    `json:"reason,omitempty"` // nonzero_exit, command_not_found, start_failed, policy_redacted, policy_blocked, guard_refused, unknown
 	ExitCode   *int      `json:"exit_code,omitempty"`
 	DurationMS int64     `json:"duration_ms"`
 	CWD        string    `json:"cwd,omitempty"`
+	// Argv is set for steps recorded by `cmdry run`: Command is then the
+	// argument list joined by util.JoinCommand, not a shell command line.
+	Argv bool `json:"argv,omitempty"`
+	// Guard is set when the command matched a dangerous-command rule in a
+	// protected environment.
+	Guard *GuardCheck `json:"guard,omitempty"`
+	// PolicyFingerprint is set when the policy changed since the previous
+	// step; see Session.PolicyFingerprintAt.
+	PolicyFingerprint string `json:"policy_fingerprint,omitempty"`
+}
+
+// Guard decisions.
+const (
+	GuardConfirmed = "confirmed"
+	GuardDeclined  = "declined"
+	// GuardRefused means no terminal was attached to ask for confirmation.
+	GuardRefused = "refused"
+)
+
+// GuardCheck records the confirmation asked for a dangerous command.
+type GuardCheck struct {
+	Rule     string `json:"rule"`
+	Decision string `json:"decision"`
 }
 
 type Session struct {
-	ID        string     `json:"id"`
-	Title     string     `json:"title"`
-	Env       string     `json:"env,omitempty"`
-	StartedAt time.Time  `json:"started_at"`
-	EndedAt   *time.Time `json:"ended_at,omitempty"`
-	Steps     []Step     `json:"steps"`
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	Env       string            `json:"env,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	StartedAt time.Time         `json:"started_at"`
+	EndedAt   *time.Time        `json:"ended_at,omitempty"`
+	// PolicyFingerprint identifies the policy in effect when the session
+	// started.
+	PolicyFingerprint string `json:"policy_fingerprint,omitempty"`
+	Steps             []Step `json:"steps"`
+}
+
+// PolicyFingerprintAt returns the fingerprint of the policy that recorded
+// step i: the latest one set on a step up to i, or the session's.
+func (s *Session) PolicyFingerprintAt(i int) string {
+	for ; i >= 0; i-- {
+		if i < len(s.Steps) && s.Steps[i].PolicyFingerprint != "" {
+			return s.Steps[i].PolicyFingerprint
+		}
+	}
+	return s.PolicyFingerprint
+}
+
+// appendStep adds step, dropping its policy fingerprint when it matches the
+// one already in effect. The first step of a session without a fingerprint
+// sets the session's.
+func (s *Session) appendStep(step Step) {
+	switch fp := step.PolicyFingerprint; {
+	case fp == "":
+	case s.PolicyFingerprint == "" && len(s.Steps) == 0:
+		s.PolicyFingerprint = fp
+		step.PolicyFingerprint = ""
+	case fp == s.PolicyFingerprintAt(len(s.Steps)-1):
+		step.PolicyFingerprint = ""
+	}
+	s.Steps = append(s.Steps, step)
+}
+
+// HasTag reports whether the session carries the given tag (case-insensitive).
+func (s *Session) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,19 @@
+// Package commandry lets Go programs record command sessions and render
+// runbooks without shelling out to the cmdry binary.
+//
+// A Recorder sanitizes each command with a Policy (the same redaction and
+// denylist rules the CLI uses) before appending it as a step to the active
+// session of a SessionStore. Use NewJSONStore to share sessions with the CLI,
+// or NewMemoryStore for tests and short-lived tools. RenderMarkdown produces
+// the same runbook as `cmdry export -f md`.
+//
+// # Compatibility
+//
+// This package follows semantic versioning together with the module: exported
+// identifiers are only removed or changed incompatibly in a new major version.
+// The on-disk format written by JSONStore is shared with the CLI and stays
+// readable by later releases. New methods may be added to SessionStore in
+// minor releases, so embed an existing store rather than implementing the
+// interface from scratch when you need a custom one. Everything under
+// internal/ is outside these guarantees.
+package commandry
@@ -0,0 +1,97 @@
+package commandry_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fixi2/Commandry/pkg/commandry"
+)
+
+func ExampleRecorder() {
+	ctx := context.Background()
+	rec := commandry.NewRecorder(commandry.NewMemoryStore(), commandry.DefaultPolicy())
+
+	if _, err := rec.Start(ctx, "Deploy api", "staging"); err != nil {
+		panic(err)
+	}
+	step, err := rec.Step(ctx, "curl -H 'Authorization: Bearer abc123' https://api.example.com/health", commandry.Result{
+		ExitCode: 0,
+		Duration: 120 * time.Millisecond,
+	})
+	if err != nil {
+		panic(err)
+	}
+	session, err := rec.Stop(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(step.Status, step.Command)
+	fmt.Println(len(session.Steps), "step(s) recorded")
+	// Output:
+	// OK curl -H 'Authorization: Bearer [REDACTED]' https://api.example.com/health
+	// 1 step(s) recorded
+}
+
+func ExampleRenderMarkdown() {
+	ctx := context.Background()
+	rec := commandry.NewRecorder(commandry.NewMemoryStore(), nil)
+
+	if _, err := rec.Start(ctx, "Rotate logs", ""); err != nil {
+		panic(err)
+	}
+	if _, err := rec.Step(ctx, "logrotate -f /etc/logrotate.conf", commandry.Result{}); err != nil {
+		panic(err)
+	}
+	if _, err := rec.Step(ctx, "systemctl status rsyslog", commandry.Result{ExitCode: 3}); err != nil {
+		panic(err)
+	}
+	session, err := rec.Stop(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	runbook := commandry.RenderMarkdown(session)
+	for _, line := range strings.Split(runbook, "\n")[:7] {
+		fmt.Println(line)
+	}
+	// Output:
+	// # Rotate logs
+	//
+	// ## Summary
+	// This runbook was generated from an explicit Commandry session.
+	// Recorded 2 step(s).
+	// Results: OK 1 | FAILED 1 | REDACTED 0
+	// Total duration: 0 ms
+}
+
+func ExampleRenderTemplate() {
+	ctx := context.Background()
+	rec := commandry.NewRecorder(commandry.NewMemoryStore(), nil)
+
+	if _, err := rec.Start(ctx, "Rotate logs", "prod"); err != nil {
+		panic(err)
+	}
+	if _, err := rec.Step(ctx, "logrotate -f /etc/logrotate.conf", commandry.Result{Duration: 1500 * time.Millisecond}); err != nil {
+		panic(err)
+	}
+	session, err := rec.Stop(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	tmpl, err := commandry.ParseTemplate("ticket", "{{upper .Session.Title}}\n{{range .Steps}}- {{.Command}} ({{duration .DurationMS}})\n{{end}}")
+	if err != nil {
+		panic(err)
+	}
+	out, err := commandry.RenderTemplate(session, tmpl, commandry.MarkdownOptions{})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(out)
+	// Output:
+	// ROTATE LOGS
+	// - logrotate -f /etc/logrotate.conf (1.5s)
+}
@@ -0,0 +1,54 @@
+package commandry
+
+import (
+	"text/template"
+
+	"github.com/fixi2/Commandry/internal/export"
+)
+
+// MarkdownOptions adds reviewer comments to a rendered runbook. StepComments
+// is keyed by the step's index in Session.Steps.
+type MarkdownOptions struct {
+	StepComments   map[int][]string
+	GlobalComments []string
+}
+
+func (o MarkdownOptions) export() export.MarkdownOptions {
+	return export.MarkdownOptions{StepComments: o.StepComments, GlobalComments: o.GlobalComments}
+}
+
+// RenderMarkdown renders a session as the runbook produced by
+// `cmdry export -f md`.
+func RenderMarkdown(session *Session) string {
+	return export.RenderMarkdown(session.toStore())
+}
+
+// RenderMarkdownWithOptions renders a session with reviewer comments.
+func RenderMarkdownWithOptions(session *Session, opts MarkdownOptions) string {
+	return export.RenderMarkdownWithOptions(session.toStore(), opts.export())
+}
+
+// ParseTemplate parses a runbook template in Go text/template syntax, with
+// the same helper functions as `cmdry export --template`.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return export.ParseTemplate(name, text)
+}
+
+// DefaultTemplate returns the source of the built-in Markdown template.
+func DefaultTemplate() string {
+	return export.DefaultTemplate()
+}
+
+// RenderTemplate renders a session with tmpl, or with the built-in Markdown
+// template when tmpl is nil.
+func RenderTemplate(session *Session, tmpl *template.Template, opts MarkdownOptions) (string, error) {
+	eopts := opts.export()
+	eopts.Template = tmpl
+	return export.RenderTemplate(session.toStore(), eopts)
+}
+
+// RunbookFilename returns the file name the CLI uses when writing the runbook
+// for session.
+func RunbookFilename(session *Session) string {
+	return export.RunbookFilename(session.toStore())
+}
@@ -0,0 +1,58 @@
+package commandry
+
+import (
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/shellwords"
+)
+
+// Policy decides which parts of a command are redacted and which commands are
+// denied entirely. Get one from DefaultPolicy or LoadPolicy.
+type Policy struct {
+	p *policy.Policy
+}
+
+// Placeholders written into sanitized commands.
+const (
+	RedactedValue     = policy.RedactedValue
+	DeniedPlaceholder = policy.DeniedPlaceholder
+)
+
+// DefaultPolicy returns the built-in redaction and denylist rules.
+func DefaultPolicy() *Policy {
+	return &Policy{p: policy.NewDefault()}
+}
+
+// LoadPolicy reads the policy section of the config.yaml file at path. A
+// missing file yields the default policy. Only that file is read: the system
+// and project policy layers and the env profiles that the CLI applies on top
+// of it are not, so a command may be sanitized differently than by `cmdry run`.
+func LoadPolicy(path string) (*Policy, error) {
+	p, err := policy.LoadFromConfigOrDefault(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{p: p}, nil
+}
+
+// Sanitize returns command, read as a POSIX shell line, as it would be
+// stored, and whether it matched the denylist.
+func (p *Policy) Sanitize(command string) (sanitized string, denied bool) {
+	result := p.p.ApplyLine(command, shellwords.POSIX)
+	return result.Command, result.Denied
+}
+
+// SanitizeCWD applies the rules for working directories to cwd.
+func (p *Policy) SanitizeCWD(cwd string) string {
+	return p.p.RedactCWD(cwd)
+}
+
+// EnforceDenylist reports whether denylisted commands should be blocked
+// before they run rather than only recorded as denied.
+func (p *Policy) EnforceDenylist() bool {
+	return p.p.EnforceDenylist()
+}
+
+// Fingerprint identifies the policy's rules; sessions and steps record it.
+func (p *Policy) Fingerprint() string {
+	return p.p.Fingerprint()
+}
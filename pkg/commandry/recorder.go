@@ -0,0 +1,123 @@
+package commandry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/shellwords"
+)
+
+// Result describes how a command finished.
+type Result struct {
+	// ExitCode is the process exit code; non-zero marks the step as failed.
+	ExitCode int
+	// Duration is how long the command ran.
+	Duration time.Duration
+	// StartedAt defaults to the time Step is called minus Duration.
+	StartedAt time.Time
+	// CWD is the working directory the command ran in (optional). Policy
+	// rules with applies_to: cwd are applied to it.
+	CWD string
+}
+
+// Recorder sanitizes commands with a Policy and appends them to the active
+// session of a SessionStore.
+type Recorder struct {
+	store  SessionStore
+	policy *Policy
+	now    func() time.Time
+}
+
+// NewRecorder returns a Recorder writing to s. A nil policy uses
+// DefaultPolicy.
+func NewRecorder(s SessionStore, p *Policy) *Recorder {
+	if p == nil {
+		p = DefaultPolicy()
+	}
+	return &Recorder{store: s, policy: p, now: time.Now}
+}
+
+// Start begins a new session. It fails with ErrActiveSessionExists if one is
+// already recording.
+func (r *Recorder) Start(ctx context.Context, title, env string) (*Session, error) {
+	if strings.TrimSpace(title) == "" {
+		return nil, errors.New("session title cannot be empty")
+	}
+	session, err := r.store.StartSession(ctx, title, env, r.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	return r.store.UpdateSession(ctx, session.ID, func(started *Session) error {
+		started.PolicyFingerprint = r.policy.Fingerprint()
+		return nil
+	})
+}
+
+// Step sanitizes command and records it with result in the active session.
+// The returned step holds the command exactly as stored.
+func (r *Recorder) Step(ctx context.Context, command string, result Result) (Step, error) {
+	raw := strings.TrimSpace(command)
+	if raw == "" {
+		return Step{}, errors.New("command cannot be empty")
+	}
+
+	sanitized := r.apply(raw)
+	startedAt := result.StartedAt
+	if startedAt.IsZero() {
+		startedAt = r.now().Add(-result.Duration)
+	}
+	step := Step{
+		Timestamp:         startedAt.UTC(),
+		Command:           sanitized.Command,
+		DurationMS:        result.Duration.Milliseconds(),
+		CWD:               r.policy.SanitizeCWD(result.CWD),
+		PolicyFingerprint: r.policy.Fingerprint(),
+	}
+	code := result.ExitCode
+	step.ExitCode = &code
+	switch {
+	case sanitized.Denied:
+		step.Status = StatusRedacted
+		step.Reason = "policy_redacted"
+		step.ExitCode = nil
+	case code == 0:
+		step.Status = StatusOK
+	default:
+		step.Status = StatusFailed
+		step.Reason = "nonzero_exit"
+	}
+
+	if err := r.store.AddStep(ctx, step); err != nil {
+		return Step{}, fmt.Errorf("record step: %w", err)
+	}
+	return step, nil
+}
+
+// Blocked reports whether command matches the denylist and the policy is
+// configured to block denylisted commands before they run.
+func (r *Recorder) Blocked(command string) bool {
+	return r.policy.EnforceDenylist() && r.apply(strings.TrimSpace(command)).Denied
+}
+
+// Stop ends the active session and returns it.
+func (r *Recorder) Stop(ctx context.Context) (*Session, error) {
+	return r.store.StopSession(ctx, r.now().UTC())
+}
+
+// Sanitize returns command as it would be stored, without recording it.
+func (r *Recorder) Sanitize(command string) string {
+	return r.apply(strings.TrimSpace(command)).Command
+}
+
+func (r *Recorder) apply(raw string) policy.Result {
+	return r.policy.p.ApplyLine(raw, shellwords.POSIX)
+}
+
+var (
+	_ SessionStore = (*JSONStore)(nil)
+	_ SessionStore = (*MemoryStore)(nil)
+)
@@ -0,0 +1,117 @@
+package commandry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/policy"
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+func TestRecorderStepAppliesPolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	strict, err := policy.New(policy.Options{EnforceDenylist: true})
+	if err != nil {
+		t.Fatalf("policy.New failed: %v", err)
+	}
+	s := NewMemoryStore()
+	rec := NewRecorder(s, &Policy{p: strict})
+	fixed := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	rec.now = func() time.Time { return fixed }
+
+	if _, err := rec.Step(ctx, "kubectl get pods", Result{}); !errors.Is(err, ErrNoActiveSession) {
+		t.Fatalf("expected ErrNoActiveSession, got %v", err)
+	}
+	if _, err := rec.Start(ctx, "Deploy", "prod"); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	failed, err := rec.Step(ctx, "kubectl rollout status deploy/api", Result{ExitCode: 1, Duration: 2 * time.Second, CWD: "/srv"})
+	if err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if failed.Status != StatusFailed || failed.Reason != "nonzero_exit" || failed.ExitCode == nil || *failed.ExitCode != 1 {
+		t.Fatalf("unexpected failed step: %+v", failed)
+	}
+	if !failed.Timestamp.Equal(fixed.Add(-2*time.Second)) || failed.DurationMS != 2000 || failed.CWD != "/srv" {
+		t.Fatalf("unexpected step metadata: %+v", failed)
+	}
+
+	denied, err := rec.Step(ctx, "printenv", Result{})
+	if err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if denied.Status != StatusRedacted || denied.Command != DeniedPlaceholder || denied.ExitCode != nil {
+		t.Fatalf("unexpected denied step: %+v", denied)
+	}
+	if !rec.Blocked("printenv") || rec.Blocked("kubectl get pods") {
+		t.Fatal("expected only denylisted commands to be blocked")
+	}
+	if got := rec.Sanitize("deploy --password hunter2"); got != "deploy --password "+RedactedValue {
+		t.Fatalf("unexpected sanitized command: %q", got)
+	}
+
+	session, err := rec.Stop(ctx)
+	if err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if len(session.Steps) != 2 || session.Env != "prod" {
+		t.Fatalf("unexpected session: %+v", session)
+	}
+	last, err := s.LastSession(ctx)
+	if err != nil || last.ID != session.ID {
+		t.Fatalf("expected stored session %s, got %+v (%v)", session.ID, last, err)
+	}
+}
+
+func TestRecorderStartRequiresTitle(t *testing.T) {
+	t.Parallel()
+
+	rec := NewRecorder(NewMemoryStore(), nil)
+	if _, err := rec.Start(context.Background(), "  ", ""); err == nil {
+		t.Fatal("expected empty title to be rejected")
+	}
+}
+
+func TestJSONStoreSharesSessionsWithTheCLI(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := NewJSONStore(dir)
+	if err := s.Init(ctx); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	rec := NewRecorder(s, nil)
+	if _, err := rec.Start(ctx, "Deploy", "prod"); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if _, err := rec.Step(ctx, "deploy --password hunter2", Result{ExitCode: 1}); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	session, err := rec.Stop(ctx)
+	if err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if _, err := s.UpdateSession(ctx, session.ID, func(s *Session) error {
+		s.Tags = append(s.Tags, "release")
+		return nil
+	}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	stored, err := store.NewJSONStore(dir).SessionByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("read with the CLI store: %v", err)
+	}
+	if len(stored.Tags) != 1 || stored.Tags[0] != "release" || stored.PolicyFingerprint != session.PolicyFingerprint {
+		t.Fatalf("unexpected stored session: %+v", stored)
+	}
+	if len(stored.Steps) != 1 || stored.Steps[0].Command != "deploy --password "+RedactedValue || stored.Steps[0].Reason != "nonzero_exit" {
+		t.Fatalf("unexpected stored steps: %+v", stored.Steps)
+	}
+}
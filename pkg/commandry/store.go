@@ -0,0 +1,272 @@
+package commandry
+
+import (
+	"context"
+	"time"
+
+	"github.com/fixi2/Commandry/internal/store"
+)
+
+// Session is a recorded session with its ordered steps. It marshals to the
+// same JSON as a line of the CLI's sessions.jsonl.
+type Session struct {
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	Env       string            `json:"env,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	StartedAt time.Time         `json:"started_at"`
+	EndedAt   *time.Time        `json:"ended_at,omitempty"`
+	// PolicyFingerprint identifies the policy in effect when the session
+	// started.
+	PolicyFingerprint string `json:"policy_fingerprint,omitempty"`
+	Steps             []Step `json:"steps"`
+}
+
+// Step is a single sanitized command and its outcome.
+type Step struct {
+	Timestamp time.Time `json:"timestamp"`
+	Command   string    `json:"command"`
+	// Status is one of StatusOK, StatusFailed or StatusRedacted; sessions
+	// recorded by other tools may hold other values.
+	Status string `json:"status,omitempty"`
+	// Reason says why a step did not succeed, for example nonzero_exit or
+	// policy_redacted.
+	Reason     string `json:"reason,omitempty"`
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	CWD        string `json:"cwd,omitempty"`
+	// Argv is set for steps recorded by `cmdry run` from an argument list.
+	Argv bool `json:"argv,omitempty"`
+	// Guard is set when the CLI asked to confirm a dangerous command.
+	Guard *GuardCheck `json:"guard,omitempty"`
+	// PolicyFingerprint is set when the policy changed since the previous
+	// step.
+	PolicyFingerprint string `json:"policy_fingerprint,omitempty"`
+}
+
+// GuardCheck records the confirmation the CLI asked for a dangerous command.
+type GuardCheck struct {
+	Rule     string `json:"rule"`
+	Decision string `json:"decision"`
+}
+
+// SessionStore persists the active session and completed sessions.
+type SessionStore interface {
+	Init(ctx context.Context) error
+	IsInitialized(ctx context.Context) (bool, error)
+	RootDir() string
+	StartSession(ctx context.Context, title, env string, startedAt time.Time) (*Session, error)
+	GetActiveSession(ctx context.Context) (*Session, error)
+	AddStep(ctx context.Context, step Step) error
+	StopSession(ctx context.Context, endedAt time.Time) (*Session, error)
+	LastSession(ctx context.Context) (*Session, error)
+	ListSessions(ctx context.Context, limit int) ([]Session, error)
+	SessionByID(ctx context.Context, id string) (*Session, error)
+	UpdateSession(ctx context.Context, id string, fn func(*Session) error) (*Session, error)
+	ReplaceSessions(ctx context.Context, removeIDs []string, add []Session) error
+}
+
+// JSONStore is the file-backed store used by the cmdry CLI.
+type JSONStore struct {
+	storeAdapter
+}
+
+// MemoryStore keeps sessions in process memory. It is always initialized and
+// is safe for concurrent use.
+type MemoryStore struct {
+	storeAdapter
+}
+
+// Step statuses written by Recorder and the CLI.
+const (
+	StatusOK       = "OK"
+	StatusFailed   = "FAILED"
+	StatusRedacted = "REDACTED"
+)
+
+var (
+	ErrNotInitialized      = store.ErrNotInitialized
+	ErrActiveSessionExists = store.ErrActiveSessionExists
+	ErrNoActiveSession     = store.ErrNoActiveSession
+	ErrNoSessions          = store.ErrNoSessions
+	ErrSessionNotFound     = store.ErrSessionNotFound
+)
+
+// NewJSONStore returns a store rooted at dir. Call Init before first use.
+func NewJSONStore(dir string) *JSONStore {
+	return &JSONStore{storeAdapter{store.NewJSONStore(dir)}}
+}
+
+// NewMemoryStore returns an empty, ready-to-use in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{storeAdapter{store.NewMemoryStore()}}
+}
+
+// DefaultStoreDir resolves the store directory the CLI would use from
+// workingDir: CMDRY_HOME, then the nearest .commandry directory, then the user
+// config directory.
+func DefaultStoreDir(workingDir string) (string, error) {
+	loc, err := store.ResolveLocation("", workingDir)
+	if err != nil {
+		return "", err
+	}
+	return loc.Dir, nil
+}
+
+// storeAdapter implements SessionStore over an internal store, converting
+// sessions at the boundary.
+type storeAdapter struct {
+	s store.SessionStore
+}
+
+func (a storeAdapter) Init(ctx context.Context) error {
+	return a.s.Init(ctx)
+}
+
+func (a storeAdapter) IsInitialized(ctx context.Context) (bool, error) {
+	return a.s.IsInitialized(ctx)
+}
+
+func (a storeAdapter) RootDir() string {
+	return a.s.RootDir()
+}
+
+func (a storeAdapter) StartSession(ctx context.Context, title, env string, startedAt time.Time) (*Session, error) {
+	session, err := a.s.StartSession(ctx, title, env, startedAt, nil)
+	return fromStoreSession(session), err
+}
+
+func (a storeAdapter) GetActiveSession(ctx context.Context) (*Session, error) {
+	session, err := a.s.GetActiveSession(ctx)
+	return fromStoreSession(session), err
+}
+
+func (a storeAdapter) AddStep(ctx context.Context, step Step) error {
+	return a.s.AddStep(ctx, step.toStore())
+}
+
+func (a storeAdapter) StopSession(ctx context.Context, endedAt time.Time) (*Session, error) {
+	session, err := a.s.StopSession(ctx, endedAt)
+	return fromStoreSession(session), err
+}
+
+func (a storeAdapter) LastSession(ctx context.Context) (*Session, error) {
+	session, err := a.s.LastSession(ctx)
+	return fromStoreSession(session), err
+}
+
+func (a storeAdapter) ListSessions(ctx context.Context, limit int) ([]Session, error) {
+	sessions, err := a.s.ListSessions(ctx, limit)
+	if sessions == nil {
+		return nil, err
+	}
+	out := make([]Session, len(sessions))
+	for i := range sessions {
+		out[i] = *fromStoreSession(&sessions[i])
+	}
+	return out, err
+}
+
+func (a storeAdapter) SessionByID(ctx context.Context, id string) (*Session, error) {
+	session, err := a.s.SessionByID(ctx, id)
+	return fromStoreSession(session), err
+}
+
+func (a storeAdapter) UpdateSession(ctx context.Context, id string, fn func(*Session) error) (*Session, error) {
+	session, err := a.s.UpdateSession(ctx, id, func(stored *store.Session) error {
+		updated := fromStoreSession(stored)
+		if err := fn(updated); err != nil {
+			return err
+		}
+		*stored = *updated.toStore()
+		return nil
+	})
+	return fromStoreSession(session), err
+}
+
+func (a storeAdapter) ReplaceSessions(ctx context.Context, removeIDs []string, add []Session) error {
+	sessions := make([]store.Session, len(add))
+	for i := range add {
+		sessions[i] = *add[i].toStore()
+	}
+	return a.s.ReplaceSessions(ctx, removeIDs, sessions)
+}
+
+func fromStoreSession(s *store.Session) *Session {
+	if s == nil {
+		return nil
+	}
+	out := &Session{
+		ID:                s.ID,
+		Title:             s.Title,
+		Env:               s.Env,
+		Tags:              s.Tags,
+		Meta:              s.Meta,
+		StartedAt:         s.StartedAt,
+		EndedAt:           s.EndedAt,
+		PolicyFingerprint: s.PolicyFingerprint,
+		Steps:             make([]Step, len(s.Steps)),
+	}
+	for i, step := range s.Steps {
+		out.Steps[i] = fromStoreStep(step)
+	}
+	return out
+}
+
+func (s *Session) toStore() *store.Session {
+	if s == nil {
+		return nil
+	}
+	out := &store.Session{
+		ID:                s.ID,
+		Title:             s.Title,
+		Env:               s.Env,
+		Tags:              s.Tags,
+		Meta:              s.Meta,
+		StartedAt:         s.StartedAt,
+		EndedAt:           s.EndedAt,
+		PolicyFingerprint: s.PolicyFingerprint,
+		Steps:             make([]store.Step, len(s.Steps)),
+	}
+	for i, step := range s.Steps {
+		out.Steps[i] = step.toStore()
+	}
+	return out
+}
+
+func fromStoreStep(step store.Step) Step {
+	out := Step{
+		Timestamp:         step.Timestamp,
+		Command:           step.Command,
+		Status:            step.Status,
+		Reason:            step.Reason,
+		ExitCode:          step.ExitCode,
+		DurationMS:        step.DurationMS,
+		CWD:               step.CWD,
+		Argv:              step.Argv,
+		PolicyFingerprint: step.PolicyFingerprint,
+	}
+	if step.Guard != nil {
+		out.Guard = &GuardCheck{Rule: step.Guard.Rule, Decision: step.Guard.Decision}
+	}
+	return out
+}
+
+func (step Step) toStore() store.Step {
+	out := store.Step{
+		Timestamp:         step.Timestamp,
+		Command:           step.Command,
+		Status:            step.Status,
+		Reason:            step.Reason,
+		ExitCode:          step.ExitCode,
+		DurationMS:        step.DurationMS,
+		CWD:               step.CWD,
+		Argv:              step.Argv,
+		PolicyFingerprint: step.PolicyFingerprint,
+	}
+	if step.Guard != nil {
+		out.Guard = &store.GuardCheck{Rule: step.Guard.Rule, Decision: step.Guard.Decision}
+	}
+	return out
+}